@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/logger"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/manifest"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/product"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/token"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type ManifestCommandContext struct {
+	Config    *configs.Config
+	Logger    *zap.Logger
+	Connector *connector.Connector
+	Product   *product.Product
+	Token     *token.Token
+	Cmd       *cobra.Command
+	Args      []string
+}
+
+type manifestCmdFunc func(*ManifestCommandContext) error
+
+var manifestFiles []string
+
+func init() {
+
+	RootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringSliceVarP(&manifestFiles, "filename", "f", []string{}, "Load manifests from specific files or directories")
+	applyCmd.MarkFlagRequired("filename")
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update products, rules and tokens from manifests",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runManifestCmd(runApplyCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func runManifestCmd(fn manifestCmdFunc, cmd *cobra.Command, args []string) error {
+
+	var cctx *ManifestCommandContext
+
+	if err := config.SetHost(host); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
+	config.SetDomain(domain)
+	config.SetAccessToken(accessToken)
+	config.SetSecurity(&security)
+
+	app := fx.New(
+		fx.Supply(config),
+		fx.Provide(
+			logger.GetLogger,
+			connector.New,
+			product.New,
+			token.New,
+		),
+		fx.Supply(cmd),
+		fx.Supply(args),
+		fx.Provide(func(
+			config *configs.Config,
+			l *zap.Logger,
+			c *connector.Connector,
+			p *product.Product,
+			t *token.Token,
+			cmd *cobra.Command,
+			args []string,
+		) *ManifestCommandContext {
+			return &ManifestCommandContext{
+				Config:    config,
+				Logger:    l,
+				Connector: c,
+				Product:   p,
+				Token:     t,
+				Cmd:       cmd,
+				Args:      args,
+			}
+		}),
+		fx.Populate(&cctx),
+		fx.NopLogger,
+	)
+
+	if err := app.Start(context.Background()); err != nil {
+		return err
+	}
+
+	// Disconnect from server when command is finished
+	defer app.Stop(context.Background())
+
+	return fn(cctx)
+}
+
+func runApplyCmd(cctx *ManifestCommandContext) error {
+
+	m, err := manifest.Load(manifestFiles...)
+	if err != nil {
+		return err
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	if len(m.Products) > 0 {
+		err := applyProducts(cctx, m.Products)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(m.Tokens) > 0 {
+		err := applyTokens(cctx, m.Tokens)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyProducts(cctx *ManifestCommandContext, products []*manifest.Product) error {
+
+	// Getting existing products
+	list, err := cctx.Product.GetClient().ListProducts()
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*product_sdk.ProductSetting, len(list))
+	for _, p := range list {
+		existing[p.Setting.Name] = p.Setting
+	}
+
+	for _, p := range products {
+
+		stream := fmt.Sprintf(productEventStream, cctx.Connector.GetDomain(), p.Name)
+
+		setting, ok := existing[p.Name]
+		if !ok {
+
+			// Create a new product
+			setting = p.NewSetting(stream)
+
+			_, err := cctx.Product.GetClient().CreateProduct(setting)
+			if err != nil {
+				return fmt.Errorf("failed to create product \"%s\": %w", p.Name, err)
+			}
+
+			fmt.Printf("Product \"%s\" was created\n", p.Name)
+
+			continue
+		}
+
+		// Nothing's changed
+		if !p.ApplyTo(setting, stream) {
+			fmt.Printf("Product \"%s\" is unchanged\n", p.Name)
+			continue
+		}
+
+		_, err := cctx.Product.GetClient().UpdateProduct(p.Name, setting)
+		if err != nil {
+			return fmt.Errorf("failed to update product \"%s\": %w", p.Name, err)
+		}
+
+		fmt.Printf("Product \"%s\" was updated\n", p.Name)
+	}
+
+	return nil
+}
+
+func applyTokens(cctx *ManifestCommandContext, tokens []*manifest.Token) error {
+
+	// Getting existing tokens
+	list, err := cctx.Token.GetClient().ListTokens()
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*token_sdk.TokenSetting, len(list))
+	for _, t := range list {
+		existing[t.ID] = t
+	}
+
+	for _, t := range tokens {
+
+		setting, ok := existing[t.ID]
+		if !ok {
+
+			// Create a new token
+			token, _, err := cctx.Token.GetClient().CreateToken(t.ID, t.NewSetting())
+			if err != nil {
+				return fmt.Errorf("failed to create token \"%s\": %w", t.ID, err)
+			}
+
+			fmt.Printf("Token \"%s\" was created\n", t.ID)
+			fmt.Printf("Token: %s\n", token)
+
+			continue
+		}
+
+		// Nothing's changed
+		if !t.ApplyTo(setting) {
+			fmt.Printf("Token \"%s\" is unchanged\n", t.ID)
+			continue
+		}
+
+		_, err := cctx.Token.GetClient().UpdateToken(t.ID, setting)
+		if err != nil {
+			return fmt.Errorf("failed to update token \"%s\": %w", t.ID, err)
+		}
+
+		fmt.Printf("Token \"%s\" was updated\n", t.ID)
+	}
+
+	return nil
+}
@@ -7,9 +7,9 @@ import (
 	"sync/atomic"
 	"time"
 
-	adapter_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/adapter"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/publisher"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/subscriber"
 	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
-	subscriber_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/subscriber"
 	gravity_sdk_types_product_event "github.com/BrobridgeOrg/gravity-sdk/v2/types/product_event"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
@@ -186,10 +186,10 @@ func doBenchmark(cctx *ProductCommandContext) error {
 	fmt.Printf("Subscribing to product: %s\n", productName)
 
 	// Initializing gravity subscriber
-	opts := subscriber_sdk.NewOptions()
+	opts := subscriber.NewOptions()
 	opts.Domain = cctx.Connector.GetDomain()
-	s := subscriber_sdk.NewSubscriberWithClient("", cctx.Connector.GetClient(), opts)
-	sub, err := s.Subscribe(productName, func(msg *nats.Msg) {
+	opts.DeliverNew = true
+	sub, err := subscriber.Subscribe(cctx.Connector.GetClient(), productName, func(msg *nats.Msg) {
 
 		var pe gravity_sdk_types_product_event.ProductEvent
 
@@ -230,11 +230,13 @@ func doBenchmark(cctx *ProductCommandContext) error {
 			maxLatency = d
 		}
 
+		msg.Ack()
+
 		counter = atomic.AddUint64((*uint64)(&counter), 1)
 		if counter == total {
 			wg.Done()
 		}
-	}, subscriber_sdk.Partition(-1), subscriber_sdk.DeliverNew())
+	}, opts)
 	if err != nil {
 		cctx.Cmd.SilenceUsage = true
 		fmt.Println(err)
@@ -245,16 +247,17 @@ func doBenchmark(cctx *ProductCommandContext) error {
 	wg.Add(1)
 
 	// Connect to gravity network
-	publisher, err := cctx.Connector.CreateClient()
+	client, err := cctx.Connector.CreateClient()
 	if err != nil {
 		cctx.Cmd.SilenceUsage = true
 		return err
 	}
 
-	// Initializing adapter connector
-	aopts := adapter_sdk.NewOptions()
-	aopts.Domain = cctx.Connector.GetDomain()
-	ac := adapter_sdk.NewAdapterConnectorWithClient(publisher, aopts)
+	js, err := client.GetJetStream()
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return err
+	}
 
 	fmt.Println("benchmarking...")
 
@@ -267,7 +270,7 @@ func doBenchmark(cctx *ProductCommandContext) error {
 		startTime = time.Now()
 		for i := uint64(1); i <= total; i++ {
 			payload := []byte(fmt.Sprintf(`{"id":%d,"ts":%d}`, i, time.Now().UnixNano()))
-			_, err = ac.PublishAsync(productName, payload, nil)
+			_, err = js.PublishMsgAsync(publisher.NewMsg(cctx.Connector.GetDomain(), productName, payload, nil))
 			if err != nil {
 				cctx.Cmd.SilenceUsage = true
 				fmt.Println(err)
@@ -276,7 +279,7 @@ func doBenchmark(cctx *ProductCommandContext) error {
 			}
 		}
 
-		<-ac.PublishAsyncComplete()
+		<-js.PublishAsyncComplete()
 
 		sentTime = time.Now()
 	}()
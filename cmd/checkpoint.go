@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/checkpoint"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var checkpointResetPartitions []int
+
+func init() {
+
+	productSubCmd.AddCommand(productSubCheckpointCmd)
+	productSubCheckpointCmd.AddCommand(productSubCheckpointShowCmd)
+	productSubCheckpointCmd.AddCommand(productSubCheckpointResetCmd)
+	productSubCheckpointResetCmd.Flags().IntSliceVar(&checkpointResetPartitions, "partitions", []int{}, "Reset specific partitions only")
+}
+
+var productSubCheckpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "Manage checkpoint files of subscription",
+}
+
+var productSubCheckpointShowCmd = &cobra.Command{
+	Use:   "show [checkpoint file]",
+	Short: "Show positions saved in checkpoint file",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		cp, err := loadCheckpoint(args[0])
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		// Machine-readable output
+		if !printer.IsTable() {
+			return printer.Print(os.Stdout, cp)
+		}
+
+		fmt.Printf("Product: %s\n", cp.Product)
+		fmt.Printf("Domain: %s\n", cp.Domain)
+		fmt.Printf("Received Sequence of All Partitions: %d\n", cp.Covered)
+		fmt.Printf("Resume Sequence: %d\n", cp.StartSequence([]int{-1}))
+		fmt.Printf("Updated At: %s\n", cp.UpdatedAt.Format(time.RFC3339))
+		fmt.Println()
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{
+			"Partition",
+			"Sequence",
+			"Consumer Sequence",
+			"Updated At",
+		})
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(true)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetCenterSeparator("")
+		table.SetColumnSeparator("")
+		table.SetRowSeparator("-")
+		table.SetHeaderLine(true)
+		table.SetBorder(false)
+		table.SetTablePadding("\t")
+		table.SetNoWhiteSpace(true)
+
+		for _, name := range cp.PartitionNames() {
+
+			pos := cp.Partitions[name]
+
+			table.Append([]string{
+				name,
+				strconv.FormatUint(pos.Sequence, 10),
+				strconv.FormatUint(pos.ConsumerSequence, 10),
+				pos.UpdatedAt.Format(time.RFC3339),
+			})
+		}
+
+		table.Render()
+
+		return nil
+	},
+}
+
+var productSubCheckpointResetCmd = &cobra.Command{
+	Use:   "reset [checkpoint file]",
+	Short: "Reset positions of checkpoint file so that subscription starts over",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		cp, err := loadCheckpoint(args[0])
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		// Remove the whole file
+		if len(checkpointResetPartitions) == 0 {
+			err = os.Remove(cp.GetPath())
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Checkpoint \"%s\" was reset\n", cp.GetPath())
+
+			return nil
+		}
+
+		cp.Reset(checkpointResetPartitions)
+
+		err = cp.Save()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Partitions %v of checkpoint \"%s\" were reset\n", checkpointResetPartitions, cp.GetPath())
+
+		return nil
+	},
+}
+
+func loadCheckpoint(filename string) (*checkpoint.Checkpoint, error) {
+
+	if _, err := os.Stat(filename); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.New(fmt.Sprintf("Not found checkpoint \"%s\"\n", filename))
+		}
+
+		return nil, err
+	}
+
+	return checkpoint.Load(filename)
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+
+	RootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextSetCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+	contextCmd.AddCommand(contextCurrentCmd)
+}
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage named connection contexts",
+	Long: `Manage named connection contexts which are stored in per-user config file.
+
+Connection settings of context can be set with global flags:
+
+  gravity-cli context set production --host 10.0.0.1:32803 --domain production --token xxx`,
+
+	// Context commands have nothing to do with connection
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available contexts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		uc, err := configs.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		// Machine-readable output, secrets are never printed
+		if !printer.IsTable() {
+			contexts := make(map[string]*configs.Context, len(uc.Contexts))
+			for name, ctx := range uc.Contexts {
+				contexts[name] = ctx.Redacted()
+			}
+
+			return printer.Print(os.Stdout, contexts)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{
+			"Current",
+			"Name",
+			"Host",
+			"Domain",
+		})
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(true)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetCenterSeparator("")
+		table.SetColumnSeparator("")
+		table.SetRowSeparator("-")
+		table.SetHeaderLine(true)
+		table.SetBorder(false)
+		table.SetTablePadding("\t")
+		table.SetNoWhiteSpace(true)
+
+		for _, name := range uc.ContextNames() {
+
+			ctx := uc.Contexts[name].Redacted()
+
+			current := ""
+			if name == uc.CurrentContext {
+				current = "*"
+			}
+
+			table.Append([]string{
+				current,
+				name,
+				ctx.Host,
+				ctx.Domain,
+			})
+		}
+
+		table.Render()
+
+		return nil
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use [context name]",
+	Short: "Switch current context",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		uc, err := configs.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		err = uc.UseContext(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = uc.Save()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Switched to context \"%s\"\n", args[0])
+
+		return nil
+	},
+}
+
+var contextSetCmd = &cobra.Command{
+	Use:   "set [context name]",
+	Short: "Create or update a context with global connection flags",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		uc, err := configs.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		name := args[0]
+		ctx, err := uc.GetContext(name)
+		if err != nil {
+			ctx = &configs.Context{}
+		}
+
+		// Invalid addresses are rejected before saving context
+		if cmd.Flags().Changed("host") {
+			if _, err := configs.ParseServers(host); err != nil {
+				return err
+			}
+
+			ctx.Host = host
+		}
+
+		if cmd.Flags().Changed("domain") {
+			ctx.Domain = domain
+		}
+
+		if cmd.Flags().Changed("token") {
+			ctx.AccessToken = accessToken
+		}
+
+		// Security settings
+		if cmd.Flags().Changed("tls") {
+			ctx.TLS = security.TLS
+		}
+
+		if cmd.Flags().Changed("tls-ca") {
+			ctx.CAFile = security.CAFile
+		}
+
+		if cmd.Flags().Changed("tls-cert") {
+			ctx.CertFile = security.CertFile
+		}
+
+		if cmd.Flags().Changed("tls-key") {
+			ctx.KeyFile = security.KeyFile
+		}
+
+		if cmd.Flags().Changed("tls-insecure") {
+			ctx.InsecureSkipVerify = security.InsecureSkipVerify
+		}
+
+		if cmd.Flags().Changed("creds") {
+			ctx.CredsFile = security.CredsFile
+		}
+
+		if cmd.Flags().Changed("nkey") {
+			ctx.NKeyFile = security.NKeyFile
+		}
+
+		if cmd.Flags().Changed("user") {
+			ctx.User = security.User
+		}
+
+		if cmd.Flags().Changed("password") {
+			ctx.Password = security.Password
+		}
+
+		uc.SetContext(name, ctx)
+
+		// Use it if there is no current context
+		if len(uc.CurrentContext) == 0 {
+			uc.CurrentContext = name
+		}
+
+		err = uc.Save()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Context \"%s\" was saved to %s\n", name, uc.GetPath())
+
+		return nil
+	},
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:   "delete [context name]",
+	Short: "Delete a context",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		uc, err := configs.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		err = uc.DeleteContext(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = uc.Save()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Context \"%s\" was deleted\n", args[0])
+
+		return nil
+	},
+}
+
+var contextCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show current context",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		uc, err := configs.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		if len(uc.CurrentContext) == 0 {
+			return fmt.Errorf("current context is not set")
+		}
+
+		fmt.Println(uc.CurrentContext)
+
+		return nil
+	},
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/manifest"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+	"github.com/spf13/cobra"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBold   = "\033[1m"
+)
+
+var ErrDriftDetected = errors.New("drift detected between manifests and cluster")
+
+var diffNoColor bool
+
+func init() {
+
+	RootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringSliceVarP(&manifestFiles, "filename", "f", []string{}, "Load manifests from specific files or directories")
+	diffCmd.Flags().BoolVar(&diffNoColor, "no-color", false, "Disable colored output")
+	diffCmd.MarkFlagRequired("filename")
+}
+
+var diffCmd = &cobra.Command{
+	Use:     "diff",
+	Aliases: []string{"plan"},
+	Short:   "Show differences between manifests and cluster",
+	Long: `Show field-level differences between manifests and cluster.
+
+Exit status is 0 if there are no differences, 2 if drift was detected and 1
+for other errors, like terraform plan -detailed-exitcode.
+
+Examples:
+  gravity-cli diff -f scripts/manifests/
+  gravity-cli plan -f accounts.yaml --no-color`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runManifestCmd(runDiffCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+type diffPrinter struct {
+	color bool
+}
+
+func (p *diffPrinter) colorize(color string, s string) string {
+
+	if !p.color {
+		return s
+	}
+
+	return color + s + colorReset
+}
+
+func (p *diffPrinter) printHeader(title string) {
+	fmt.Println(p.colorize(colorBold, title))
+}
+
+func (p *diffPrinter) printChanges(changes []*manifest.Change) {
+
+	for _, c := range changes {
+
+		switch c.Type {
+		case manifest.ChangeAdded:
+			fmt.Println(p.colorize(colorGreen, fmt.Sprintf("  + %s: %s", c.Path, formatDiffValue(c.Desired))))
+		case manifest.ChangeRemoved:
+			fmt.Println(p.colorize(colorRed, fmt.Sprintf("  - %s: %s", c.Path, formatDiffValue(c.Live))))
+		case manifest.ChangeModified:
+
+			if !c.IsText() {
+				fmt.Println(p.colorize(colorYellow, fmt.Sprintf("  ~ %s: %s => %s", c.Path, formatDiffValue(c.Live), formatDiffValue(c.Desired))))
+				continue
+			}
+
+			fmt.Println(p.colorize(colorYellow, fmt.Sprintf("  ~ %s:", c.Path)))
+			for _, line := range strings.Split(strings.TrimRight(c.TextDiff(), "\n"), "\n") {
+
+				color := ""
+				switch {
+				case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+					color = colorBold
+				case strings.HasPrefix(line, "+"):
+					color = colorGreen
+				case strings.HasPrefix(line, "-"):
+					color = colorRed
+				}
+
+				if len(color) > 0 {
+					line = p.colorize(color, line)
+				}
+
+				fmt.Println("      " + line)
+			}
+		}
+	}
+}
+
+func formatDiffValue(v interface{}) string {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+func isTerminal(f *os.File) bool {
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func runDiffCmd(cctx *ManifestCommandContext) error {
+
+	m, err := manifest.Load(manifestFiles...)
+	if err != nil {
+		return err
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	p := &diffPrinter{
+		color: !diffNoColor && isTerminal(os.Stdout),
+	}
+
+	drifted := 0
+
+	// Products
+	if len(m.Products) > 0 {
+
+		list, err := cctx.Product.GetClient().ListProducts()
+		if err != nil {
+			return err
+		}
+
+		live := make(map[string]*product_sdk.ProductSetting, len(list))
+		for _, info := range list {
+			live[info.Setting.Name] = info.Setting
+		}
+
+		for _, product := range m.Products {
+
+			stream := fmt.Sprintf(productEventStream, cctx.Connector.GetDomain(), product.Name)
+			changes := product.Diff(live[product.Name], stream)
+			if len(changes) == 0 {
+				continue
+			}
+
+			drifted++
+			p.printHeader(fmt.Sprintf("product \"%s\"", product.Name))
+			p.printChanges(changes)
+			fmt.Println("")
+		}
+	}
+
+	// Tokens
+	if len(m.Tokens) > 0 {
+
+		list, err := cctx.Token.GetClient().ListTokens()
+		if err != nil {
+			return err
+		}
+
+		live := make(map[string]*token_sdk.TokenSetting, len(list))
+		for _, t := range list {
+			live[t.ID] = t
+		}
+
+		for _, t := range m.Tokens {
+
+			changes := t.Diff(live[t.ID])
+			if len(changes) == 0 {
+				continue
+			}
+
+			drifted++
+			p.printHeader(fmt.Sprintf("token \"%s\"", t.ID))
+			p.printChanges(changes)
+			fmt.Println("")
+		}
+	}
+
+	if drifted > 0 {
+		cctx.Cmd.SilenceErrors = true
+		fmt.Fprintf(os.Stderr, "%d resource(s) drifted\n", drifted)
+		return ErrDriftDetected
+	}
+
+	fmt.Println("No differences found")
+
+	return nil
+}
@@ -53,9 +53,14 @@ func runDomainCmd(fn domainCmdFunc, cmd *cobra.Command, args []string) error {
 
 	var cctx *DomainCommandContext
 
-	config.SetHost(host)
+	if err := config.SetHost(host); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
 	config.SetDomain(domain)
 	config.SetAccessToken(accessToken)
+	config.SetSecurity(&security)
 
 	app := fx.New(
 		fx.Supply(config),
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var exportDir string
+
+func init() {
+
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVar(&exportDir, "dir", "", "Specify output directory")
+	exportCmd.MarkFlagRequired("dir")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export products, rules and tokens of domain to manifests",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runManifestCmd(runExportCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func runExportCmd(cctx *ManifestCommandContext) error {
+
+	cctx.Cmd.SilenceUsage = true
+
+	// Products
+	products, err := cctx.Product.GetClient().ListProducts()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range products {
+
+		p := manifest.FromProductSetting(info.Setting)
+
+		dir := filepath.Join(exportDir, "products", manifest.SafeFilename(p.Name))
+		filename, err := p.Export(dir)
+		if err != nil {
+			return fmt.Errorf("failed to export product \"%s\": %w", p.Name, err)
+		}
+
+		fmt.Printf("Product \"%s\" was exported to %s\n", p.Name, filename)
+	}
+
+	// Tokens
+	tokens, err := cctx.Token.GetClient().ListTokens()
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range tokens {
+
+		t := manifest.FromTokenSetting(setting)
+
+		filename, err := t.Export(filepath.Join(exportDir, "tokens"))
+		if err != nil {
+			return fmt.Errorf("failed to export token \"%s\": %w", t.ID, err)
+		}
+
+		fmt.Printf("Token \"%s\" was exported to %s\n", t.ID, filename)
+	}
+
+	return nil
+}
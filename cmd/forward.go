@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/hook"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/webhook"
+	"github.com/spf13/cobra"
+)
+
+var productForwardURL string
+var productForwardHeaders []string
+var productForwardSecret string
+var productForwardSignatureHeader string
+var productForwardBatch int
+var productForwardBatchWait time.Duration
+var productForwardConcurrency int
+var productForwardRetries int
+var productForwardBackoff time.Duration
+var productForwardMaxBackoff time.Duration
+var productForwardRequestTimeout time.Duration
+
+func init() {
+
+	productCmd.AddCommand(productForwardCmd)
+	productForwardCmd.Flags().StringVar(&productForwardURL, "url", "", "Specify URL of webhook")
+	productForwardCmd.Flags().StringArrayVar(&productForwardHeaders, "header", []string{}, `Add header to requests in key=value format, e.g. "Authorization=Bearer xxx" (can be specified multiple times)`)
+	productForwardCmd.Flags().StringVar(&productForwardSecret, "secret", "", "Sign request body with HMAC-SHA256 using specific secret")
+	productForwardCmd.Flags().StringVar(&productForwardSignatureHeader, "signature-header", webhook.DefaultSignatureHeader, "Header of signature")
+	productForwardCmd.Flags().IntVar(&productForwardBatch, "batch", 1, "Post batch of events in JSON array")
+	productForwardCmd.Flags().DurationVar(&productForwardBatchWait, "batch-wait", time.Second, "Post incomplete batch after specific duration")
+	productForwardCmd.Flags().IntVar(&productForwardConcurrency, "concurrency", hook.DefaultConcurrency, "Maximum number of requests at the same time")
+	productForwardCmd.Flags().IntVar(&productForwardRetries, "retries", hook.DefaultRetries, "Number of retries if request failed")
+	productForwardCmd.Flags().DurationVar(&productForwardBackoff, "backoff", hook.DefaultBackoff, "Initial delay of retry, it's doubled for each retry")
+	productForwardCmd.Flags().DurationVar(&productForwardMaxBackoff, "max-backoff", hook.DefaultMaxBackoff, "Maximum delay of retry")
+	productForwardCmd.Flags().DurationVar(&productForwardRequestTimeout, "request-timeout", 10*time.Second, "Timeout of each request")
+
+	// Subscription
+	productForwardCmd.Flags().StringVar(&productSubscriberName, "name", "", "Specify subscriber name")
+	productForwardCmd.Flags().Uint64Var(&productSubscriberStartSeq, "seq", 1, "Specify start stream sequence")
+	productForwardCmd.Flags().StringVar(&productSubscriberSince, "since", "", "Start from events since specific time (RFC3339) or duration ago, e.g. 2024-01-02T09:00:00+08:00 or 5m")
+	productForwardCmd.Flags().Uint64Var(&productSubscriberLast, "last", 0, "Start from the last N events of stream")
+	productForwardCmd.Flags().BoolVar(&productSubscriberNew, "new", false, "Forward new events only")
+	productForwardCmd.Flags().IntSliceVar(&productSubscriberPartitions, "partitions", []int{-1}, "Specify partitions (default -1 for all)")
+	productForwardCmd.Flags().Uint64Var(&productSubscriberCount, "count", 0, "Exit after forwarding specific number of events")
+	productForwardCmd.Flags().DurationVar(&productSubscriberTimeout, "timeout", 0, "Exit after specific duration")
+	productForwardCmd.Flags().Uint64Var(&productSubscriberUntilSeq, "until-seq", 0, "Exit after forwarding event with specific stream sequence or later")
+	productForwardCmd.Flags().DurationVar(&productSubscriberIdleTimeout, "idle-timeout", 0, "Exit if no events were received for specific duration")
+	productForwardCmd.Flags().StringVar(&productFilter, "filter", "", `Forward events which match specific jq expression only, e.g. '.method == "DELETE"'`)
+	productForwardCmd.Flags().StringVar(&productSelect, "select", "", "Forward result of specific jq expression instead of events, e.g. '.payload'")
+	productForwardCmd.Flags().StringVar(&productSubscriberCheckpoint, "checkpoint", "", "Save position to file and resume from it on restart")
+	productForwardCmd.Flags().DurationVar(&productSubscriberCheckpointInterval, "checkpoint-interval", time.Second, "Interval of saving checkpoint")
+	productForwardCmd.MarkFlagRequired("url")
+}
+
+var productForwardCmd = &cobra.Command{
+	Use:   "forward [product name]",
+	Short: "Forward events of product to HTTP webhook",
+	Long: `Forward events of product to HTTP webhook.
+
+Each event is posted in JSON, or with --batch, batch of events is posted in
+JSON array. Events are acknowledged only after server responded with 2xx
+status, otherwise the request is retried with exponential backoff and events
+are redelivered after retries are exhausted. Events which are waiting for
+requests or retries are marked in progress, so they are not redelivered and
+posted twice. Events are not forwarded in order if --concurrency is greater
+than 1.
+
+Metadata is sent in headers: X-Gravity-Product, X-Gravity-Seq (stream
+sequence), X-Gravity-Subject, X-Gravity-Partition, X-Gravity-Event,
+X-Gravity-Method, X-Gravity-Primary-Keys (names) and X-Gravity-Primary-Key
+(JSON of values). Batches have X-Gravity-Batch-Size, X-Gravity-First-Seq and
+X-Gravity-Last-Seq instead.
+
+With --secret, request body is signed with HMAC-SHA256 and the signature is
+sent in X-Gravity-Signature as "sha256=<hex>".
+
+Start position, exit conditions, checkpoint and jq expressions are the same as
+"product sub".
+
+Examples:
+  gravity-cli product forward accounts --url http://localhost:8080/events
+  gravity-cli product forward accounts --url https://example.com/hook --secret xxx --header "Authorization=Bearer xxx"
+  gravity-cli product forward accounts --url http://localhost:8080/events --batch 100 --concurrency 4 --name forwarder
+  gravity-cli product forward accounts --url http://localhost:8080/events --select '.payload' --checkpoint accounts.checkpoint`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductSubCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func newProductWebhook() (*eventHook, error) {
+
+	if productForwardBatch <= 0 {
+		return nil, errors.New("--batch must be greater than 0")
+	}
+
+	headers, err := webhook.ParseHeaders(productForwardHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	whOpts := webhook.NewOptions()
+	whOpts.URL = productForwardURL
+	whOpts.Headers = headers
+	whOpts.Secret = productForwardSecret
+	whOpts.SignatureHeader = productForwardSignatureHeader
+	whOpts.Client = &http.Client{}
+
+	wh, err := webhook.New(whOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := hook.NewOptions()
+	opts.Name = "Request"
+	opts.Concurrency = productForwardConcurrency
+	opts.Retries = productForwardRetries
+	opts.Backoff = productForwardBackoff
+	opts.MaxBackoff = productForwardMaxBackoff
+	opts.Timeout = productForwardRequestTimeout
+
+	return newWebhook(wh, opts, productForwardBatch), nil
+}
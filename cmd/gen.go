@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/generator"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/publisher"
+	"github.com/spf13/cobra"
+)
+
+var genTemplateFile string
+var genCount uint64
+var genStart uint64
+var genRate float64
+var genConcurrency int
+var genNoProgress bool
+
+func init() {
+
+	pubCmd.AddCommand(pubGenCmd)
+	pubGenCmd.Flags().StringVar(&genTemplateFile, "template", "", `Load payload template from specific file ("-" for stdin)`)
+	pubGenCmd.Flags().Uint64Var(&genCount, "count", 10, "Number of events to be published (0 for unlimited)")
+	pubGenCmd.Flags().Uint64Var(&genStart, "start", 1, "Start sequence number")
+	pubGenCmd.Flags().Float64Var(&genRate, "rate", 0, "Maximum events per second (0 for unlimited)")
+	pubGenCmd.Flags().IntVar(&genConcurrency, "concurrency", 1, "Number of workers generating events")
+	pubGenCmd.Flags().IntVar(&pubMaxInflight, "max-inflight", publisher.DefaultMaxInflight, "Maximum number of messages waiting for acknowledgement")
+	pubGenCmd.Flags().StringArrayVar(&pubHeaders, "header", []string{}, "Custom header in key=value format (can be specified multiple times)")
+	pubGenCmd.Flags().BoolVar(&genNoProgress, "no-progress", false, "Disable live progress")
+	pubGenCmd.MarkFlagRequired("template")
+}
+
+var pubGenCmd = &cobra.Command{
+	Use:   "gen [event]",
+	Short: "Generate and publish synthetic domain events from template",
+	Long: `Generate and publish synthetic domain events from Go template.
+
+Functions available in template:
+  seq                    sequence number of current event
+  randInt min max        random integer in [min, max]
+  randFloat min max      random float in [min, max)
+  randString n           random alphanumeric string with length n
+  randBool               random boolean
+  uuid                   random UUID
+  now                    current time in RFC3339 format
+  unix / unixMilli       current Unix time in seconds / milliseconds
+  timestamp layout       current time in specific Go layout
+  pick a b ...           random item of arguments
+  json v                 value encoded in JSON
+
+Template example:
+  {"id":{{ seq }},"name":"{{ randString 8 }}","type":{{ pick "personal" "business" | json }},"created_at":"{{ now }}"}
+
+An event named "gen" is published by "gravity-cli pub -- gen [payload]"
+instead of this command.
+
+Examples:
+  gravity-cli pub gen accountCreated --template account.tmpl --count 100000 --rate 5000 --concurrency 4`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runPubCmd(runPubGenCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func runPubGenCmd(cctx *PubCommandContext) error {
+
+	event := cctx.Args[0]
+
+	if genConcurrency <= 0 {
+		return errors.New("concurrency should be greater than 0")
+	}
+
+	meta, err := parseHeaders(pubHeaders)
+	if err != nil {
+		return err
+	}
+
+	// Load template
+	input, err := openInput(genTemplateFile)
+	if err != nil {
+		return err
+	}
+
+	text, err := io.ReadAll(input)
+	input.Close()
+	if err != nil {
+		return err
+	}
+
+	g, err := generator.Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	// Initializing publisher
+	opts := publisher.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+	opts.MaxInflight = pubMaxInflight
+	opts.Rate = genRate
+	opts.ErrorHandler = func(event string, err error) {
+		fmt.Fprintf(os.Stderr, "\nFailed to publish event \"%s\": %v\n", event, err)
+	}
+
+	p, err := publisher.New(cctx.Connector.GetClient(), opts)
+	if err != nil {
+		return err
+	}
+
+	// Stop generating on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	seqs := make(chan uint64, genConcurrency)
+	go func() {
+		defer close(seqs)
+
+		for i := uint64(0); genCount == 0 || i < genCount; i++ {
+			select {
+			case seqs <- genStart + i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var generated uint64
+	var wg sync.WaitGroup
+	for i := 0; i < genConcurrency; i++ {
+
+		w, err := g.Clone()
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for seq := range seqs {
+
+				payload, err := w.Generate(seq)
+				if err != nil {
+					p.Fail(event, fmt.Errorf("sequence %d: %w", seq, err))
+					continue
+				}
+
+				atomic.AddUint64(&generated, 1)
+				p.Publish(event, payload, meta)
+			}
+		}()
+	}
+
+	// Live progress
+	done := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+
+		if genNoProgress || !isTerminal(os.Stderr) {
+			<-done
+			return
+		}
+
+		startTime := time.Now()
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				printGenProgress(p.Stats(), atomic.LoadUint64(&generated), time.Since(startTime))
+			case <-done:
+				printGenProgress(p.Stats(), atomic.LoadUint64(&generated), time.Since(startTime))
+				fmt.Fprintln(os.Stderr)
+				return
+			}
+		}
+	}()
+
+	startTime := time.Now()
+	wg.Wait()
+	stats := p.Close()
+	elapsed := time.Since(startTime)
+
+	close(done)
+	<-progressDone
+
+	fmt.Printf("Published: %d, Failed: %d, Elapsed: %s, Rate: %.1f msg/s\n",
+		stats.Published,
+		stats.Failed,
+		elapsed.Round(time.Millisecond),
+		float64(stats.Published)/elapsed.Seconds(),
+	)
+
+	if stats.Failed > 0 {
+		return fmt.Errorf("failed to publish %d messages", stats.Failed)
+	}
+
+	return nil
+}
+
+func printGenProgress(stats publisher.Stats, generated uint64, elapsed time.Duration) {
+
+	total := "unlimited"
+	if genCount > 0 {
+		total = fmt.Sprintf("%d", genCount)
+	}
+
+	fmt.Fprintf(os.Stderr, "\rGenerated: %d/%s, Published: %d, Failed: %d, Rate: %.1f msg/s   ",
+		generated,
+		total,
+		stats.Published,
+		stats.Failed,
+		float64(stats.Published)/elapsed.Seconds(),
+	)
+}
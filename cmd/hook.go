@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/hook"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/subscriber"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/webhook"
+	"github.com/nats-io/nats.go"
+)
+
+// hookItem is an event which is waiting for hook
+type hookItem struct {
+	msg     *nats.Msg
+	seq     uint64
+	event   map[string]interface{}
+	outputs []interface{}
+	ack     func()
+}
+
+// hookField is metadata of events, it's passed as environment variable
+// (GRAVITY_PRIMARY_KEY) to commands and header (X-Gravity-Primary-Key) to
+// webhooks.
+type hookField struct {
+	name  string
+	value string
+}
+
+func (f hookField) Env() string {
+	return "GRAVITY_" + strings.ToUpper(strings.ReplaceAll(f.name, "-", "_")) + "=" + f.value
+}
+
+func (f hookField) Header() string {
+	return "X-Gravity-" + f.name
+}
+
+// eventHook runs job for each event or batch of events, events are
+// acknowledged by done handler if job succeeded. Events are marked in progress
+// until they are done, so that they are not redelivered while waiting for
+// executor or retries.
+type eventHook struct {
+	executor  *hook.Executor
+	batchSize int
+	job       func(items []*hookItem, fields []hookField) *hook.Job
+	done      func(items []*hookItem, err error)
+	progress  *subscriber.Progress
+
+	// Jobs are queued in order and submitted by dispatcher, so that flushing
+	// never blocks while executor is busy.
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	batch      []*hookItem
+	queue      []*hook.Job
+	closed     bool
+	dispatched chan struct{}
+}
+
+func newEventHook(executor *hook.Executor, batchSize int, job func(items []*hookItem, fields []hookField) *hook.Job) *eventHook {
+
+	h := &eventHook{
+		executor:   executor,
+		batchSize:  batchSize,
+		job:        job,
+		progress:   subscriber.NewProgress(subscriber.ProgressInterval),
+		dispatched: make(chan struct{}),
+	}
+
+	h.cond = sync.NewCond(&h.mutex)
+
+	go h.dispatch()
+
+	return h
+}
+
+// newCommandHook runs shell command with events in NDJSON on stdin
+func newCommandHook(opts *hook.Options, batchSize int) *eventHook {
+
+	return newEventHook(hook.New(opts), batchSize,
+		func(items []*hookItem, fields []hookField) *hook.Job {
+
+			var input bytes.Buffer
+			for _, item := range items {
+				for _, output := range item.outputs {
+					data, _ := json.Marshal(output)
+					input.Write(data)
+					input.WriteByte('\n')
+				}
+			}
+
+			env := make([]string, len(fields))
+			for i, f := range fields {
+				env[i] = f.Env()
+			}
+
+			return &hook.Job{
+				Input: input.Bytes(),
+				Env:   env,
+			}
+		},
+	)
+}
+
+// newWebhook posts event in JSON, or array of events for batch
+func newWebhook(wh *webhook.Webhook, opts *hook.Options, batchSize int) *eventHook {
+
+	return newEventHook(hook.New(opts), batchSize,
+		func(items []*hookItem, fields []hookField) *hook.Job {
+
+			var outputs []interface{}
+			for _, item := range items {
+				outputs = append(outputs, item.outputs...)
+			}
+
+			var body []byte
+			if batchSize == 1 && len(outputs) == 1 {
+				body, _ = json.Marshal(outputs[0])
+			} else {
+				body, _ = json.Marshal(outputs)
+			}
+
+			headers := make(http.Header)
+			for _, f := range fields {
+				headers.Set(f.Header(), f.value)
+			}
+
+			return &hook.Job{
+				Run: func(ctx context.Context) error {
+					return wh.Post(ctx, body, headers)
+				},
+			}
+		},
+	)
+}
+
+// Add adds event to batch, it blocks while the previous batch is waiting for
+// executor so that events are not buffered without limit.
+func (h *eventHook) Add(item *hookItem) {
+
+	h.progress.Add(item.msg)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.batch = append(h.batch, item)
+	if len(h.batch) < h.batchSize {
+		return
+	}
+
+	for len(h.queue) > 0 && !h.closed {
+		h.cond.Wait()
+	}
+
+	h.enqueue()
+}
+
+// Flush queues job for events which are waiting in batch, it doesn't block
+func (h *eventHook) Flush() {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.enqueue()
+}
+
+func (h *eventHook) enqueue() {
+
+	if len(h.batch) == 0 {
+		return
+	}
+
+	items := h.batch
+	h.batch = nil
+
+	var fields []hookField
+	if h.batchSize > 1 {
+		fields = []hookField{
+			{"Product", productName},
+			{"Batch-Size", fmt.Sprint(len(items))},
+			{"First-Seq", fmt.Sprint(items[0].seq)},
+			{"Last-Seq", fmt.Sprint(items[len(items)-1].seq)},
+		}
+	} else {
+		fields = hookEventFields(items[0])
+	}
+
+	job := h.job(items, fields)
+	job.Done = func(err error) {
+
+		for _, item := range items {
+			h.progress.Done(item.msg)
+		}
+
+		h.done(items, err)
+	}
+
+	h.queue = append(h.queue, job)
+	h.cond.Broadcast()
+}
+
+// dispatch submits queued jobs in order until hook is closed
+func (h *eventHook) dispatch() {
+
+	defer close(h.dispatched)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for {
+		for len(h.queue) == 0 && !h.closed {
+			h.cond.Wait()
+		}
+
+		if len(h.queue) == 0 {
+			return
+		}
+
+		job := h.queue[0]
+		h.queue = h.queue[1:]
+		h.cond.Broadcast()
+
+		// Executor blocks while all slots are busy
+		h.mutex.Unlock()
+		h.executor.Submit(job)
+		h.mutex.Lock()
+	}
+}
+
+// hookEventFields returns metadata of event
+func hookEventFields(item *hookItem) []hookField {
+
+	fields := []hookField{
+		{"Product", productName},
+		{"Seq", fmt.Sprint(item.seq)},
+		{"Subject", item.msg.Subject},
+	}
+
+	if partition, err := subscriber.Partition(item.msg.Subject); err == nil {
+		fields = append(fields, hookField{"Partition", fmt.Sprint(partition)})
+	}
+
+	if event, ok := item.event["event"].(string); ok {
+		fields = append(fields, hookField{"Event", event})
+	}
+
+	if method, ok := item.event["method"].(string); ok {
+		fields = append(fields, hookField{"Method", method})
+	}
+
+	// Names and values of primary key
+	keys, _ := item.event["primaryKey"].([]string)
+	payload, _ := item.event["payload"].(map[string]interface{})
+	if len(keys) > 0 {
+		values := make(map[string]interface{}, len(keys))
+		for _, k := range keys {
+			values[k] = payload[k]
+		}
+
+		data, _ := json.Marshal(values)
+		fields = append(fields,
+			hookField{"Primary-Keys", strings.Join(keys, ",")},
+			hookField{"Primary-Key", string(data)},
+		)
+	}
+
+	return fields
+}
+
+// Close submits queued jobs, then stops retrying and waits for running jobs
+func (h *eventHook) Close() {
+
+	h.mutex.Lock()
+	h.closed = true
+	h.cond.Broadcast()
+	h.mutex.Unlock()
+
+	<-h.dispatched
+
+	h.executor.Close()
+	h.progress.Stop()
+}
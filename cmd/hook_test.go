@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/hook"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/webhook"
+	"github.com/nats-io/nats.go"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newTestWebhookHook(t *testing.T, batchSize int) (*eventHook, *[]webhookRequest, *[]uint64, func()) {
+
+	var mutex sync.Mutex
+	var requests []webhookRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mutex.Lock()
+		requests = append(requests, webhookRequest{header: r.Header.Clone(), body: body})
+		mutex.Unlock()
+	}))
+
+	whOpts := webhook.NewOptions()
+	whOpts.URL = server.URL
+
+	wh, err := webhook.New(whOpts)
+	if err != nil {
+		t.Fatalf("webhook.New: %v", err)
+	}
+
+	opts := hook.NewOptions()
+	opts.Name = "Request"
+
+	var acked []uint64
+	h := newWebhook(wh, opts, batchSize)
+	h.done = func(items []*hookItem, err error) {
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+			return
+		}
+
+		mutex.Lock()
+		for _, item := range items {
+			acked = append(acked, item.seq)
+		}
+		mutex.Unlock()
+	}
+
+	return h, &requests, &acked, server.Close
+}
+
+func newTestHookItem(seq uint64) *hookItem {
+	return &hookItem{
+		msg: &nats.Msg{Subject: "$GVT.default.DP.accounts.0.EVENT.accountCreated"},
+		seq: seq,
+		event: map[string]interface{}{
+			"event":      "accountCreated",
+			"method":     "create",
+			"primaryKey": []string{"id"},
+			"payload":    map[string]interface{}{"id": seq},
+		},
+		outputs: []interface{}{map[string]interface{}{"id": seq}},
+	}
+}
+
+func TestWebhookBatch(t *testing.T) {
+
+	productName = "accounts"
+
+	tests := []struct {
+		batchSize int
+		items     int
+		sizes     []int
+	}{
+		{1, 2, []int{1, 1}},
+		{3, 7, []int{3, 3, 1}},
+		{10, 4, []int{4}},
+	}
+
+	for _, tt := range tests {
+
+		h, requests, acked, closeServer := newTestWebhookHook(t, tt.batchSize)
+
+		for i := 1; i <= tt.items; i++ {
+			h.Add(newTestHookItem(uint64(i)))
+		}
+
+		h.Flush()
+		h.Close()
+		closeServer()
+
+		if len(*requests) != len(tt.sizes) {
+			t.Fatalf("batch %d: %d requests, want %d", tt.batchSize, len(*requests), len(tt.sizes))
+		}
+
+		seq := 1
+		for i, req := range *requests {
+
+			// Single event is posted as object, batch as array
+			if tt.batchSize == 1 {
+				var event map[string]interface{}
+				if err := json.Unmarshal(req.body, &event); err != nil {
+					t.Errorf("batch %d: request %d is not an object: %s", tt.batchSize, i, req.body)
+				}
+
+				if got := req.header.Get("X-Gravity-Seq"); got != jsonString(seq) {
+					t.Errorf("batch %d: X-Gravity-Seq = %q, want %d", tt.batchSize, got, seq)
+				}
+
+				if got := req.header.Get("X-Gravity-Primary-Key"); got != `{"id":`+jsonString(seq)+`}` {
+					t.Errorf("batch %d: X-Gravity-Primary-Key = %q", tt.batchSize, got)
+				}
+
+				seq++
+				continue
+			}
+
+			var events []map[string]interface{}
+			if err := json.Unmarshal(req.body, &events); err != nil {
+				t.Fatalf("batch %d: request %d is not an array: %s", tt.batchSize, i, req.body)
+			}
+
+			if len(events) != tt.sizes[i] {
+				t.Errorf("batch %d: request %d has %d events, want %d", tt.batchSize, i, len(events), tt.sizes[i])
+			}
+
+			for name, want := range map[string]string{
+				"X-Gravity-Product":    "accounts",
+				"X-Gravity-Batch-Size": jsonString(tt.sizes[i]),
+				"X-Gravity-First-Seq":  jsonString(seq),
+				"X-Gravity-Last-Seq":   jsonString(seq + tt.sizes[i] - 1),
+			} {
+				if got := req.header.Get(name); got != want {
+					t.Errorf("batch %d: request %d: %s = %q, want %q", tt.batchSize, i, name, got, want)
+				}
+			}
+
+			seq += tt.sizes[i]
+		}
+
+		if len(*acked) != tt.items {
+			t.Errorf("batch %d: %d events acknowledged, want %d", tt.batchSize, len(*acked), tt.items)
+		}
+	}
+}
+
+func jsonString(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestWebhookFlushDoesNotBlock(t *testing.T) {
+
+	productName = "accounts"
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	whOpts := webhook.NewOptions()
+	whOpts.URL = server.URL
+
+	wh, err := webhook.New(whOpts)
+	if err != nil {
+		t.Fatalf("webhook.New: %v", err)
+	}
+
+	opts := hook.NewOptions()
+	opts.Name = "Request"
+	opts.Concurrency = 1
+
+	var mutex sync.Mutex
+	var acked []uint64
+	h := newWebhook(wh, opts, 2)
+	h.done = func(items []*hookItem, err error) {
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+			return
+		}
+
+		mutex.Lock()
+		for _, item := range items {
+			acked = append(acked, item.seq)
+		}
+		mutex.Unlock()
+	}
+
+	// The first batch occupies the only slot of executor
+	h.Add(newTestHookItem(1))
+	h.Add(newTestHookItem(2))
+	h.Add(newTestHookItem(3))
+
+	flushed := make(chan struct{})
+	go func() {
+		h.Flush()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatalf("Flush blocked while executor was busy")
+	}
+
+	close(release)
+	h.Close()
+
+	if len(acked) != 3 || acked[0] != 1 || acked[2] != 3 {
+		t.Errorf("acknowledged %v, want [1 2 3]", acked)
+	}
+}
+
+func TestCommandHookProgress(t *testing.T) {
+
+	productName = "accounts"
+
+	opts := hook.NewOptions()
+	opts.Command = "exit 1"
+	opts.Retries = 2
+	opts.Backoff = 200 * time.Millisecond
+	opts.Stderr = io.Discard
+
+	done := make(chan error, 1)
+	h := newCommandHook(opts, 2)
+	h.done = func(items []*hookItem, err error) {
+		done <- err
+	}
+
+	h.Add(newTestHookItem(1))
+	h.Add(newTestHookItem(2))
+
+	// Events are kept in progress while command is being retried
+	time.Sleep(100 * time.Millisecond)
+	if n := h.progress.Len(); n != 2 {
+		t.Errorf("%d events in progress while retrying, want 2", n)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected error of command")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("command was not done")
+	}
+
+	if n := h.progress.Len(); n != 0 {
+		t.Errorf("%d events in progress after done, want 0", n)
+	}
+
+	h.Close()
+}
+
+func TestWebhookProgress(t *testing.T) {
+
+	productName = "accounts"
+
+	// The first request fails, so the event waits for retry
+	var mutex sync.Mutex
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		n := requests
+		mutex.Unlock()
+
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	whOpts := webhook.NewOptions()
+	whOpts.URL = server.URL
+
+	wh, err := webhook.New(whOpts)
+	if err != nil {
+		t.Fatalf("webhook.New: %v", err)
+	}
+
+	opts := hook.NewOptions()
+	opts.Name = "Request"
+	opts.Backoff = 300 * time.Millisecond
+	opts.Stderr = io.Discard
+
+	done := make(chan error, 1)
+	h := newWebhook(wh, opts, 1)
+	h.done = func(items []*hookItem, err error) {
+		done <- err
+	}
+
+	h.Add(newTestHookItem(1))
+
+	// Event is kept in progress while request is waiting for retry
+	time.Sleep(100 * time.Millisecond)
+	if n := h.progress.Len(); n != 1 {
+		t.Errorf("%d events in progress while waiting for retry, want 1", n)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("request was not done")
+	}
+
+	if n := h.progress.Len(); n != 0 {
+		t.Errorf("%d events in progress after done, want 0", n)
+	}
+
+	h.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if requests != 2 {
+		t.Errorf("%d requests, want 2", requests)
+	}
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/importer"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/publisher"
+	"github.com/spf13/cobra"
+)
+
+var importFile string
+var importFormat string
+var importNoHeader bool
+var importColumns []string
+var importSchemaFile string
+var importTypes []string
+var importBatchSize int
+var importRate float64
+var importOffset int64
+var importProgressFile string
+var importHeaders []string
+var importMsgIDField string
+
+func init() {
+
+	RootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&importFile, "file", "f", "", `Import records from specific file ("-" for stdin)`)
+	importCmd.Flags().StringVar(&importFormat, "format", "", "Input format: csv, json or ndjson (default: detected by file extension)")
+	importCmd.Flags().BoolVar(&importNoHeader, "no-header", false, "The first row of CSV is data rather than column names")
+	importCmd.Flags().StringSliceVar(&importColumns, "columns", []string{}, "Column names of CSV, it overrides header row")
+	importCmd.Flags().StringVar(&importSchemaFile, "schema", "", "Load type hints of fields from specific schema file")
+	importCmd.Flags().StringSliceVar(&importTypes, "type", []string{}, "Type hint of field in field=type format (uint, int, float, bool, string, time, binary, map, array)")
+	importCmd.Flags().IntVar(&importBatchSize, "batch-size", 1000, "Number of records to be acknowledged before saving progress")
+	importCmd.Flags().Float64Var(&importRate, "rate", 0, "Maximum records per second (0 for unlimited)")
+	importCmd.Flags().Int64Var(&importOffset, "offset", 0, "Skip specific number of records")
+	importCmd.Flags().StringVar(&importProgressFile, "progress", "", "Save progress to specific file and resume from it")
+	importCmd.Flags().StringArrayVar(&importHeaders, "header", []string{}, "Custom header in key=value format (can be specified multiple times)")
+	importCmd.Flags().StringVar(&importMsgIDField, "msg-id-field", "", "Take message ID from specific field of each record for duplicate detection")
+	importCmd.MarkFlagRequired("file")
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [event]",
+	Short: "Import records from CSV or JSON as domain events",
+	Long: `Import records from CSV, JSON array or NDJSON as domain events.
+
+Values of CSV are strings unless type hints are given by --schema or --type.
+
+Examples:
+  gravity-cli import accountCreated --file accounts.csv --schema scripts/schema_test.json
+  gravity-cli import accountCreated --file accounts.csv --no-header --columns id,name --type id=uint
+  gravity-cli import accountCreated --file accounts.json --progress accounts.progress --msg-id-field id`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runPubCmd(runImportCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func runImportCmd(cctx *PubCommandContext) error {
+
+	event := cctx.Args[0]
+
+	if importBatchSize <= 0 {
+		return errors.New("batch size should be greater than 0")
+	}
+
+	format, err := importer.ParseFormat(importFormat, importFile)
+	if err != nil {
+		return err
+	}
+
+	// Preparing type hints
+	types := make(map[string]string)
+	if len(importSchemaFile) > 0 {
+		schema, err := readSchemaFile(importSchemaFile)
+		if err != nil {
+			return err
+		}
+
+		types = importer.TypesFromSchema(schema)
+	}
+
+	hints, err := importer.ParseTypeHints(importTypes)
+	if err != nil {
+		return err
+	}
+
+	for name, t := range hints {
+		types[name] = t
+	}
+
+	meta, err := parseHeaders(importHeaders)
+	if err != nil {
+		return err
+	}
+
+	// Resume from progress file
+	offset := importOffset
+	if len(importProgressFile) > 0 && !cctx.Cmd.Flags().Changed("offset") {
+		offset, err = readImportProgress(importProgressFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	input, err := openInput(importFile)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	opts := importer.NewOptions()
+	opts.Format = format
+	opts.Header = !importNoHeader
+	opts.Columns = importColumns
+	opts.Types = types
+
+	reader, err := importer.NewReader(input, opts)
+	if err != nil {
+		return err
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	return importRecords(cctx.Connector, reader, &importJob{
+		Event:        event,
+		Meta:         meta,
+		MsgIDField:   importMsgIDField,
+		BatchSize:    importBatchSize,
+		MaxInflight:  importBatchSize,
+		Rate:         importRate,
+		Offset:       offset,
+		ProgressFile: importProgressFile,
+		Summary:      "Imported",
+	})
+}
+
+// importJob is settings of publishing records as domain events
+type importJob struct {
+	Event      string
+	Meta       map[string]string
+	MsgIDField string
+
+	// Progress is saved after each batch was acknowledged
+	BatchSize    int
+	MaxInflight  int
+	Rate         float64
+	Offset       int64
+	ProgressFile string
+
+	// Label of summary
+	Summary string
+}
+
+// importRecords publishes records after offset and saves progress
+func importRecords(c *connector.Connector, reader importer.Reader, job *importJob) error {
+
+	// Initializing publisher
+	popts := publisher.NewOptions()
+	popts.Domain = c.GetDomain()
+	popts.MaxInflight = job.MaxInflight
+	popts.Rate = job.Rate
+	popts.ErrorHandler = func(event string, err error) {
+		fmt.Fprintf(os.Stderr, "Failed to publish event \"%s\": %v\n", event, err)
+	}
+
+	p, err := publisher.New(c.GetClient(), popts)
+	if err != nil {
+		return err
+	}
+
+	offset := job.Offset
+	if offset > 0 {
+		fmt.Printf("Resuming from offset %d\n", offset)
+	}
+
+	var skipped uint64
+	committed := offset
+	batch := 0
+
+	// commit waits for acknowledgement of the current batch and saves progress
+	commit := func(current int64) error {
+
+		if batch == 0 {
+			return nil
+		}
+
+		if err := p.Flush(publisher.DefaultCompleteTimeout); err != nil {
+			return err
+		}
+
+		// Progress should not go beyond records which failed to be published
+		if p.Stats().Failed > 0 {
+			return fmt.Errorf("failed to publish records after offset %d", committed)
+		}
+
+		committed = current
+		batch = 0
+
+		if len(job.ProgressFile) > 0 {
+			return writeImportProgress(job.ProgressFile, committed)
+		}
+
+		return nil
+	}
+
+	// finish waits for pending records and reports summary
+	finish := func(err error) error {
+
+		stats := p.Close()
+		printImportSummary(job.Summary, stats, skipped, committed)
+
+		if err != nil {
+			return err
+		}
+
+		if stats.Failed > 0 {
+			return fmt.Errorf("failed to publish %d records", stats.Failed)
+		}
+
+		return nil
+	}
+
+	var current int64
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return finish(err)
+		}
+
+		current = record.Offset
+		if current <= offset {
+			continue
+		}
+
+		batch++
+
+		recordMeta := job.Meta
+		if record.Err == nil && len(job.MsgIDField) > 0 {
+			id, err := getMsgID(record.Data, job.MsgIDField)
+			if err != nil {
+				record.Err = err
+			}
+
+			recordMeta = withMsgID(job.Meta, id)
+		}
+
+		if record.Err != nil {
+			skipped++
+			fmt.Fprintf(os.Stderr, "Skipped record %s: %v\n", describeRecord(record), record.Err)
+		} else {
+
+			payload, err := json.Marshal(record.Data)
+			if err != nil {
+				return finish(err)
+			}
+
+			p.Publish(job.Event, payload, recordMeta)
+		}
+
+		if batch >= job.BatchSize {
+			if err := commit(current); err != nil {
+				return finish(err)
+			}
+		}
+	}
+
+	return finish(commit(current))
+}
+
+func describeRecord(record *importer.Record) string {
+
+	if record.Line > 0 {
+		return fmt.Sprintf("%d (line %d)", record.Offset, record.Line)
+	}
+
+	return strconv.FormatInt(record.Offset, 10)
+}
+
+func printImportSummary(label string, stats publisher.Stats, skipped uint64, offset int64) {
+	fmt.Printf("%s: %d, Skipped: %d, Failed: %d, Offset: %d\n", label, stats.Published, skipped, stats.Failed, offset)
+}
+
+func readImportProgress(filename string) (int64, error) {
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid progress file %s", filename)
+	}
+
+	return offset, nil
+}
+
+// writeImportProgress replaces progress file atomically
+func writeImportProgress(filename string, offset int64) error {
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.WriteString(strconv.FormatInt(offset, 10) + "\n")
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,507 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/materializer"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/snapshot"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/subscriber"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	record_type "github.com/BrobridgeOrg/gravity-sdk/v2/types/record"
+	"github.com/nats-io/nats.go"
+	"github.com/spf13/cobra"
+)
+
+var materializeSQLite string
+var materializeTable string
+var materializePrimaryKey []string
+var materializeSnapshot bool
+var materializeBatchSize int
+var materializeCommitInterval time.Duration
+var materializeIdleTimeout time.Duration
+var materializeSkipErrors bool
+var materializeErrorReport string
+
+func init() {
+
+	productCmd.AddCommand(productMaterializeCmd)
+	productMaterializeCmd.Flags().StringVar(&materializeSQLite, "sqlite", "", "Specify SQLite database file")
+	productMaterializeCmd.Flags().StringVar(&materializeTable, "table", "", "Specify table name (default: product name)")
+	productMaterializeCmd.Flags().StringSliceVar(&materializePrimaryKey, "primary-key", []string{}, "Specify fields of primary key (default: primary key of rules)")
+	productMaterializeCmd.Flags().BoolVar(&materializeSnapshot, "snapshot", false, "Start from snapshot of product, then continue from live events")
+	productMaterializeCmd.Flags().IntVar(&materializeBatchSize, "batch-size", 1000, "Number of changes committed in a transaction")
+	productMaterializeCmd.Flags().DurationVar(&materializeCommitInterval, "commit-interval", time.Second, "Interval of committing changes")
+	productMaterializeCmd.Flags().DurationVar(&materializeIdleTimeout, "idle-timeout", 0, "Exit if no events were received for specific duration")
+	productMaterializeCmd.Flags().BoolVar(&materializeSkipErrors, "skip-errors", false, "Skip events which cannot be applied instead of stopping")
+	productMaterializeCmd.Flags().StringVar(&materializeErrorReport, "error-report", "", "Append skipped events with errors to specific file in NDJSON")
+	productMaterializeCmd.MarkFlagRequired("sqlite")
+}
+
+var productMaterializeCmd = &cobra.Command{
+	Use:   "materialize [product name]",
+	Short: "Materialize product into a local SQLite database",
+	Long: `Materialize product into a local SQLite database.
+
+A table is created from product schema with primary key of rules. Records are
+upserted on INSERT and UPDATE events and deleted on DELETE events. Maps, arrays
+and values of any type are stored in JSON, and time in RFC3339.
+
+The position of stream is committed with changes, so materialization resumes
+from it on restart. With --snapshot, a new database is populated from snapshot
+of product first, then events which were published since the snapshot was
+created are applied.
+
+An event which cannot be applied stops materialization after events before it
+were committed, so it resumes from the failed event on restart. With
+--skip-errors, such events are skipped and committed past instead, and they
+are appended to the file of --error-report with sequence, error and raw data.
+
+Examples:
+  gravity-cli product materialize accounts --sqlite accounts.db --snapshot
+  gravity-cli product materialize accounts --sqlite accounts.db --idle-timeout 10s
+  gravity-cli product materialize accounts --sqlite accounts.db --skip-errors --error-report errors.ndjson
+  sqlite3 accounts.db 'SELECT * FROM accounts LIMIT 10'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductMaterializeCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// materializeError is a line of error report
+type materializeError struct {
+	Seq   uint64 `json:"seq"`
+	Error string `json:"error"`
+	Data  []byte `json:"data"`
+}
+
+func runProductMaterializeCmd(cctx *ProductCommandContext) error {
+
+	productName = cctx.Args[0]
+
+	if len(materializeErrorReport) > 0 && !materializeSkipErrors {
+		return errors.New("--error-report requires --skip-errors")
+	}
+
+	// Getting product information
+	product, err := cctx.Product.GetClient().GetProduct(productName)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
+	}
+
+	primaryKey := materializePrimaryKey
+	if len(primaryKey) == 0 {
+		primaryKey, err = findPrimaryKey(product.Setting)
+		if err != nil {
+			cctx.Cmd.SilenceUsage = true
+			return err
+		}
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	opts := &materializer.Options{
+		Table:      materializeTable,
+		PrimaryKey: primaryKey,
+		Schema:     product.Setting.Schema,
+	}
+
+	m, err := materializer.Open(materializeSQLite, productName, opts)
+	if err != nil {
+		return err
+	}
+
+	defer m.Close()
+
+	pos, err := m.Position()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Materializing product \"%s\" into table \"%s\" of %s (primary key: %s)\n", productName, m.GetTable(), materializeSQLite, strings.Join(primaryKey, ", "))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if materializeSnapshot {
+		if pos > 0 {
+			fmt.Fprintf(os.Stderr, "Snapshot is skipped, resuming from sequence %d\n", pos+1)
+		} else {
+			if !product.Setting.EnabledSnapshot {
+				return errors.New("Product snapshot is not enabled")
+			}
+
+			pos, err = materializeFromSnapshot(ctx, cctx, m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return materializeFromEvents(ctx, cctx, m, pos+1)
+}
+
+// findPrimaryKey returns primary key which is shared by rules of product
+func findPrimaryKey(setting *product_sdk.ProductSetting) ([]string, error) {
+
+	var primaryKey []string
+	for _, rule := range sortRules(setting.Rules) {
+
+		if len(rule.PrimaryKey) == 0 {
+			continue
+		}
+
+		if len(primaryKey) == 0 {
+			primaryKey = rule.PrimaryKey
+			continue
+		}
+
+		if strings.Join(primaryKey, ",") != strings.Join(rule.PrimaryKey, ",") {
+			return nil, fmt.Errorf("rules of product have different primary keys, require flag: --primary-key")
+		}
+	}
+
+	if len(primaryKey) == 0 {
+		return nil, fmt.Errorf("rules of product have no primary key, require flag: --primary-key")
+	}
+
+	return primaryKey, nil
+}
+
+// materializeFromSnapshot populates table with snapshot and returns the last
+// sequence of stream before snapshot was created. Events which are applied
+// again after the sequence are idempotent.
+func materializeFromSnapshot(ctx context.Context, cctx *ProductCommandContext, m *materializer.Materializer) (uint64, error) {
+
+	var lastSeq uint64
+
+	js, err := cctx.Connector.GetClient().GetJetStream()
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := js.StreamInfo(fmt.Sprintf(subscriber.ProductEventStream, cctx.Connector.GetDomain(), productName))
+	if err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		return 0, err
+	}
+
+	if info != nil {
+		lastSeq = info.State.LastSeq
+	}
+
+	fmt.Fprintf(os.Stderr, "Loading snapshot...\n")
+
+	opts := snapshot.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+
+	reader, err := snapshot.Open(ctx, cctx.Connector.GetClient(), productName, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	defer reader.Close()
+
+	var count int
+	for {
+		msg, err := reader.Next(ctx)
+		if err == io.EOF {
+			err := m.Commit(lastSeq)
+			if err != nil {
+				return 0, err
+			}
+
+			fmt.Fprintf(os.Stderr, "Loaded %d records from snapshot\n", count)
+
+			return lastSeq, nil
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, m.Rollback()
+			}
+
+			return 0, err
+		}
+
+		var r record_type.Record
+		err = record_type.Unmarshal(msg.Data, &r)
+		if err != nil {
+			return 0, err
+		}
+
+		err = m.Apply("INSERT", r.AsMap())
+		if err != nil {
+			return 0, err
+		}
+
+		msg.Ack()
+		count++
+
+		if count%materializeBatchSize == 0 {
+			err := m.Commit(0)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+func materializeFromEvents(ctx context.Context, cctx *ProductCommandContext, m *materializer.Materializer, startSeq uint64) error {
+
+	// Events which cannot be applied are reported to file
+	var report *json.Encoder
+	if len(materializeErrorReport) > 0 {
+		f, err := os.OpenFile(materializeErrorReport, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		report = json.NewEncoder(f)
+	}
+
+	fmt.Fprintf(os.Stderr, "Start Sequence: %d\n", startSeq)
+
+	var mutex sync.Mutex
+	var pending []*nats.Msg
+	var failed uint64
+	var lastSeq uint64
+
+	// Changes are committed with position before events are acknowledged
+	commit := func() error {
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		err := m.Commit(lastSeq)
+		if err != nil {
+			for _, msg := range pending {
+				subscriber.Redeliver(msg)
+			}
+
+			pending = pending[:0]
+
+			return err
+		}
+
+		for _, msg := range pending {
+			msg.Ack()
+		}
+
+		pending = pending[:0]
+
+		return nil
+	}
+
+	// Skipped events are committed past
+	skip := func(msg *nats.Msg, seq uint64, err error) error {
+
+		if report == nil {
+			fmt.Fprintf(os.Stderr, "Skipped event (seq %d): %v\n", seq, err)
+			return nil
+		}
+
+		rerr := report.Encode(&materializeError{
+			Seq:   seq,
+			Error: err.Error(),
+			Data:  msg.Data,
+		})
+		if rerr != nil {
+			return fmt.Errorf("failed to write error report: %w", rerr)
+		}
+
+		return nil
+	}
+
+	errCh := make(chan error, 1)
+	activity := make(chan struct{}, 1)
+	var stopping bool
+
+	handler := func(msg *nats.Msg) {
+
+		mutex.Lock()
+		if stopping {
+			mutex.Unlock()
+			subscriber.Redeliver(msg)
+			return
+		}
+
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+
+		var seq uint64
+		if md, err := msg.Metadata(); err == nil {
+			seq = md.Sequence.Stream
+		}
+
+		event, err := decodeProductEvent(msg)
+		if err == nil {
+			payload, _ := event["payload"].(map[string]interface{})
+			err = m.Apply(event["method"].(string), payload)
+		}
+
+		if err != nil {
+			failed++
+
+			if materializeSkipErrors {
+				err = skip(msg, seq, err)
+			} else {
+				err = fmt.Errorf("failed to apply event (seq %d): %w", seq, err)
+			}
+		}
+
+		// Events before the failed one are committed, so it resumes from
+		// the failed event.
+		if err != nil {
+			subscriber.Redeliver(msg)
+			stopping = true
+			mutex.Unlock()
+
+			if cerr := commit(); cerr != nil {
+				err = cerr
+			}
+
+			select {
+			case errCh <- err:
+			default:
+			}
+
+			return
+		}
+
+		pending = append(pending, msg)
+		lastSeq = seq
+
+		full := len(pending) >= materializeBatchSize
+		mutex.Unlock()
+
+		if full {
+			if err := commit(); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+			}
+		}
+	}
+
+	opts := subscriber.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+	opts.StartSequence = startSeq
+
+	startTime := time.Now()
+
+	sub, err := subscriber.Subscribe(cctx.Connector.GetClient(), productName, handler, opts)
+	if err != nil {
+		return err
+	}
+
+	var commitC <-chan time.Time
+	if materializeCommitInterval > 0 {
+		ticker := time.NewTicker(materializeCommitInterval)
+		defer ticker.Stop()
+		commitC = ticker.C
+	}
+
+	var idleC <-chan time.Time
+	var idleTimer *time.Timer
+	if materializeIdleTimeout > 0 {
+		idleTimer = time.NewTimer(materializeIdleTimeout)
+		defer idleTimer.Stop()
+		idleC = idleTimer.C
+	}
+
+	var reason string
+	var result error
+
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			reason = "interrupted"
+			break wait
+		case <-idleC:
+			reason = "idle timeout"
+			break wait
+		case <-activity:
+			if idleTimer != nil {
+				if !idleTimer.Stop() {
+					select {
+					case <-idleTimer.C:
+					default:
+					}
+				}
+				idleTimer.Reset(materializeIdleTimeout)
+			}
+		case <-commitC:
+			if err := commit(); err != nil {
+				reason = "commit error"
+				result = err
+				break wait
+			}
+		case err := <-errCh:
+			reason = "error"
+			result = err
+			break wait
+		case <-sub.Done():
+			reason = "subscription closed"
+			result = sub.Err()
+			break wait
+		}
+	}
+
+	mutex.Lock()
+	stopping = true
+	mutex.Unlock()
+
+	if err := sub.Close(); err != nil && result == nil {
+		result = err
+	}
+
+	if err := commit(); err != nil && result == nil {
+		result = err
+	}
+
+	if err := cctx.Connector.GetClient().GetConnection().Flush(); err != nil && result == nil {
+		result = err
+	}
+
+	pos, _ := m.Position()
+	stats := m.Stats()
+
+	fmt.Fprintf(os.Stderr, "Stopped (%s): upserted %d, deleted %d, failed %d, position %d, elapsed %s\n",
+		reason,
+		stats.Upserted,
+		stats.Deleted,
+		failed,
+		pos,
+		time.Since(startTime).Round(time.Millisecond),
+	)
+
+	return result
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,17 +17,12 @@ import (
 	"github.com/BrobridgeOrg/gravity-cli/pkg/logger"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/product"
 	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
-	subscriber_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/subscriber"
-	gravity_sdk_types_product_event "github.com/BrobridgeOrg/gravity-sdk/v2/types/product_event"
-	record_type "github.com/BrobridgeOrg/gravity-sdk/v2/types/record"
 	"github.com/docker/go-units"
 	"github.com/google/uuid"
-	"github.com/nats-io/nats.go"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -50,11 +46,6 @@ var productDesc string
 var productEnabled bool
 var productSchemaFile string
 
-// product subscriber
-var productSubscriberName string
-var productSubscriberStartSeq uint64
-var productSubscriberPartitions []int
-
 // Rule flags
 var ruleName string
 var ruleDescription string
@@ -89,15 +80,6 @@ func init() {
 	// Show product information
 	productCmd.AddCommand(productInfoCmd)
 
-	// Subscribe product
-	productCmd.AddCommand(productSubCmd)
-	productSubCmd.Flags().StringVar(&productSubscriberName, "name", "", "Specify subscriber name")
-	productSubCmd.Flags().Uint64Var(&productSubscriberStartSeq, "seq", 1, "Specify start sequence")
-	productSubCmd.Flags().IntSliceVar(&productSubscriberPartitions, "partitions", []int{-1}, "Specify partitions (default -1 for all)")
-
-	// Snapshot
-	productCmd.AddCommand(productSnapshotCmd)
-
 	// Rule
 	productCmd.AddCommand(productRuleCmd)
 
@@ -172,6 +154,20 @@ func readHandlerScriptFile(filename string) ([]byte, error) {
 	return data, nil
 }
 
+func sortRules(rules map[string]*product_sdk.Rule) []*product_sdk.Rule {
+
+	list := make([]*product_sdk.Rule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, rule)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
 var productCmd = &cobra.Command{
 	Use:   "product",
 	Short: "Manage data products",
@@ -181,9 +177,14 @@ func runProductCmd(fn productCmdFunc, cmd *cobra.Command, args []string) error {
 
 	var cctx *ProductCommandContext
 
-	config.SetHost(host)
+	if err := config.SetHost(host); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
 	config.SetDomain(domain)
 	config.SetAccessToken(accessToken)
+	config.SetSecurity(&security)
 
 	app := fx.New(
 		fx.Supply(config),
@@ -219,6 +220,9 @@ func runProductCmd(fn productCmdFunc, cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Disconnect from server when command is finished
+	defer app.Stop(context.Background())
+
 	return fn(cctx)
 }
 
@@ -243,6 +247,12 @@ func runProductListCmd(cctx *ProductCommandContext) error {
 		return err
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, products)
+	}
+
 	if len(products) == 0 {
 		cctx.Cmd.SilenceUsage = true
 		return errors.New("No available products")
@@ -502,6 +512,12 @@ func runProductInfoCmd(cctx *ProductCommandContext) error {
 		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, product)
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
@@ -604,82 +620,6 @@ func runProductInfoCmd(cctx *ProductCommandContext) error {
 	return nil
 }
 
-var productSubCmd = &cobra.Command{
-	Use:   "sub [product name]",
-	Short: "Generic subscription client for product",
-	Args:  cobra.MinimumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-
-		if err := runProductCmd(runProductSubCmd, cmd, args); err != nil {
-			return err
-		}
-
-		return nil
-	},
-}
-
-func runProductSubCmd(cctx *ProductCommandContext) error {
-
-	productName = cctx.Args[0]
-
-	fmt.Printf("Subscribing to product: %s\n", productName)
-
-	if len(productSubscriberName) > 0 {
-		fmt.Printf("Subscriber name: %s\n", productSubscriberName)
-	}
-
-	fmt.Printf("Start Sequence: %d\n", productSubscriberStartSeq)
-
-	// Initializing gravity subscriber
-	opts := subscriber_sdk.NewOptions()
-	opts.Verbose = true
-	s := subscriber_sdk.NewSubscriberWithClient(productSubscriberName, cctx.Connector.GetClient(), opts)
-	_, err := s.Subscribe(productName, func(msg *nats.Msg) {
-
-		var pe gravity_sdk_types_product_event.ProductEvent
-
-		err := proto.Unmarshal(msg.Data, &pe)
-		if err != nil {
-			fmt.Printf("Failed to parsing product event: %v", err)
-			msg.Ack()
-			return
-		}
-
-		md, _ := msg.Metadata()
-
-		r, err := pe.GetContent()
-		if err != nil {
-			fmt.Printf("Failed to parsing content: %v", err)
-			msg.Ack()
-			return
-		}
-
-		// Convert data to JSON
-		event := map[string]interface{}{
-			"header":     msg.Header,
-			"subject":    msg.Subject,
-			"seq":        md.Sequence.Consumer,
-			"timestamp":  md.Timestamp,
-			"product":    productName,
-			"event":      pe.EventName,
-			"method":     pe.Method.String(),
-			"table":      pe.Table,
-			"primaryKey": pe.PrimaryKeys,
-			"payload":    r.AsMap(),
-		}
-
-		data, _ := json.MarshalIndent(event, "", "  ")
-		fmt.Println(string(data))
-		msg.Ack()
-	}, subscriber_sdk.Partition(productSubscriberPartitions...), subscriber_sdk.StartSequence(productSubscriberStartSeq))
-	if err != nil {
-		cctx.Cmd.SilenceUsage = true
-		return err
-	}
-
-	select {}
-}
-
 var productRuleCmd = &cobra.Command{
 	Use:   "ruleset",
 	Short: "Manage rules of data product",
@@ -931,6 +871,12 @@ func runProductRuleListCmd(cctx *ProductCommandContext) error {
 		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, sortRules(product.Setting.Rules))
+	}
+
 	if product.Setting.Rules == nil {
 		cctx.Cmd.SilenceUsage = true
 		return errors.New("No available rules")
@@ -1089,6 +1035,12 @@ func runProductRuleInfoCmd(cctx *ProductCommandContext) error {
 		return errors.New(fmt.Sprintf("Not found rule \"%s\"\n", ruleName))
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, rule)
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
@@ -1190,73 +1142,3 @@ func runProductRuleInfoCmd(cctx *ProductCommandContext) error {
 
 	return nil
 }
-
-var productSnapshotCmd = &cobra.Command{
-	Use:   "snapshot [product name]",
-	Short: "Take snapshot from product",
-	Args:  cobra.MinimumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-
-		if err := runProductCmd(runProductSnapshotCmd, cmd, args); err != nil {
-			return err
-		}
-
-		return nil
-	},
-}
-
-func runProductSnapshotCmd(cctx *ProductCommandContext) error {
-
-	productName = cctx.Args[0]
-
-	// Getting product information
-	product, err := cctx.Product.GetClient().GetProduct(productName)
-	if err != nil {
-		cctx.Cmd.SilenceUsage = true
-		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
-	}
-
-	if !product.Setting.EnabledSnapshot {
-		cctx.Cmd.SilenceUsage = true
-		return errors.New("Product snapshot is not enabled")
-	}
-
-	// Create new snapshot
-	s, err := cctx.Product.GetClient().CreateSnapshot(productName)
-	if err != nil {
-		cctx.Cmd.SilenceUsage = true
-		return err
-	}
-
-	// Fetch records
-	msgs, err := s.Fetch()
-	if err != nil {
-		cctx.Cmd.SilenceUsage = true
-		return err
-	}
-
-	for msg := range msgs {
-
-		fmt.Println(string(msg.Data))
-		var r record_type.Record
-		err := record_type.Unmarshal(msg.Data, &r)
-		if err != nil {
-			cctx.Cmd.SilenceUsage = true
-			return err
-		}
-
-		// Convert data to JSON
-		data, _ := json.MarshalIndent(r.AsMap(), "", "  ")
-		fmt.Println(string(data))
-
-		msg.Ack()
-	}
-
-	err = s.Close()
-	if err != nil {
-		cctx.Cmd.SilenceUsage = true
-		return err
-	}
-
-	return nil
-}
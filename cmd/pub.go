@@ -1,13 +1,23 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/logger"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/product"
-	adapter_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/adapter"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/publisher"
+	"github.com/nats-io/nats.go"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -26,16 +36,45 @@ type pubCmdFunc func(*PubCommandContext) error
 
 var pubEvent string
 var pubPayload string
+var pubFile string
+var pubNDJSON bool
+var pubEventField string
+var pubMaxInflight int
+var pubHeaders []string
+var pubMsgID string
+var pubMsgIDField string
+
+// pubMeta is metadata which will be sent as message headers
+var pubMeta map[string]string
 
 func init() {
 
 	RootCmd.AddCommand(pubCmd)
+	pubCmd.Flags().StringVarP(&pubFile, "file", "f", "", `Read payload from specific file ("-" for stdin)`)
+	pubCmd.Flags().BoolVar(&pubNDJSON, "ndjson", false, "Publish each line of input as an event")
+	pubCmd.Flags().StringVar(&pubEventField, "event-field", "", "Take event name from specific field of each line in NDJSON mode")
+	pubCmd.Flags().IntVar(&pubMaxInflight, "max-inflight", publisher.DefaultMaxInflight, "Maximum number of messages waiting for acknowledgement in NDJSON mode")
+	pubCmd.Flags().StringArrayVar(&pubHeaders, "header", []string{}, "Custom header in key=value format (can be specified multiple times)")
+	pubCmd.Flags().StringVar(&pubMsgID, "msg-id", "", "Message ID for duplicate detection")
+	pubCmd.Flags().StringVar(&pubMsgIDField, "msg-id-field", "", "Take message ID from specific field of each line in NDJSON mode")
 }
 
 var pubCmd = &cobra.Command{
 	Use:   "pub [event] [payload]",
 	Short: "Publish domain event",
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Publish domain event with payload from argument, file or stdin.
+
+"gen" is the subcommand of generating events, an event named "gen" can be
+published after "--", e.g. gravity-cli pub -- gen '{"id":1}'.
+
+Examples:
+  gravity-cli pub accountCreated '{"id":4,"name":"fred"}'
+  gravity-cli pub accountCreated --file account.json
+  cat accounts.ndjson | gravity-cli pub accountCreated - --ndjson
+  gravity-cli pub --file events.ndjson --ndjson --event-field event
+  gravity-cli pub accountCreated '{"id":4}' --msg-id account-4 --header trace-id=abc123
+  gravity-cli pub accountCreated --file accounts.ndjson --ndjson --msg-id-field id`,
+	Args: cobra.RangeArgs(0, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if err := runPubCmd(runPublishCmd, cmd, args); err != nil {
@@ -50,9 +89,14 @@ func runPubCmd(fn pubCmdFunc, cmd *cobra.Command, args []string) error {
 
 	var cctx *PubCommandContext
 
-	config.SetHost(host)
+	if err := config.SetHost(host); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
 	config.SetDomain(domain)
 	config.SetAccessToken(accessToken)
+	config.SetSecurity(&security)
 
 	app := fx.New(
 		fx.Supply(config),
@@ -91,19 +135,266 @@ func runPubCmd(fn pubCmdFunc, cmd *cobra.Command, args []string) error {
 
 func runPublishCmd(cctx *PubCommandContext) error {
 
-	pubEvent = cctx.Args[0]
-	pubPayload = cctx.Args[1]
+	if len(cctx.Args) > 0 {
+		pubEvent = cctx.Args[0]
+	}
+
+	// Preparing input
+	var input io.Reader
+	switch {
+	case len(pubFile) > 0 && len(cctx.Args) > 1:
+		return errors.New("payload argument cannot be used with --file")
+	case len(pubFile) > 0:
+		r, err := openInput(pubFile)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		input = r
+	case len(cctx.Args) > 1 && cctx.Args[1] == "-":
+		input = os.Stdin
+	case len(cctx.Args) > 1:
+		input = bytes.NewReader([]byte(cctx.Args[1]))
+	default:
+		return errors.New("require payload or flag: --file")
+	}
+
+	if len(pubEvent) == 0 && !(pubNDJSON && len(pubEventField) > 0) {
+		return errors.New("require event")
+	}
+
+	// Preparing metadata
+	meta, err := parseHeaders(pubHeaders)
+	if err != nil {
+		return err
+	}
+
+	pubMeta = meta
+
+	if pubNDJSON && len(pubMsgID) > 0 {
+		return errors.New("--msg-id cannot be used in NDJSON mode, use --msg-id-field instead")
+	}
+
+	if !pubNDJSON && len(pubMsgIDField) > 0 {
+		return errors.New("--msg-id-field can be used in NDJSON mode only")
+	}
+
+	cctx.Cmd.SilenceUsage = true
 
-	// Initializing adapter connector
-	opts := adapter_sdk.NewOptions()
+	if pubNDJSON {
+		return publishNDJSON(cctx, input)
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return err
+	}
+
+	pubPayload = string(bytes.TrimSpace(data))
+
+	js, err := cctx.Connector.GetClient().GetJetStream()
+	if err != nil {
+		return err
+	}
+
+	_, err = js.PublishMsg(publisher.NewMsg(cctx.Connector.GetDomain(), pubEvent, []byte(pubPayload), withMsgID(pubMeta, pubMsgID)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func openInput(filename string) (io.ReadCloser, error) {
+
+	if filename == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(filename)
+}
+
+// readLines reads input line by line without limitation of line length
+func readLines(r io.Reader, fn func(lineNo int, line []byte) error) error {
+
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				if err := fn(lineNo, line); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func publishNDJSON(cctx *PubCommandContext, input io.Reader) error {
+
+	opts := publisher.NewOptions()
 	opts.Domain = cctx.Connector.GetDomain()
+	opts.MaxInflight = pubMaxInflight
+	opts.ErrorHandler = func(event string, err error) {
+		fmt.Fprintf(os.Stderr, "Failed to publish event \"%s\": %v\n", event, err)
+	}
 
-	ac := adapter_sdk.NewAdapterConnectorWithClient(cctx.Connector.GetClient(), opts)
-	_, err := ac.Publish(pubEvent, []byte(pubPayload), nil)
+	p, err := publisher.New(cctx.Connector.GetClient(), opts)
 	if err != nil {
-		cctx.Cmd.SilenceUsage = true
 		return err
 	}
 
+	err = readLines(input, func(lineNo int, line []byte) error {
+
+		event, payload, meta, err := parseNDJSONLine(line)
+		if err != nil {
+			p.Fail(event, fmt.Errorf("line %d: %w", lineNo, err))
+			return nil
+		}
+
+		p.Publish(event, payload, meta)
+
+		return nil
+	})
+
+	stats := p.Close()
+
+	fmt.Printf("Published: %d, Failed: %d\n", stats.Published, stats.Failed)
+
+	if err != nil {
+		return err
+	}
+
+	if stats.Failed > 0 {
+		return fmt.Errorf("failed to publish %d messages", stats.Failed)
+	}
+
 	return nil
 }
+
+func parseNDJSONLine(line []byte) (string, []byte, map[string]string, error) {
+
+	if len(pubEventField) == 0 && len(pubMsgIDField) == 0 {
+
+		if !json.Valid(line) {
+			return pubEvent, nil, nil, errors.New("invalid JSON")
+		}
+
+		return pubEvent, line, pubMeta, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	err := dec.Decode(&obj)
+	if err != nil {
+		return pubEvent, nil, nil, errors.New("invalid JSON object")
+	}
+
+	meta := pubMeta
+	if len(pubMsgIDField) > 0 {
+		id, err := getMsgID(obj, pubMsgIDField)
+		if err != nil {
+			return pubEvent, nil, nil, err
+		}
+
+		meta = withMsgID(pubMeta, id)
+	}
+
+	if len(pubEventField) == 0 {
+		return pubEvent, line, meta, nil
+	}
+
+	// Take event name from field
+	event := pubEvent
+	if v, ok := obj[pubEventField].(string); ok && len(v) > 0 {
+		event = v
+	}
+
+	if len(event) == 0 {
+		return event, nil, nil, fmt.Errorf("not found event name in field \"%s\"", pubEventField)
+	}
+
+	delete(obj, pubEventField)
+
+	payload, err := json.Marshal(obj)
+	if err != nil {
+		return event, nil, nil, err
+	}
+
+	return event, payload, meta, nil
+}
+
+// parseHeaders parses headers in "key=value" format
+func parseHeaders(headers []string) (map[string]string, error) {
+
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	meta := make(map[string]string, len(headers))
+	for _, h := range headers {
+
+		parts := strings.SplitN(h, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("invalid header \"%s\"", h)
+		}
+
+		meta[strings.TrimSpace(parts[0])] = parts[1]
+	}
+
+	return meta, nil
+}
+
+// withMsgID returns a copy of metadata with message ID which is used by
+// JetStream for duplicate detection.
+func withMsgID(meta map[string]string, id string) map[string]string {
+
+	if len(id) == 0 {
+		return meta
+	}
+
+	m := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		m[k] = v
+	}
+
+	m[nats.MsgIdHdr] = id
+
+	return m
+}
+
+// getMsgID takes message ID from specific field of payload
+func getMsgID(obj map[string]interface{}, field string) (string, error) {
+
+	switch v := obj[field].(type) {
+	case string:
+		if len(v) > 0 {
+			return v, nil
+		}
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	}
+
+	return "", fmt.Errorf("not found message ID in field \"%s\"", field)
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/importer"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/recordfile"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	"github.com/spf13/cobra"
+)
+
+var restoreFrom string
+var restoreEvent string
+var restoreFormat string
+var restoreConcurrency int
+var restoreBatchSize int
+var restoreRate float64
+var restoreOffset int64
+var restoreProgressFile string
+var restoreHeaders []string
+var restoreMsgIDField string
+
+func init() {
+
+	productCmd.AddCommand(productRestoreCmd)
+	productRestoreCmd.Flags().StringVar(&restoreFrom, "from", "", "Restore records from specific snapshot file")
+	productRestoreCmd.Flags().StringVar(&restoreEvent, "event", "", "Publish records as specific event (default: event of the only rule)")
+	productRestoreCmd.Flags().StringVar(&restoreFormat, "format", "", "Input format: ndjson, json or csv (default: detected by file extension)")
+	productRestoreCmd.Flags().IntVar(&restoreConcurrency, "concurrency", 100, "Maximum number of records waiting for acknowledgement")
+	productRestoreCmd.Flags().IntVar(&restoreBatchSize, "batch-size", 1000, "Number of records to be acknowledged before saving progress")
+	productRestoreCmd.Flags().Float64Var(&restoreRate, "rate", 0, "Maximum records per second (0 for unlimited)")
+	productRestoreCmd.Flags().Int64Var(&restoreOffset, "offset", 0, "Skip specific number of records")
+	productRestoreCmd.Flags().StringVar(&restoreProgressFile, "progress", "", "Save progress to specific file and resume from it")
+	productRestoreCmd.Flags().StringArrayVar(&restoreHeaders, "header", []string{}, "Custom header in key=value format (can be specified multiple times)")
+	productRestoreCmd.Flags().StringVar(&restoreMsgIDField, "msg-id-field", "", "Take message ID from specific field of each record for duplicate detection")
+	productRestoreCmd.MarkFlagRequired("from")
+}
+
+var productRestoreCmd = &cobra.Command{
+	Use:   "restore [product name]",
+	Short: "Restore product from snapshot file by publishing domain events",
+	Long: `Restore product from snapshot file by publishing domain events.
+
+Records of snapshot file (see "product snapshot --out") are published as the
+event of a rule of product, so the product can be rebuilt on another cluster
+or after it was purged. Gzip and zstd files are decompressed by extension, and
+values of CSV are converted by schema of the rule.
+
+With --progress, the offset of the last acknowledged batch is saved to the
+file and restore resumes from it on the next run, while --offset skips a
+specific number of records.
+
+Examples:
+  gravity-cli product restore accounts --from accounts.ndjson.zst
+  gravity-cli product restore accounts --from accounts.csv --event accountCreated --rate 1000
+  gravity-cli product restore accounts --from accounts.ndjson --progress accounts.progress --msg-id-field id`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductRestoreCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// findRestoreRule returns rule of product which handles event, event can be
+// omitted if all rules handle the same event.
+func findRestoreRule(setting *product_sdk.ProductSetting, event string) (*product_sdk.Rule, error) {
+
+	events := make(map[string]*product_sdk.Rule)
+	for _, rule := range sortRules(setting.Rules) {
+		if _, ok := events[rule.Event]; !ok {
+			events[rule.Event] = rule
+		}
+	}
+
+	names := make([]string, 0, len(events))
+	for name := range events {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if len(event) == 0 {
+		switch len(names) {
+		case 0:
+			return nil, errors.New("product has no rules")
+		case 1:
+			return events[names[0]], nil
+		}
+
+		return nil, fmt.Errorf("rules of product handle different events (%s), require flag: --event", strings.Join(names, ", "))
+	}
+
+	rule, ok := events[event]
+	if !ok {
+		return nil, fmt.Errorf("event \"%s\" is not handled by rules of product (%s)", event, strings.Join(names, ", "))
+	}
+
+	return rule, nil
+}
+
+func runProductRestoreCmd(cctx *ProductCommandContext) error {
+
+	productName = cctx.Args[0]
+
+	if restoreBatchSize <= 0 {
+		return errors.New("batch size should be greater than 0")
+	}
+
+	meta, err := parseHeaders(restoreHeaders)
+	if err != nil {
+		return err
+	}
+
+	// Getting product information
+	product, err := cctx.Product.GetClient().GetProduct(productName)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
+	}
+
+	rule, err := findRestoreRule(product.Setting, restoreEvent)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return err
+	}
+
+	input, err := recordfile.Open(restoreFrom)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return err
+	}
+	defer input.Close()
+
+	name := restoreFormat
+	if len(name) == 0 {
+		name = string(input.Format)
+	}
+
+	if name == string(recordfile.FormatParquet) {
+		return errors.New("restoring from parquet is not supported, use ndjson, json or csv")
+	}
+
+	format, err := importer.ParseFormat(name, restoreFrom)
+	if err != nil {
+		return err
+	}
+
+	// Payload of event is parsed by schema of rule
+	schema := rule.SchemaConfig
+	if len(schema) == 0 {
+		schema = product.Setting.Schema
+	}
+
+	opts := importer.NewOptions()
+	opts.Format = format
+	opts.Types = importer.TypesFromSchema(schema)
+
+	reader, err := importer.NewReader(input, opts)
+	if err != nil {
+		return err
+	}
+
+	// Resume from progress file
+	offset := restoreOffset
+	if len(restoreProgressFile) > 0 && !cctx.Cmd.Flags().Changed("offset") {
+		offset, err = readImportProgress(restoreProgressFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	fmt.Printf("Restoring product \"%s\" from %s with event \"%s\" (rule %s)\n", productName, restoreFrom, rule.Event, rule.Name)
+
+	return importRecords(cctx.Connector, reader, &importJob{
+		Event:        rule.Event,
+		Meta:         meta,
+		MsgIDField:   restoreMsgIDField,
+		BatchSize:    restoreBatchSize,
+		MaxInflight:  restoreConcurrency,
+		Rate:         restoreRate,
+		Offset:       offset,
+		ProgressFile: restoreProgressFile,
+		Summary:      "Restored",
+	})
+}
@@ -4,19 +4,39 @@ import (
 	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/logger"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/output"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
 var config *configs.Config
+var configErr error
 var host string
 var domain string
 var accessToken string
+var security configs.SecurityConfig
+var contextName string
+var printer *output.Printer
 
 var RootCmd = &cobra.Command{
 	Use:   "gravity-cli",
 	Short: "Gravity utility",
 	Long:  `gravity-cli is a command line utility of Gravity.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+
+		// Invalid settings of config file or environment variables
+		if configErr != nil {
+			cmd.SilenceUsage = true
+			return configErr
+		}
+
+		if err := resolveContext(cmd); err != nil {
+			return err
+		}
+
+		return nil
+	},
 	/*
 		RunE: func(cmd *cobra.Command, args []string) error {
 
@@ -29,16 +49,147 @@ var RootCmd = &cobra.Command{
 }
 
 func init() {
-	config = configs.GetConfig()
+	config, configErr = configs.GetConfig()
 
-	RootCmd.PersistentFlags().StringVarP(&host, "host", "s", "0.0.0.0:32803", "Specify server address")
+	RootCmd.PersistentFlags().StringVarP(&host, "host", "s", "0.0.0.0:32803", "Specify server addresses separated by comma (host:port or nats://, tls://, ws://, wss:// URLs)")
 	RootCmd.PersistentFlags().StringVarP(&domain, "domain", "d", "default", "Specify data domain")
 	RootCmd.PersistentFlags().StringVarP(&accessToken, "token", "t", "", "Specify access token")
+
+	// Security settings
+	RootCmd.PersistentFlags().BoolVar(&security.TLS, "tls", false, "Enable TLS connection")
+	RootCmd.PersistentFlags().StringVar(&security.CAFile, "tls-ca", "", "Specify CA certificate file for TLS")
+	RootCmd.PersistentFlags().StringVar(&security.CertFile, "tls-cert", "", "Specify client certificate file for TLS")
+	RootCmd.PersistentFlags().StringVar(&security.KeyFile, "tls-key", "", "Specify client private key file for TLS")
+	RootCmd.PersistentFlags().BoolVar(&security.InsecureSkipVerify, "tls-insecure", false, "Skip verification of server certificate")
+	RootCmd.PersistentFlags().StringVar(&security.CredsFile, "creds", "", "Specify NATS credentials file")
+	RootCmd.PersistentFlags().StringVar(&security.NKeyFile, "nkey", "", "Specify NKey seed file")
+	RootCmd.PersistentFlags().StringVar(&security.User, "user", "", "Specify username")
+	RootCmd.PersistentFlags().StringVar(&security.Password, "password", "", "Specify password")
+
+	// Settings from config file and environment variables are defaults, they
+	// are not shown as flag defaults to avoid exposing secrets in usage
+	security = config.Security
+
+	RootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Specify named context for connection settings")
+
+	// Printer is created when flag is parsed, so it's available to commands
+	// which override PersistentPreRunE as well
+	printer, _ = output.New(output.FormatTable)
+	RootCmd.PersistentFlags().VarP(&printerValue{spec: output.FormatTable}, "output", "o", "Specify output format (table|json|yaml|jsonpath=<expr>|go-template=<template>)")
+}
+
+// printerValue is flag of output format which sets printer
+type printerValue struct {
+	spec string
+}
+
+func (v *printerValue) Set(spec string) error {
+
+	p, err := output.New(spec)
+	if err != nil {
+		return err
+	}
+
+	v.spec = spec
+	printer = p
+
+	return nil
+}
+
+func (v *printerValue) String() string {
+	return v.spec
+}
+
+func (v *printerValue) Type() string {
+	return "string"
+}
+
+// resolveContext applies connection settings of named context. Flags which
+// have been set explicitly always take precedence over context.
+func resolveContext(cmd *cobra.Command) error {
+
+	uc, err := configs.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	name := contextName
+	if !cmd.Flags().Changed("context") {
+
+		// From environment variable or current context
+		name = viper.GetString("context")
+		if len(name) == 0 {
+			name = uc.CurrentContext
+		}
+	}
+
+	if len(name) == 0 {
+		return nil
+	}
+
+	ctx, err := uc.GetContext(name)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
+	if !cmd.Flags().Changed("host") && len(ctx.Host) > 0 {
+		host = ctx.Host
+	}
+
+	if !cmd.Flags().Changed("domain") && len(ctx.Domain) > 0 {
+		domain = ctx.Domain
+	}
+
+	if !cmd.Flags().Changed("token") && len(ctx.AccessToken) > 0 {
+		accessToken = ctx.AccessToken
+	}
+
+	// Security settings
+	if !cmd.Flags().Changed("tls") && ctx.TLS {
+		security.TLS = ctx.TLS
+	}
+
+	if !cmd.Flags().Changed("tls-ca") && len(ctx.CAFile) > 0 {
+		security.CAFile = ctx.CAFile
+	}
+
+	if !cmd.Flags().Changed("tls-cert") && len(ctx.CertFile) > 0 {
+		security.CertFile = ctx.CertFile
+	}
+
+	if !cmd.Flags().Changed("tls-key") && len(ctx.KeyFile) > 0 {
+		security.KeyFile = ctx.KeyFile
+	}
+
+	if !cmd.Flags().Changed("tls-insecure") && ctx.InsecureSkipVerify {
+		security.InsecureSkipVerify = ctx.InsecureSkipVerify
+	}
+
+	if !cmd.Flags().Changed("creds") && len(ctx.CredsFile) > 0 {
+		security.CredsFile = ctx.CredsFile
+	}
+
+	if !cmd.Flags().Changed("nkey") && len(ctx.NKeyFile) > 0 {
+		security.NKeyFile = ctx.NKeyFile
+	}
+
+	if !cmd.Flags().Changed("user") && len(ctx.User) > 0 {
+		security.User = ctx.User
+	}
+
+	if !cmd.Flags().Changed("password") && len(ctx.Password) > 0 {
+		security.Password = ctx.Password
+	}
+
+	return nil
 }
 
 func run() error {
 
-	config.SetHost(host)
+	if err := config.SetHost(host); err != nil {
+		return err
+	}
 
 	fx.New(
 		fx.Supply(config),
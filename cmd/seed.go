@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/generator"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/publisher"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	"github.com/spf13/cobra"
+)
+
+var seedRuleName string
+var seedCount uint64
+var seedStart uint64
+var seedRate float64
+var seedMaxInflight int
+var seedSeed int64
+
+func init() {
+
+	productCmd.AddCommand(productSeedCmd)
+	productSeedCmd.Flags().StringVar(&seedRuleName, "rule", "", "Specify rule which provides event name and schema (default: the only rule of product)")
+	productSeedCmd.Flags().Uint64Var(&seedCount, "count", 10, "Number of events to be published")
+	productSeedCmd.Flags().Uint64Var(&seedStart, "start", 1, "Start sequence number for primary key")
+	productSeedCmd.Flags().Float64Var(&seedRate, "rate", 0, "Maximum events per second (0 for unlimited)")
+	productSeedCmd.Flags().Int64Var(&seedSeed, "seed", 0, "Seed of random values for reproducible payloads (default: random)")
+	productSeedCmd.Flags().IntVar(&seedMaxInflight, "max-inflight", publisher.DefaultMaxInflight, "Maximum number of messages waiting for acknowledgement")
+}
+
+var productSeedCmd = &cobra.Command{
+	Use:   "seed [product name]",
+	Short: "Populate product with fake data generated from rule schema",
+	Long: `Populate product with fake data generated from rule schema.
+
+Payloads are synthesized according to field types of rule schema, and fields
+of primary key are derived from sequence number so that each record is distinct.
+Schema of product will be used if the rule has no schema. With --seed, the
+same payloads are generated again except time values, which are relative to
+current time.
+
+Examples:
+  gravity-cli product seed accounts --rule accountCreated --count 1000
+  gravity-cli product seed accounts --count 1000 --seed 42`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductSeedCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func runProductSeedCmd(cctx *ProductCommandContext) error {
+
+	productName = cctx.Args[0]
+
+	// Getting product information
+	product, err := cctx.Product.GetClient().GetProduct(productName)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
+	}
+
+	rule, err := findSeedRule(product.Setting)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return err
+	}
+
+	if len(rule.Event) == 0 {
+		cctx.Cmd.SilenceUsage = true
+		return fmt.Errorf("rule \"%s\" has no event", rule.Name)
+	}
+
+	schema := rule.SchemaConfig
+	if len(schema) == 0 {
+		schema = product.Setting.Schema
+	}
+
+	faker, err := generator.NewFaker(schema, rule.PrimaryKey)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return err
+	}
+
+	if cctx.Cmd.Flags().Changed("seed") {
+		faker.Seed(seedSeed)
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	// Initializing publisher
+	opts := publisher.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+	opts.MaxInflight = seedMaxInflight
+	opts.Rate = seedRate
+	opts.ErrorHandler = func(event string, err error) {
+		fmt.Fprintf(os.Stderr, "Failed to publish event \"%s\": %v\n", event, err)
+	}
+
+	p, err := publisher.New(cctx.Connector.GetClient(), opts)
+	if err != nil {
+		return err
+	}
+
+	for i := uint64(0); i < seedCount; i++ {
+
+		record, err := faker.Generate(seedStart + i)
+		if err != nil {
+			p.Fail(rule.Event, err)
+			continue
+		}
+
+		payload, err := json.Marshal(record)
+		if err != nil {
+			p.Fail(rule.Event, err)
+			continue
+		}
+
+		p.Publish(rule.Event, payload, nil)
+	}
+
+	stats := p.Close()
+
+	fmt.Printf("Published %d events \"%s\" for product \"%s\", Failed: %d\n", stats.Published, rule.Event, productName, stats.Failed)
+
+	if stats.Failed > 0 {
+		return fmt.Errorf("failed to publish %d messages", stats.Failed)
+	}
+
+	return nil
+}
+
+func findSeedRule(setting *product_sdk.ProductSetting) (*product_sdk.Rule, error) {
+
+	if len(seedRuleName) > 0 {
+		rule, ok := setting.Rules[seedRuleName]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Not found rule \"%s\"\n", seedRuleName))
+		}
+
+		return rule, nil
+	}
+
+	switch len(setting.Rules) {
+	case 0:
+		return nil, fmt.Errorf("product \"%s\" has no rules", setting.Name)
+	case 1:
+		for _, rule := range setting.Rules {
+			return rule, nil
+		}
+	}
+
+	names := make([]string, 0, len(setting.Rules))
+	for _, rule := range sortRules(setting.Rules) {
+		names = append(names, rule.Name)
+	}
+
+	return nil, fmt.Errorf("require flag: --rule (%s)", strings.Join(names, ", "))
+}
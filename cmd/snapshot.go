@@ -0,0 +1,618 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/query"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/recordfile"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/snapshot"
+	record_type "github.com/BrobridgeOrg/gravity-sdk/v2/types/record"
+	"github.com/docker/go-units"
+	"github.com/nats-io/nats.go"
+	"github.com/spf13/cobra"
+)
+
+var productSnapshotOut string
+var productSnapshotFormat string
+var productSnapshotCompression string
+var productSnapshotNoProgress bool
+var productSnapshotWorkers int
+var productSnapshotBuffer int
+var productSnapshotSkipErrors bool
+var productSnapshotErrorReport string
+var productSnapshotResume bool
+var productSnapshotCheckpointInterval time.Duration
+
+func init() {
+
+	productCmd.AddCommand(productSnapshotCmd)
+	productSnapshotCmd.Flags().StringVar(&productFilter, "filter", "", `Output records which match specific jq expression only, e.g. '.id == 4'`)
+	productSnapshotCmd.Flags().StringVar(&productSelect, "select", "", "Output result of specific jq expression instead of records, e.g. '{id, name}'")
+	productSnapshotCmd.Flags().StringVar(&productSnapshotOut, "out", "", "Write records to specific file instead of stdout")
+	productSnapshotCmd.Flags().StringVar(&productSnapshotFormat, "format", "", "Output format (json, ndjson, csv or parquet), detected from extension of --out by default")
+	productSnapshotCmd.Flags().StringVar(&productSnapshotCompression, "compression", "", "Compression of output (none, gzip or zstd), detected from extension of --out by default")
+	productSnapshotCmd.Flags().BoolVar(&productSnapshotNoProgress, "no-progress", false, "Disable live progress")
+	productSnapshotCmd.Flags().IntVar(&productSnapshotWorkers, "workers", runtime.NumCPU(), "Number of workers decoding records in parallel")
+	productSnapshotCmd.Flags().IntVar(&productSnapshotBuffer, "buffer", snapshot.DefaultBufferSize, "Maximum number of records buffered from snapshot view")
+	productSnapshotCmd.Flags().BoolVar(&productSnapshotSkipErrors, "skip-errors", false, "Skip records which cannot be decoded instead of stopping")
+	productSnapshotCmd.Flags().StringVar(&productSnapshotErrorReport, "error-report", "", "Write skipped records with errors to specific file in NDJSON")
+	productSnapshotCmd.Flags().BoolVar(&productSnapshotResume, "resume", false, "Save state of snapshot and resume from it after interruption (requires --out)")
+	productSnapshotCmd.Flags().DurationVar(&productSnapshotCheckpointInterval, "checkpoint-interval", 5*time.Second, "Interval of saving state of snapshot with --resume")
+}
+
+var productSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [product name]",
+	Short: "Take snapshot from product",
+	Long: `Take snapshot from product.
+
+Records are written to stdout in indented JSON by default. With --out, records
+are streamed to the file, which is replaced only after all records were
+written, and a summary with number of records, size and SHA-256 checksum of
+the file is printed to stderr.
+
+Formats:
+  json     Indented JSON (default)
+  ndjson   One JSON object per line
+  csv      Fields with header row, columns are taken from product schema and
+           fields of map type are flattened with dot-separated names
+  parquet  Nullable column of each field with type of product schema, time is
+           stored as timestamp in microseconds, and maps, arrays and values of
+           any type are stored in JSON
+
+Format and compression are detected from extension of --out, e.g.
+accounts.ndjson.zst or accounts.parquet. Compression of parquet applies to
+pages instead of the whole file.
+
+Records are pulled from snapshot view only if there is room in the buffer
+(--buffer) and decoded by workers in parallel (--workers), while records are
+still written in order. A record which cannot be decoded stops the snapshot
+unless --skip-errors is specified, skipped records are written to the file of
+--error-report with position, error and raw data. Snapshot view is always
+deleted, even if it was interrupted.
+
+With --resume, records are written to <out>.partial and the position is saved
+to <out>.resume periodically. Running the same command again continues after
+the last saved position, and both files are removed when it's done. Error
+report is truncated to the saved position as well, so it has every skipped
+record exactly once.
+
+Examples:
+  gravity-cli product snapshot accounts
+  gravity-cli product snapshot accounts --out accounts.ndjson.gz
+  gravity-cli product snapshot accounts --out accounts.parquet --compression zstd
+  gravity-cli product snapshot accounts --format csv --select '{id, name}' > accounts.csv
+  gravity-cli product snapshot accounts --out accounts.ndjson.zst --resume --skip-errors --error-report errors.ndjson`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductSnapshotCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// checksumWriter counts bytes and computes checksum of output
+type checksumWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size uint64
+}
+
+func (cw *checksumWriter) Write(p []byte) (int, error) {
+
+	n, err := cw.w.Write(p)
+	cw.hash.Write(p[:n])
+	atomic.AddUint64(&cw.size, uint64(n))
+
+	return n, err
+}
+
+func (cw *checksumWriter) Size() uint64 {
+	return atomic.LoadUint64(&cw.size)
+}
+
+// snapshotError is a line of error report
+type snapshotError struct {
+	Record uint64 `json:"record"`
+	Error  string `json:"error"`
+	Data   []byte `json:"data"`
+}
+
+func runProductSnapshotCmd(cctx *ProductCommandContext) error {
+
+	productName = cctx.Args[0]
+
+	projection, err := query.NewProjection(productFilter, productSelect)
+	if err != nil {
+		return err
+	}
+
+	// Format and compression
+	format, compression := recordfile.DetectFormat(productSnapshotOut)
+	if len(productSnapshotFormat) > 0 {
+		format, err = recordfile.ParseFormat(productSnapshotFormat)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(format) == 0 {
+		format = recordfile.FormatJSON
+	}
+
+	if len(productSnapshotCompression) > 0 {
+		compression, err = sink.ParseCompression(productSnapshotCompression)
+		if err != nil {
+			return err
+		}
+	}
+
+	if format == recordfile.FormatParquet && len(productSnapshotOut) == 0 {
+		return errors.New("--out is required for format parquet")
+	}
+
+	if productSnapshotResume {
+		if len(productSnapshotOut) == 0 {
+			return errors.New("--out is required for --resume")
+		}
+
+		if format == recordfile.FormatParquet {
+			return errors.New("--resume cannot be used with format parquet")
+		}
+	}
+
+	if len(productSnapshotErrorReport) > 0 && !productSnapshotSkipErrors {
+		return errors.New("--error-report requires --skip-errors")
+	}
+
+	if productSnapshotWorkers <= 0 {
+		return errors.New("workers should be greater than 0")
+	}
+
+	// Getting product information
+	product, err := cctx.Product.GetClient().GetProduct(productName)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return errors.New(fmt.Sprintf("Not found product \"%s\"\n", productName))
+	}
+
+	if !product.Setting.EnabledSnapshot {
+		cctx.Cmd.SilenceUsage = true
+		return errors.New("Product snapshot is not enabled")
+	}
+
+	// Typed columns of product schema, results of --select have their own
+	// columns.
+	var columns []recordfile.Column
+	if len(productSelect) == 0 {
+		columns = recordfile.Columns(product.Setting.Schema)
+	}
+
+	if format == recordfile.FormatParquet && len(columns) == 0 {
+		return errors.New("format parquet requires product schema and cannot be used with --select")
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	// Position of snapshot which was written to partial file
+	stateFile := productSnapshotOut + ".resume"
+	state := &snapshot.State{
+		Product:     productName,
+		Format:      string(format),
+		Compression: string(compression),
+	}
+
+	if productSnapshotResume {
+		saved, err := snapshot.LoadState(stateFile)
+		if err != nil {
+			return err
+		}
+
+		if saved != nil {
+			if saved.Product != state.Product || saved.Format != state.Format || saved.Compression != state.Compression {
+				return fmt.Errorf("%s was saved for product \"%s\" in format %s with compression %s, remove it to start over",
+					stateFile,
+					saved.Product,
+					saved.Format,
+					saved.Compression,
+				)
+			}
+
+			state = saved
+		}
+	}
+
+	// Columns of CSV which were taken from the first record
+	if len(columns) == 0 {
+		for _, name := range state.Columns {
+			columns = append(columns, recordfile.Column{Name: name})
+		}
+	}
+
+	// Records are written to temporary file which replaces the output file
+	// when it's done. With --resume, the partial file is kept on failure and
+	// truncated to the last saved state.
+	var out io.Writer = os.Stdout
+	var tmp *os.File
+	if len(productSnapshotOut) > 0 {
+		if productSnapshotResume {
+			tmp, err = openSnapshotPartial(productSnapshotOut+".partial", state.Size)
+		} else {
+			tmp, err = os.CreateTemp(filepath.Dir(productSnapshotOut), filepath.Base(productSnapshotOut)+".*.tmp")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if tmp != nil {
+				tmp.Close()
+
+				if !productSnapshotResume {
+					os.Remove(tmp.Name())
+				}
+			}
+		}()
+
+		out = tmp
+	}
+
+	cw := &checksumWriter{
+		w:    out,
+		hash: sha256.New(),
+	}
+
+	newWriter := func() (recordfile.Writer, error) {
+		return recordfile.NewWriter(cw, &recordfile.Options{
+			Format:      format,
+			Compression: compression,
+			Columns:     columns,
+			NoHeader:    state.Size > 0,
+		})
+	}
+
+	writer, err := newWriter()
+	if err != nil {
+		return err
+	}
+
+	// Records which cannot be decoded are reported to file
+	var report *json.Encoder
+	var reportFile *os.File
+	if len(productSnapshotErrorReport) > 0 {
+		if productSnapshotResume {
+			reportFile, err = openSnapshotPartial(productSnapshotErrorReport, state.ReportSize)
+		} else {
+			reportFile, err = os.OpenFile(productSnapshotErrorReport, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		}
+
+		if err != nil {
+			return err
+		}
+		defer reportFile.Close()
+
+		report = json.NewEncoder(reportFile)
+	}
+
+	if len(state.LastKey) > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming snapshot of product \"%s\" after %d records\n", productName, state.Records)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Create snapshot view, it's always deleted even if it was interrupted
+	opts := snapshot.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+	opts.BufferSize = productSnapshotBuffer
+	opts.LastKey = state.LastKey
+
+	reader, err := snapshot.Open(ctx, cctx.Connector.GetClient(), productName, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return errors.New("snapshot was interrupted")
+		}
+
+		return err
+	}
+	defer reader.Close()
+
+	resumed := state.Written
+	written := state.Written
+	skipped := state.Skipped
+	failed := state.Failed
+	size := uint64(state.Size)
+
+	// Live progress
+	done := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+
+		if tmp == nil || productSnapshotNoProgress || !isTerminal(os.Stderr) {
+			<-done
+			return
+		}
+
+		startTime := time.Now()
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				printSnapshotProgress(atomic.LoadUint64(&written), resumed, size+cw.Size(), time.Since(startTime))
+			case <-done:
+				printSnapshotProgress(atomic.LoadUint64(&written), resumed, size+cw.Size(), time.Since(startTime))
+				fmt.Fprintln(os.Stderr)
+				return
+			}
+		}
+	}()
+
+	// Output is committed to disk at the end of a pulled batch, so snapshot
+	// can be resumed after the last key of the batch.
+	lastCheckpoint := time.Now()
+	checkpoint := func(records uint64, lastKey string) error {
+
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		if err := tmp.Sync(); err != nil {
+			return err
+		}
+
+		if reportFile != nil {
+			if err := reportFile.Sync(); err != nil {
+				return err
+			}
+
+			offset, err := reportFile.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+
+			state.ReportSize = offset
+		}
+
+		if c, ok := writer.(interface{ Columns() []recordfile.Column }); ok && len(columns) == 0 {
+			columns = c.Columns()
+			for _, column := range columns {
+				state.Columns = append(state.Columns, column.Name)
+			}
+		}
+
+		state.LastKey = lastKey
+		state.Records = records
+		state.Written = atomic.LoadUint64(&written)
+		state.Skipped = atomic.LoadUint64(&skipped)
+		state.Failed = atomic.LoadUint64(&failed)
+		state.Size = int64(size + cw.Size())
+
+		if err := state.Save(stateFile); err != nil {
+			return err
+		}
+
+		lastCheckpoint = time.Now()
+
+		w, err := newWriter()
+		if err != nil {
+			return err
+		}
+
+		writer = w
+
+		return nil
+	}
+
+	decode := func(msg *nats.Msg) (interface{}, error) {
+
+		var r record_type.Record
+		err := record_type.Unmarshal(msg.Data, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		return projection.Apply(r.AsMap())
+	}
+
+	records := state.Records
+	startTime := time.Now()
+
+	err = snapshot.Decode(ctx, reader, productSnapshotWorkers, productSnapshotWorkers*64, decode, func(result *snapshot.Result) error {
+
+		if result.Err != nil {
+			if !productSnapshotSkipErrors {
+				return fmt.Errorf("record %d: %w", records+result.Index, result.Err)
+			}
+
+			atomic.AddUint64(&failed, 1)
+
+			if report != nil {
+				err := report.Encode(&snapshotError{
+					Record: records + result.Index,
+					Error:  result.Err.Error(),
+					Data:   result.Msg.Data,
+				})
+				if err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(os.Stderr, "Skipped record %d: %v\n", records+result.Index, result.Err)
+			}
+		} else {
+
+			outputs := result.Value.([]interface{})
+
+			// Filtered out
+			if len(outputs) == 0 {
+				atomic.AddUint64(&skipped, 1)
+			}
+
+			for _, output := range outputs {
+				if err := writer.Write(output); err != nil {
+					return err
+				}
+
+				atomic.AddUint64(&written, 1)
+			}
+		}
+
+		result.Msg.Ack()
+
+		lastKey, ok := reader.BatchKey(result.Index)
+		if ok && productSnapshotResume && time.Since(lastCheckpoint) >= productSnapshotCheckpointInterval {
+			return checkpoint(records+result.Index, lastKey)
+		}
+
+		return nil
+	})
+
+	close(done)
+	<-progressDone
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = errors.New("snapshot was interrupted")
+		}
+
+		if productSnapshotResume {
+			return fmt.Errorf("%w, run it again with --resume to continue after %d records", err, state.Records)
+		}
+
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if err := reader.Close(); err != nil {
+		return err
+	}
+
+	if tmp == nil {
+		return nil
+	}
+
+	// Output file is replaced after all records were committed to disk
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), productSnapshotOut); err != nil {
+		return err
+	}
+
+	tmp = nil
+
+	if productSnapshotResume {
+		if err := os.Remove(stateFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// Checksum of resumed file covers records which were written before
+	checksum := hex.EncodeToString(cw.hash.Sum(nil))
+	if size > 0 {
+		checksum, err = fileChecksum(productSnapshotOut)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d records (%d filtered out, %d failed) to %s in %s, format: %s, compression: %s, size: %s, sha256: %s\n",
+		written,
+		skipped,
+		failed,
+		productSnapshotOut,
+		time.Since(startTime).Round(time.Millisecond),
+		format,
+		compression,
+		units.HumanSize(float64(size+cw.Size())),
+		checksum,
+	)
+
+	return nil
+}
+
+// openSnapshotPartial opens partial output or error report and truncates it to
+// size which was saved in state.
+func openSnapshotPartial(filename string, size int64) (*os.File, error) {
+
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info.Size() < size {
+		f.Close()
+		return nil, fmt.Errorf("%s is smaller than saved state (%d bytes), remove it to start over", filename, size)
+	}
+
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func fileChecksum(filename string) (string, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func printSnapshotProgress(written uint64, resumed uint64, size uint64, elapsed time.Duration) {
+
+	fmt.Fprintf(os.Stderr, "\rExported: %d records, %s, Rate: %.1f records/s   ",
+		written,
+		units.HumanSize(float64(size)),
+		float64(written-resumed)/elapsed.Seconds(),
+	)
+}
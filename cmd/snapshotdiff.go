@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/importer"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/recorddiff"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/recordfile"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/snapshot"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	record_type "github.com/BrobridgeOrg/gravity-sdk/v2/types/record"
+	"github.com/nats-io/nats.go"
+	"github.com/spf13/cobra"
+)
+
+var ErrSnapshotsDiffer = errors.New("differences found between snapshots")
+
+var snapshotDiffPrimaryKey []string
+var snapshotDiffFormat string
+var snapshotDiffSummary bool
+var snapshotDiffNoColor bool
+var snapshotDiffMemoryLimit int
+var snapshotDiffTempDir string
+
+func init() {
+
+	productSnapshotCmd.AddCommand(productSnapshotDiffCmd)
+	productSnapshotDiffCmd.Flags().StringSliceVar(&snapshotDiffPrimaryKey, "primary-key", []string{}, "Specify fields of primary key (default: primary key of rules)")
+	productSnapshotDiffCmd.Flags().StringVar(&snapshotDiffFormat, "format", "text", "Output format of changes (text or ndjson)")
+	productSnapshotDiffCmd.Flags().BoolVar(&snapshotDiffSummary, "summary", false, "Print summary counts only")
+	productSnapshotDiffCmd.Flags().BoolVar(&snapshotDiffNoColor, "no-color", false, "Disable colored output")
+	productSnapshotDiffCmd.Flags().IntVar(&snapshotDiffMemoryLimit, "memory-limit", recorddiff.NewOptions().MemoryLimit, "Maximum number of records kept in memory before spilling to disk")
+	productSnapshotDiffCmd.Flags().StringVar(&snapshotDiffTempDir, "temp-dir", "", "Directory of spilled records (default: system temporary directory)")
+}
+
+var productSnapshotDiffCmd = &cobra.Command{
+	Use:   "diff [product name] [product name or file]",
+	Short: "Show differences between snapshots of products or snapshot file",
+	Long: `Show differences between snapshots of products or snapshot file.
+
+Records are keyed by primary key of rules of the first product, and records
+which were added, removed and changed in the second product or file are
+reported with field-level differences, followed by summary counts on stderr.
+Snapshot files in NDJSON, JSON or CSV are read like "product restore", values
+of CSV are converted by schema of the first product.
+
+Records are kept in memory up to --memory-limit, then all records are spilled
+to partition files on disk and compared partition by partition. Changes are
+reported in order of keys, or in order of keys in each partition if records
+were spilled.
+
+It exits with non-zero status if there are differences.
+
+Examples:
+  gravity-cli product snapshot diff accounts accounts_v2
+  gravity-cli product snapshot diff accounts accounts.ndjson.zst --summary
+  gravity-cli product snapshot diff accounts accounts.csv --format ndjson > changes.ndjson`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductSnapshotDiffCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func runProductSnapshotDiffCmd(cctx *ProductCommandContext) error {
+
+	productName = cctx.Args[0]
+
+	if snapshotDiffFormat != "text" && snapshotDiffFormat != "ndjson" {
+		return fmt.Errorf("unsupported format \"%s\" (text or ndjson)", snapshotDiffFormat)
+	}
+
+	product, err := getSnapshotProduct(cctx, productName)
+	if err != nil {
+		return err
+	}
+
+	primaryKey := snapshotDiffPrimaryKey
+	if len(primaryKey) == 0 {
+		primaryKey, err = findPrimaryKey(product.Setting)
+		if err != nil {
+			return err
+		}
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	interrupted := func(err error) error {
+		if ctx.Err() != nil {
+			return errors.New("snapshot diff was interrupted")
+		}
+
+		return err
+	}
+
+	opts := recorddiff.NewOptions()
+	opts.MemoryLimit = snapshotDiffMemoryLimit
+	opts.TempDir = snapshotDiffTempDir
+
+	differ := recorddiff.New(opts)
+	defer differ.Close()
+
+	add := func(side recorddiff.Side, record map[string]interface{}) error {
+
+		key, err := recordKey(record, primaryKey)
+		if err != nil {
+			return err
+		}
+
+		return differ.Add(side, key, record)
+	}
+
+	// The second source is a file if it exists
+	target := cctx.Args[1]
+	load := func(fn func(record map[string]interface{}) error) error {
+		return loadSnapshotDiffProduct(ctx, cctx, target, fn)
+	}
+
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		load = func(fn func(record map[string]interface{}) error) error {
+			return loadSnapshotDiffFile(ctx, target, product.Setting.Schema, fn)
+		}
+	} else if _, err := getSnapshotProduct(cctx, target); err != nil {
+		return err
+	}
+
+	// The first source is always a product
+	if err := loadSnapshotDiffProduct(ctx, cctx, productName, func(record map[string]interface{}) error {
+		return add(recorddiff.SideA, record)
+	}); err != nil {
+		return interrupted(err)
+	}
+
+	if err := load(func(record map[string]interface{}) error {
+		return add(recorddiff.SideB, record)
+	}); err != nil {
+		return interrupted(err)
+	}
+
+	// Changes
+	p := &diffPrinter{
+		color: !snapshotDiffNoColor && isTerminal(os.Stdout),
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	summary, err := differ.Diff(ctx, func(change *recorddiff.Change) error {
+
+		if snapshotDiffSummary {
+			return nil
+		}
+
+		if snapshotDiffFormat == "ndjson" {
+			return enc.Encode(change)
+		}
+
+		printRecordChange(p, change)
+
+		return nil
+	})
+	if err != nil {
+		return interrupted(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Added: %d, Removed: %d, Changed: %d, Unchanged: %d\n",
+		summary.Added,
+		summary.Removed,
+		summary.Changed,
+		summary.Unchanged,
+	)
+
+	if summary.DuplicatesA > 0 || summary.DuplicatesB > 0 {
+		fmt.Fprintf(os.Stderr, "Duplicate keys: %d in %s, %d in %s (the last record was compared)\n",
+			summary.DuplicatesA,
+			productName,
+			summary.DuplicatesB,
+			target,
+		)
+	}
+
+	if summary.Differences() > 0 {
+		return ErrSnapshotsDiffer
+	}
+
+	return nil
+}
+
+func getSnapshotProduct(cctx *ProductCommandContext, name string) (*product_sdk.ProductInfo, error) {
+
+	product, err := cctx.Product.GetClient().GetProduct(name)
+	if err != nil {
+		cctx.Cmd.SilenceUsage = true
+		return nil, errors.New(fmt.Sprintf("Not found product \"%s\"\n", name))
+	}
+
+	if !product.Setting.EnabledSnapshot {
+		cctx.Cmd.SilenceUsage = true
+		return nil, fmt.Errorf("Product snapshot of \"%s\" is not enabled", name)
+	}
+
+	return product, nil
+}
+
+// recordKey returns key of record with values of primary key, e.g. "id=1"
+func recordKey(record map[string]interface{}, primaryKey []string) (string, error) {
+
+	parts := make([]string, 0, len(primaryKey))
+	for _, field := range primaryKey {
+
+		v, ok := record[field]
+		if !ok || v == nil {
+			data, _ := json.Marshal(record)
+			return "", fmt.Errorf("primary key \"%s\" is missing in record %s", field, data)
+		}
+
+		var value string
+		switch d := v.(type) {
+		case string:
+			value = d
+		case json.Number:
+			value = d.String()
+		default:
+			value = formatDiffValue(d)
+
+			// Values like time are encoded as JSON string
+			var s string
+			if json.Unmarshal([]byte(value), &s) == nil {
+				value = s
+			}
+		}
+
+		parts = append(parts, field+"="+value)
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+func loadSnapshotDiffProduct(ctx context.Context, cctx *ProductCommandContext, name string, fn func(record map[string]interface{}) error) error {
+
+	fmt.Fprintf(os.Stderr, "Loading snapshot of product \"%s\"...\n", name)
+
+	opts := snapshot.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+
+	reader, err := snapshot.Open(ctx, cctx.Connector.GetClient(), name, opts)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decode := func(msg *nats.Msg) (interface{}, error) {
+
+		var r record_type.Record
+		err := record_type.Unmarshal(msg.Data, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		return r.AsMap(), nil
+	}
+
+	var count uint64
+	err = snapshot.Decode(ctx, reader, runtime.NumCPU(), runtime.NumCPU()*64, decode, func(result *snapshot.Result) error {
+
+		if result.Err != nil {
+			return fmt.Errorf("record %d: %w", result.Index, result.Err)
+		}
+
+		if err := fn(result.Value.(map[string]interface{})); err != nil {
+			return err
+		}
+
+		result.Msg.Ack()
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Loaded %d records from snapshot of product \"%s\"\n", count, name)
+
+	return reader.Close()
+}
+
+func loadSnapshotDiffFile(ctx context.Context, filename string, schema map[string]interface{}, fn func(record map[string]interface{}) error) error {
+
+	input, err := recordfile.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	if input.Format == recordfile.FormatParquet {
+		return errors.New("comparing with parquet is not supported, use ndjson, json or csv")
+	}
+
+	format, err := importer.ParseFormat(string(input.Format), filename)
+	if err != nil {
+		return err
+	}
+
+	opts := importer.NewOptions()
+	opts.Format = format
+	opts.Types = importer.TypesFromSchema(schema)
+
+	reader, err := importer.NewReader(input, opts)
+	if err != nil {
+		return err
+	}
+
+	var count uint64
+	for ctx.Err() == nil {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if record.Err != nil {
+			return fmt.Errorf("%s:%d: %w", filename, record.Line, record.Err)
+		}
+
+		if err := fn(record.Data); err != nil {
+			return err
+		}
+
+		count++
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Loaded %d records from %s\n", count, filename)
+
+	return nil
+}
+
+func printRecordChange(p *diffPrinter, change *recorddiff.Change) {
+
+	switch change.Type {
+	case recorddiff.ChangeAdded:
+		fmt.Println(p.colorize(colorGreen, fmt.Sprintf("+ %s: %s", change.Key, formatDiffValue(change.New))))
+	case recorddiff.ChangeRemoved:
+		fmt.Println(p.colorize(colorRed, fmt.Sprintf("- %s: %s", change.Key, formatDiffValue(change.Old))))
+	case recorddiff.ChangeChanged:
+		fmt.Println(p.colorize(colorYellow, fmt.Sprintf("~ %s", change.Key)))
+
+		for _, f := range change.Fields {
+			switch f.Type {
+			case recorddiff.ChangeAdded:
+				fmt.Println(p.colorize(colorGreen, fmt.Sprintf("    + %s: %s", f.Field, formatDiffValue(f.New))))
+			case recorddiff.ChangeRemoved:
+				fmt.Println(p.colorize(colorRed, fmt.Sprintf("    - %s: %s", f.Field, formatDiffValue(f.Old))))
+			default:
+				fmt.Println(p.colorize(colorYellow, fmt.Sprintf("    ~ %s: %s => %s", f.Field, formatDiffValue(f.Old), formatDiffValue(f.New))))
+			}
+		}
+	}
+}
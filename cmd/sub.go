@@ -0,0 +1,885 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/checkpoint"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/eventformat"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/hook"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/query"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/subscriber"
+	gravity_sdk_types_product_event "github.com/BrobridgeOrg/gravity-sdk/v2/types/product_event"
+	"github.com/nats-io/nats.go"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+// product subscriber
+var productSubscriberName string
+var productSubscriberStartSeq uint64
+var productSubscriberPartitions []int
+var productSubscriberCount uint64
+var productSubscriberTimeout time.Duration
+var productSubscriberUntilSeq uint64
+var productSubscriberIdleTimeout time.Duration
+var productSubscriberFormat string
+var productSubscriberSince string
+var productSubscriberLast uint64
+var productSubscriberNew bool
+var productSubscriberCheckpoint string
+var productSubscriberCheckpointInterval time.Duration
+var productSubscriberSink string
+var productSubscriberSinkCompression string
+var productSubscriberSinkMaxSize string
+var productSubscriberSinkMaxAge time.Duration
+var productSubscriberSinkSyncInterval time.Duration
+var productSubscriberSinkBatch int
+var productSubscriberExec string
+var productSubscriberExecBatch int
+var productSubscriberExecBatchWait time.Duration
+var productSubscriberExecConcurrency int
+var productSubscriberExecRetries int
+var productSubscriberExecBackoff time.Duration
+var productSubscriberExecTimeout time.Duration
+var productSubscriberSkipInvalid bool
+
+// Filter and projection for events and snapshot records
+var productFilter string
+var productSelect string
+
+var (
+	ErrSubscriptionTimeout = errors.New("timeout before expected events were received")
+	ErrInvalidEvent        = errors.New("event cannot be decoded, use --skip-invalid to skip it")
+)
+
+func init() {
+
+	// Subscribe product
+	productCmd.AddCommand(productSubCmd)
+	productSubCmd.Flags().StringVar(&productSubscriberName, "name", "", "Specify subscriber name")
+	productSubCmd.Flags().Uint64Var(&productSubscriberStartSeq, "seq", 1, "Specify start stream sequence")
+	productSubCmd.Flags().StringVar(&productSubscriberSince, "since", "", "Start from events since specific time (RFC3339) or duration ago, e.g. 2024-01-02T09:00:00+08:00 or 5m")
+	productSubCmd.Flags().Uint64Var(&productSubscriberLast, "last", 0, "Start from the last N events of stream")
+	productSubCmd.Flags().BoolVar(&productSubscriberNew, "new", false, "Receive new events only")
+	productSubCmd.Flags().IntSliceVar(&productSubscriberPartitions, "partitions", []int{-1}, "Specify partitions (default -1 for all)")
+	productSubCmd.Flags().Uint64Var(&productSubscriberCount, "count", 0, "Exit after receiving specific number of events")
+	productSubCmd.Flags().DurationVar(&productSubscriberTimeout, "timeout", 0, "Exit after specific duration")
+	productSubCmd.Flags().Uint64Var(&productSubscriberUntilSeq, "until-seq", 0, "Exit after receiving event with specific stream sequence or later")
+	productSubCmd.Flags().DurationVar(&productSubscriberIdleTimeout, "idle-timeout", 0, "Exit if no events were received for specific duration")
+	productSubCmd.Flags().StringVar(&productFilter, "filter", "", `Output events which match specific jq expression only, e.g. '.method == "DELETE"'`)
+	productSubCmd.Flags().StringVar(&productSelect, "select", "", "Output result of specific jq expression instead of events, e.g. '.payload'")
+	productSubCmd.Flags().StringVar(&productSubscriberCheckpoint, "checkpoint", "", "Save position to file and resume from it on restart")
+	productSubCmd.Flags().DurationVar(&productSubscriberCheckpointInterval, "checkpoint-interval", time.Second, "Interval of saving checkpoint")
+	productSubCmd.Flags().StringVar(&productSubscriberSink, "sink", "", "Write events to sink instead of stdout, e.g. file:///var/lib/archive")
+	productSubCmd.Flags().StringVar(&productSubscriberSinkCompression, "sink-compression", "none", "Compression of sink files (none, gzip or zstd)")
+	productSubCmd.Flags().StringVar(&productSubscriberSinkMaxSize, "sink-max-size", "100MB", "Rotate sink file when it reaches specific size (0 to disable)")
+	productSubCmd.Flags().DurationVar(&productSubscriberSinkMaxAge, "sink-max-age", 0, "Rotate sink file after specific duration (0 to disable)")
+	productSubCmd.Flags().DurationVar(&productSubscriberSinkSyncInterval, "sink-sync-interval", time.Second, "Interval of committing sink to disk and acknowledging events")
+	productSubCmd.Flags().IntVar(&productSubscriberSinkBatch, "sink-batch", 500, "Commit sink to disk when specific number of events are waiting for acknowledgement")
+	productSubCmd.Flags().StringVar(&productSubscriberExec, "exec", "", "Run shell command for each event with event JSON on stdin")
+	productSubCmd.Flags().IntVar(&productSubscriberExecBatch, "exec-batch", 1, "Run command for batch of events with NDJSON on stdin")
+	productSubCmd.Flags().DurationVar(&productSubscriberExecBatchWait, "exec-batch-wait", time.Second, "Run command for incomplete batch after specific duration")
+	productSubCmd.Flags().IntVar(&productSubscriberExecConcurrency, "exec-concurrency", hook.DefaultConcurrency, "Maximum number of commands running at the same time")
+	productSubCmd.Flags().IntVar(&productSubscriberExecRetries, "exec-retries", hook.DefaultRetries, "Number of retries if command exited with non-zero status")
+	productSubCmd.Flags().DurationVar(&productSubscriberExecBackoff, "exec-backoff", hook.DefaultBackoff, "Initial delay of retry, it's doubled for each retry")
+	productSubCmd.Flags().DurationVar(&productSubscriberExecTimeout, "exec-timeout", 0, "Kill command after specific duration (0 for unlimited)")
+	productSubCmd.Flags().BoolVar(&productSubscriberSkipInvalid, "skip-invalid", false, "Acknowledge events which cannot be decoded and count them as failed instead of stopping")
+	productSubCmd.Flags().StringVar(&productSubscriberFormat, "format", "json", "Output format (json, ndjson, csv, raw, proto-text or hex)")
+}
+
+var productSubCmd = &cobra.Command{
+	Use:   "sub [product name]",
+	Short: "Generic subscription client for product",
+	Long: `Generic subscription client for product.
+
+Start position is specified by one of --seq, --since, --last and --new, it
+applies to selected partitions only. --last counts events of the whole stream,
+so only events of selected partitions among the last N events are received.
+Named subscriber which already exists resumes from its own position and
+ignores start position.
+
+Sequences are stream sequences of product everywhere: "seq" of events, --seq,
+--until-seq, GRAVITY_SEQ, checkpoints and the summary. They are unique across
+partitions and don't change when events are redelivered or received by
+another subscriber.
+
+With --checkpoint, the stream sequence up to which all events of each
+partition were acknowledged is saved to the file periodically and on exit, so
+it never moves past an event which failed and waits for redelivery.
+Subscription resumes from the checkpoint on restart, taking precedence over
+start position, and events which were received before are skipped. Delivery
+is at-least-once: events received after the last save are output again after
+a crash. Use "product sub checkpoint show/reset" to inspect or rewind
+checkpoints.
+
+With --sink, events are written to files in NDJSON instead of stdout. Files
+are named by product and creation time, and rotated by size and age. Events
+are acknowledged only after they were committed to disk (fsync), which happens
+every --sink-sync-interval, every --sink-batch events and on rotation.
+
+With --exec, shell command runs for each event with event JSON (or result of
+--select) on stdin instead of writing to stdout. Metadata is passed as
+environment variables: GRAVITY_PRODUCT, GRAVITY_SEQ (stream sequence),
+GRAVITY_SUBJECT, GRAVITY_PARTITION, GRAVITY_EVENT, GRAVITY_METHOD,
+GRAVITY_PRIMARY_KEYS (names) and GRAVITY_PRIMARY_KEY (JSON of values). With
+--exec-batch, command runs for batch of events with NDJSON on stdin, and
+GRAVITY_BATCH_SIZE, GRAVITY_FIRST_SEQ and GRAVITY_LAST_SEQ are passed instead.
+Events are acknowledged only if command exited with zero status, otherwise it
+is retried with exponential backoff and events are redelivered after retries
+are exhausted. Events which are waiting for command or retries are marked in
+progress, so they are not redelivered while command is still running. Events
+are not handled in order if --exec-concurrency is greater than 1.
+
+Subscription keeps running until it's interrupted (SIGINT or SIGTERM) or one of
+exit conditions is met. Summary is printed to stderr on exit. With --timeout,
+exit status is non-zero if --count or --until-seq was not reached in time.
+
+Events can be filtered and projected with jq expressions, which are evaluated
+over event fields: header, subject, seq, timestamp, product, event, method,
+table, primaryKey and payload. --count only counts events which match filter.
+
+Output formats:
+  json        Indented JSON (default)
+  ndjson      One JSON object per line
+  csv         Metadata and payload fields with header row, columns of payload
+              are taken from product schema
+  raw         Original ProductEvent bytes, each prefixed with its length in
+              4-byte big-endian
+  proto-text  ProductEvent and its content in protobuf text format
+  hex         Hex dump of original ProductEvent bytes
+
+An event which cannot be decoded stops subscription with non-zero exit status,
+and it will be redelivered, so it's not lost when output goes to sink or
+command. With --skip-invalid, such events are acknowledged and counted as
+failed instead.
+
+Formats raw, proto-text and hex write original messages, so --select is not
+available. Messages which cannot be decoded are still written if neither
+filter nor --exec was specified.
+
+Examples:
+  gravity-cli product sub accounts --count 10 --timeout 30s
+  gravity-cli product sub accounts --idle-timeout 5s
+  gravity-cli product sub accounts --since 5m
+  gravity-cli product sub accounts --since 2024-01-02T09:00:00+08:00 --partitions 0,1
+  gravity-cli product sub accounts --last 100
+  gravity-cli product sub accounts --checkpoint accounts.checkpoint
+  gravity-cli product sub accounts --sink file:///var/lib/archive --sink-compression zstd --sink-max-age 1h
+  gravity-cli product sub accounts --filter '.method == "DELETE"' --exec 'curl -s -d @- http://localhost:8080/deleted'
+  gravity-cli product sub accounts --filter '.method == "DELETE"'
+  gravity-cli product sub accounts --filter '.payload.id == 4' --select '{seq, method, name: .payload.name}'
+  gravity-cli product sub accounts --format csv --count 100 > accounts.csv`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if err := runProductCmd(runProductSubCmd, cmd, args); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// decodeProductEvent converts message of product to generic event
+func decodeProductEvent(msg *nats.Msg) (map[string]interface{}, error) {
+
+	var pe gravity_sdk_types_product_event.ProductEvent
+
+	err := proto.Unmarshal(msg.Data, &pe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse product event: %w", err)
+	}
+
+	md, err := msg.Metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := pe.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse content: %w", err)
+	}
+
+	event := map[string]interface{}{
+		"header":     msg.Header,
+		"subject":    msg.Subject,
+		"seq":        md.Sequence.Stream,
+		"timestamp":  md.Timestamp,
+		"product":    productName,
+		"event":      pe.EventName,
+		"method":     pe.Method.String(),
+		"table":      pe.Table,
+		"primaryKey": pe.PrimaryKeys,
+		"payload":    r.AsMap(),
+	}
+
+	return event, nil
+}
+
+func openProductSink() (sink.Sink, error) {
+
+	opts := sink.NewOptions()
+	opts.Prefix = productName
+	opts.MaxAge = productSubscriberSinkMaxAge
+
+	compression, err := sink.ParseCompression(productSubscriberSinkCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Compression = compression
+
+	opts.MaxSize, err = sink.ParseSize(productSubscriberSinkMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return sink.Open(productSubscriberSink, opts)
+}
+
+type pendingAck struct {
+	msg *nats.Msg
+	ack func()
+}
+
+// sinkAcks writes events to sink and holds acknowledgements until sink was
+// committed to disk. Acknowledgements are performed immediately if there is
+// no sink.
+type sinkAcks struct {
+	sink    sink.Sink
+	mutex   sync.Mutex
+	pending []pendingAck
+}
+
+// Write writes a record to sink, it's used by writer of events
+func (sa *sinkAcks) Write(data []byte) (int, error) {
+
+	err := sa.sink.Write(data)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+func (sa *sinkAcks) Add(msg *nats.Msg, ack func()) {
+
+	if sa == nil {
+		ack()
+		return
+	}
+
+	sa.mutex.Lock()
+	sa.pending = append(sa.pending, pendingAck{msg: msg, ack: ack})
+	sa.mutex.Unlock()
+}
+
+func (sa *sinkAcks) Len() int {
+
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	return len(sa.pending)
+}
+
+// Sync commits sink to disk and acknowledges events
+func (sa *sinkAcks) Sync() error {
+
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	if err := sa.sink.Sync(); err != nil {
+		return err
+	}
+
+	for _, p := range sa.pending {
+		p.ack()
+	}
+
+	sa.pending = sa.pending[:0]
+
+	return nil
+}
+
+// Abort asks server to redeliver events which were not committed
+func (sa *sinkAcks) Abort() {
+
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	for _, p := range sa.pending {
+		subscriber.Redeliver(p.msg)
+	}
+
+	sa.pending = sa.pending[:0]
+}
+
+// parseSince parses time in RFC3339 or duration before now
+func parseSince(value string, now time.Time) (time.Time, error) {
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since \"%s\": expected RFC3339 time or duration", value)
+	}
+
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since \"%s\": duration must not be negative", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+// productCSVColumns returns columns of events in CSV, columns of payload are
+// taken from product schema. It returns nil to take columns from the first
+// event if schema is not available.
+func productCSVColumns(cctx *ProductCommandContext) []string {
+
+	product, err := cctx.Product.GetClient().GetProduct(productName)
+	if err != nil || product.Setting == nil || len(product.Setting.Schema) == 0 {
+		fmt.Fprintf(os.Stderr, "Schema of product is not available, columns are taken from the first event\n")
+		return nil
+	}
+
+	columns := []string{"seq", "timestamp", "event", "method", "table", "primaryKey"}
+
+	return append(columns, eventformat.SchemaColumns("payload", product.Setting.Schema)...)
+}
+
+func runProductSubCmd(cctx *ProductCommandContext) error {
+
+	productName = cctx.Args[0]
+
+	fmt.Fprintf(os.Stderr, "Subscribing to product: %s\n", productName)
+
+	if len(productSubscriberName) > 0 {
+		fmt.Fprintf(os.Stderr, "Subscriber name: %s\n", productSubscriberName)
+	}
+
+	projection, err := query.NewProjection(productFilter, productSelect)
+	if err != nil {
+		return err
+	}
+
+	format, err := eventformat.ParseFormat(productSubscriberFormat)
+	if err != nil {
+		return err
+	}
+
+	if format.IsBinary() && len(productSelect) > 0 {
+		return fmt.Errorf("--select cannot be used with format %s", format)
+	}
+
+	writerOpts := &eventformat.Options{}
+	if format == eventformat.FormatCSV && len(productSelect) == 0 {
+		writerOpts.Columns = productCSVColumns(cctx)
+	}
+
+	// Events are written to sink in NDJSON
+	var out io.Writer = os.Stdout
+	var acks *sinkAcks
+	if len(productSubscriberSink) > 0 {
+
+		if cctx.Cmd.Flags().Changed("format") && format != eventformat.FormatNDJSON {
+			return fmt.Errorf("--sink writes events in ndjson, format %s is not supported", format)
+		}
+
+		format = eventformat.FormatNDJSON
+
+		s, err := openProductSink()
+		if err != nil {
+			cctx.Cmd.SilenceUsage = true
+			return err
+		}
+
+		defer s.Close()
+
+		fmt.Fprintf(os.Stderr, "Sink: %s\n", s)
+
+		acks = &sinkAcks{
+			sink: s,
+		}
+
+		out = acks
+	}
+
+	writer, err := eventformat.NewWriter(format, out, writerOpts)
+	if err != nil {
+		return err
+	}
+
+	// Events are passed to hook instead of writing to output
+	var hooks *eventHook
+	var hookName string
+	var hookBatch int
+	var hookBatchWait time.Duration
+	var hookConcurrency int
+	switch {
+	case len(productForwardURL) > 0:
+		hooks, err = newProductWebhook()
+		if err != nil {
+			return err
+		}
+
+		hookName = "Request"
+		hookBatch = productForwardBatch
+		hookBatchWait = productForwardBatchWait
+		hookConcurrency = productForwardConcurrency
+
+		fmt.Fprintf(os.Stderr, "Forwarding to: %s\n", productForwardURL)
+
+	case len(productSubscriberExec) > 0:
+
+		if acks != nil {
+			return errors.New("--exec cannot be used with --sink")
+		}
+
+		if cctx.Cmd.Flags().Changed("format") {
+			return errors.New("--exec passes events in JSON, --format is not supported")
+		}
+
+		if productSubscriberExecBatch <= 0 {
+			return errors.New("--exec-batch must be greater than 0")
+		}
+
+		opts := hook.NewOptions()
+		opts.Command = productSubscriberExec
+		opts.Concurrency = productSubscriberExecConcurrency
+		opts.Retries = productSubscriberExecRetries
+		opts.Backoff = productSubscriberExecBackoff
+		opts.Timeout = productSubscriberExecTimeout
+
+		hooks = newCommandHook(opts, productSubscriberExecBatch)
+		hookName = opts.Name
+		hookBatch = productSubscriberExecBatch
+		hookBatchWait = productSubscriberExecBatchWait
+		hookConcurrency = productSubscriberExecConcurrency
+
+		fmt.Fprintf(os.Stderr, "Command: %s\n", productSubscriberExec)
+	}
+
+	// Acknowledgements must be in order for checkpoint
+	if hooks != nil && len(productSubscriberCheckpoint) > 0 && hookConcurrency > 1 {
+		return errors.New("--checkpoint cannot be used with concurrency greater than 1")
+	}
+
+	// Start position
+	positions := 0
+	for _, name := range []string{"seq", "since", "last", "new"} {
+		if cctx.Cmd.Flags().Changed(name) {
+			positions++
+		}
+	}
+
+	if positions > 1 {
+		return errors.New("only one of --seq, --since, --last and --new can be specified")
+	}
+
+	// Resume from checkpoint
+	var cp *checkpoint.Checkpoint
+	var resumeSeq uint64
+	if len(productSubscriberCheckpoint) > 0 {
+		cp, err = checkpoint.Load(productSubscriberCheckpoint)
+		if err != nil {
+			cctx.Cmd.SilenceUsage = true
+			return err
+		}
+
+		err = cp.Check(cctx.Connector.GetDomain(), productName)
+		if err != nil {
+			cctx.Cmd.SilenceUsage = true
+			return err
+		}
+
+		resumeSeq = cp.StartSequence(productSubscriberPartitions)
+	}
+
+	var since *time.Time
+	switch {
+	case resumeSeq > 0:
+		fmt.Fprintf(os.Stderr, "Resuming from checkpoint: sequence %d\n", resumeSeq)
+	case productSubscriberNew:
+		fmt.Fprintf(os.Stderr, "Start Position: new events\n")
+	case len(productSubscriberSince) > 0:
+		t, err := parseSince(productSubscriberSince, time.Now())
+		if err != nil {
+			return err
+		}
+
+		since = &t
+		fmt.Fprintf(os.Stderr, "Start Time: %s\n", t.Format(time.RFC3339))
+	case productSubscriberLast > 0:
+		fmt.Fprintf(os.Stderr, "Start Position: last %d events\n", productSubscriberLast)
+	default:
+		fmt.Fprintf(os.Stderr, "Start Sequence: %d\n", productSubscriberStartSeq)
+	}
+
+	var received uint64
+	var skipped uint64
+	var failed uint64
+	var lastSeq uint64
+	var stopping int32
+
+	stop := make(chan string, 1)
+	requestStop := func(reason string) {
+		atomic.StoreInt32(&stopping, 1)
+		select {
+		case stop <- reason:
+		default:
+		}
+	}
+
+	activity := make(chan struct{}, 1)
+
+	allPartitions := false
+	for _, p := range productSubscriberPartitions {
+		if p == -1 {
+			allPartitions = true
+		}
+	}
+
+	// Events are acknowledged if hook succeeded, otherwise they will be
+	// redelivered.
+	if hooks != nil {
+		hooks.done = func(items []*hookItem, err error) {
+
+			if err != nil {
+				atomic.AddUint64(&failed, uint64(len(items)))
+				fmt.Fprintf(os.Stderr, "%s failed for events %d-%d: %v\n", hookName, items[0].seq, items[len(items)-1].seq, err)
+
+				for _, item := range items {
+					subscriber.Redeliver(item.msg)
+				}
+
+				return
+			}
+
+			for _, item := range items {
+				item.ack()
+
+				n := atomic.AddUint64(&received, 1)
+
+				// Exit conditions
+				if productSubscriberCount > 0 && n >= productSubscriberCount {
+					requestStop("count reached")
+				}
+
+				if productSubscriberUntilSeq > 0 && item.seq >= productSubscriberUntilSeq {
+					requestStop("sequence reached")
+				}
+			}
+		}
+	}
+
+	handler := func(msg *nats.Msg) {
+
+		// The rest of messages will be redelivered
+		if atomic.LoadInt32(&stopping) == 1 {
+			subscriber.Redeliver(msg)
+			return
+		}
+
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+
+		md, _ := msg.Metadata()
+		if md != nil {
+			atomic.StoreUint64(&lastSeq, md.Sequence.Stream)
+		}
+
+		// Acknowledged events are recorded by checkpoint. Acknowledgements
+		// are deferred in order until sink was committed to disk.
+		partition, perr := subscriber.Partition(msg.Subject)
+		ack := func() {
+			acks.Add(msg, func() {
+				msg.Ack()
+
+				if cp != nil && perr == nil && md != nil {
+					cp.Update(partition, md.Sequence.Stream, md.Sequence.Consumer, allPartitions)
+				}
+			})
+		}
+
+		// Received before checkpoint was saved
+		if cp != nil && perr == nil && md != nil && md.Sequence.Stream <= cp.Received(partition) {
+			acks.Add(msg, func() {
+				msg.Ack()
+
+				if allPartitions {
+					cp.Cover(md.Sequence.Stream)
+				}
+			})
+
+			return
+		}
+
+		// Position doesn't advance past events which failed and will be
+		// redelivered until they were acknowledged
+		if cp != nil && perr == nil && md != nil {
+			cp.Track(partition, md.Sequence.Stream)
+		}
+
+		event, err := decodeProductEvent(msg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+
+			// Original message can be still written for inspection, but
+			// hook requires decoded event.
+			if !format.IsBinary() || len(productFilter) > 0 || hooks != nil {
+				atomic.AddUint64(&failed, 1)
+
+				if productSubscriberSkipInvalid {
+					ack()
+					return
+				}
+
+				subscriber.Redeliver(msg)
+				requestStop("invalid event")
+				return
+			}
+		}
+
+		var outputs []interface{}
+		if event != nil {
+			outputs, err = projection.Apply(event)
+			if err != nil {
+				atomic.AddUint64(&failed, 1)
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				ack()
+				return
+			}
+
+			// Filtered out
+			if len(outputs) == 0 {
+				atomic.AddUint64(&skipped, 1)
+				ack()
+				return
+			}
+		}
+
+		// Hook acknowledges events when it succeeded
+		if hooks != nil {
+			var seq uint64
+			if md != nil {
+				seq = md.Sequence.Stream
+			}
+
+			hooks.Add(&hookItem{
+				msg:     msg,
+				seq:     seq,
+				event:   event,
+				outputs: outputs,
+				ack:     ack,
+			})
+
+			return
+		}
+
+		if err := writer.Write(msg, outputs); err != nil {
+			atomic.AddUint64(&failed, 1)
+			fmt.Fprintf(os.Stderr, "Failed to write event: %v\n", err)
+			subscriber.Redeliver(msg)
+			requestStop("output error")
+			return
+		}
+
+		ack()
+
+		if acks != nil && acks.Len() >= productSubscriberSinkBatch {
+			if err := acks.Sync(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to commit sink: %v\n", err)
+				requestStop("sink error")
+			}
+		}
+
+		n := atomic.AddUint64(&received, 1)
+
+		// Exit conditions
+		if productSubscriberCount > 0 && n >= productSubscriberCount {
+			requestStop("count reached")
+		}
+
+		if productSubscriberUntilSeq > 0 && md != nil && md.Sequence.Stream >= productSubscriberUntilSeq {
+			requestStop("sequence reached")
+		}
+	}
+
+	// Initializing subscriber
+	opts := subscriber.NewOptions()
+	opts.Domain = cctx.Connector.GetDomain()
+	opts.Name = productSubscriberName
+	opts.Partitions = productSubscriberPartitions
+	if resumeSeq > 0 {
+		opts.StartSequence = resumeSeq
+	} else {
+		opts.StartSequence = productSubscriberStartSeq
+		opts.StartTime = since
+		opts.Last = productSubscriberLast
+		opts.DeliverNew = productSubscriberNew
+	}
+
+	cctx.Cmd.SilenceUsage = true
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	startTime := time.Now()
+
+	sub, err := subscriber.Subscribe(cctx.Connector.GetClient(), productName, handler, opts)
+	if err != nil {
+		return err
+	}
+
+	var timeoutC <-chan time.Time
+	if productSubscriberTimeout > 0 {
+		timer := time.NewTimer(productSubscriberTimeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	var idleC <-chan time.Time
+	var idleTimer *time.Timer
+	if productSubscriberIdleTimeout > 0 {
+		idleTimer = time.NewTimer(productSubscriberIdleTimeout)
+		defer idleTimer.Stop()
+		idleC = idleTimer.C
+	}
+
+	var checkpointC <-chan time.Time
+	if cp != nil && productSubscriberCheckpointInterval > 0 {
+		ticker := time.NewTicker(productSubscriberCheckpointInterval)
+		defer ticker.Stop()
+		checkpointC = ticker.C
+	}
+
+	var syncC <-chan time.Time
+	if acks != nil && productSubscriberSinkSyncInterval > 0 {
+		ticker := time.NewTicker(productSubscriberSinkSyncInterval)
+		defer ticker.Stop()
+		syncC = ticker.C
+	}
+
+	var execC <-chan time.Time
+	if hooks != nil && hookBatch > 1 && hookBatchWait > 0 {
+		ticker := time.NewTicker(hookBatchWait)
+		defer ticker.Stop()
+		execC = ticker.C
+	}
+
+	var reason string
+	var result error
+
+wait:
+	for {
+		select {
+		case reason = <-stop:
+			if reason == "invalid event" {
+				result = ErrInvalidEvent
+			}
+			break wait
+		case <-ctx.Done():
+			reason = "interrupted"
+			break wait
+		case <-timeoutC:
+			reason = "timeout"
+			if productSubscriberCount > 0 || productSubscriberUntilSeq > 0 {
+				result = ErrSubscriptionTimeout
+			}
+			break wait
+		case <-idleC:
+			reason = "idle timeout"
+			break wait
+		case <-activity:
+			if idleTimer != nil {
+				if !idleTimer.Stop() {
+					select {
+					case <-idleTimer.C:
+					default:
+					}
+				}
+				idleTimer.Reset(productSubscriberIdleTimeout)
+			}
+		case <-syncC:
+			if err := acks.Sync(); err != nil {
+				reason = "sink error"
+				result = fmt.Errorf("failed to commit sink: %w", err)
+				break wait
+			}
+		case <-execC:
+			hooks.Flush()
+		case <-checkpointC:
+			if err := cp.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to save checkpoint: %v\n", err)
+			}
+		case <-sub.Done():
+			reason = "subscription closed"
+			result = sub.Err()
+			break wait
+		}
+	}
+
+	atomic.StoreInt32(&stopping, 1)
+
+	// Stop receiving and make sure acknowledgements were sent
+	if err := sub.Close(); err != nil && result == nil {
+		result = err
+	}
+
+	// Events which are waiting for hook, retries are canceled so they will
+	// be redelivered.
+	if hooks != nil {
+		hooks.Flush()
+		hooks.Close()
+	}
+
+	// Events which have been written to sink
+	if acks != nil {
+		err := acks.Sync()
+		if err == nil {
+			err = acks.sink.Close()
+		}
+
+		if err != nil {
+			acks.Abort()
+			if result == nil {
+				result = fmt.Errorf("failed to commit sink: %w", err)
+			}
+		}
+	}
+
+	if err := cctx.Connector.GetClient().GetConnection().Flush(); err != nil && result == nil {
+		result = err
+	}
+
+	if cp != nil {
+		if err := cp.Save(); err != nil && result == nil {
+			result = fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Stopped (%s): received %d events, %d filtered out, %d failed, last sequence %d, elapsed %s\n",
+		reason,
+		atomic.LoadUint64(&received),
+		atomic.LoadUint64(&skipped),
+		atomic.LoadUint64(&failed),
+		atomic.LoadUint64(&lastSeq),
+		time.Since(startTime).Round(time.Millisecond),
+	)
+
+	return result
+}
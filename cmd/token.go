@@ -33,6 +33,12 @@ type TokenCommandContext struct {
 
 type tokenCmdFunc func(*TokenCommandContext) error
 
+// TokenInfo is the full representation of token for machine-readable output
+type TokenInfo struct {
+	Token   string                  `json:"token"`
+	Setting *token_sdk.TokenSetting `json:"setting"`
+}
+
 func init() {
 
 	RootCmd.AddCommand(tokenCmd)
@@ -67,9 +73,14 @@ func runTokenCmd(fn tokenCmdFunc, cmd *cobra.Command, args []string) error {
 
 	var cctx *TokenCommandContext
 
-	config.SetHost(host)
+	if err := config.SetHost(host); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
 	config.SetDomain(domain)
 	config.SetAccessToken(accessToken)
+	config.SetSecurity(&security)
 
 	app := fx.New(
 		fx.Supply(config),
@@ -133,6 +144,12 @@ func runTokenListAvailablePermissionsCmd(cctx *TokenCommandContext) error {
 		return errors.New("No available permissions")
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, permissions)
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{
 		"Permissions",
@@ -184,6 +201,12 @@ func runTokenListCmd(cctx *TokenCommandContext) error {
 		return err
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, tokens)
+	}
+
 	if len(tokens) == 0 {
 		cctx.Cmd.SilenceUsage = true
 		return errors.New("No available tokens")
@@ -380,6 +403,15 @@ func runTokenInfoCmd(cctx *TokenCommandContext) error {
 		return errors.New(fmt.Sprintf("Not found token \"%s\"\n", tokenID))
 	}
 
+	// Machine-readable output
+	if !printer.IsTable() {
+		cctx.Cmd.SilenceUsage = true
+		return printer.Print(os.Stdout, &TokenInfo{
+			Token:   token,
+			Setting: tokenSetting,
+		})
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
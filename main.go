@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/BrobridgeOrg/gravity-cli/cmd"
@@ -10,6 +11,13 @@ func main() {
 
 	err := cmd.RootCmd.Execute()
 	if err != nil {
+
+		// Drift is distinguished from failures, like terraform plan
+		// -detailed-exitcode
+		if errors.Is(err, cmd.ErrDriftDetected) {
+			os.Exit(2)
+		}
+
 		os.Exit(1)
 	}
 }
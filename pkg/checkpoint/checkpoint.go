@@ -0,0 +1,408 @@
+package checkpoint
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Position is the sequence up to which all events of a partition were
+// acknowledged
+type Position struct {
+	Sequence         uint64    `json:"seq" yaml:"seq"`
+	ConsumerSequence uint64    `json:"consumerSeq" yaml:"consumerSeq"`
+	UpdatedAt        time.Time `json:"updatedAt" yaml:"updatedAt"`
+}
+
+// Checkpoint keeps positions of subscription in a local file. Sequences are
+// stream sequences of product, so subscription can resume from them no matter
+// which consumer receives events.
+type Checkpoint struct {
+	Domain  string `json:"domain" yaml:"domain"`
+	Product string `json:"product" yaml:"product"`
+
+	// All partitions were received up to this sequence
+	Covered uint64 `json:"covered" yaml:"covered"`
+
+	Partitions map[string]*Position `json:"partitions" yaml:"partitions"`
+	UpdatedAt  time.Time            `json:"updatedAt" yaml:"updatedAt"`
+
+	path  string
+	mutex sync.Mutex
+	dirty bool
+
+	// Events which were received but not acknowledged yet, positions never
+	// advance past them.
+	progress map[int]*progress
+	acked    uint64
+}
+
+// progress tracks events of partition in this run
+type progress struct {
+	pending     map[uint64]struct{}
+	queue       sequenceHeap
+	acked       uint64
+	consumerSeq uint64
+}
+
+// lowest returns the lowest sequence which is pending, or 0 if there is none
+func (p *progress) lowest() uint64 {
+
+	for p.queue.Len() > 0 {
+		seq := p.queue[0]
+		if _, ok := p.pending[seq]; ok {
+			return seq
+		}
+
+		heap.Pop(&p.queue)
+	}
+
+	return 0
+}
+
+// position returns sequence up to which all received events were acknowledged
+func (p *progress) position() uint64 {
+
+	if lowest := p.lowest(); lowest > 0 && lowest-1 < p.acked {
+		return lowest - 1
+	}
+
+	return p.acked
+}
+
+type sequenceHeap []uint64
+
+func (h sequenceHeap) Len() int            { return len(h) }
+func (h sequenceHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h sequenceHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sequenceHeap) Push(x interface{}) { *h = append(*h, x.(uint64)) }
+
+func (h *sequenceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// Load reads checkpoint from file, empty checkpoint will be returned if the
+// file doesn't exist.
+func Load(path string) (*Checkpoint, error) {
+
+	cp := &Checkpoint{
+		Partitions: make(map[string]*Position),
+		path:       path,
+		progress:   make(map[int]*progress),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cp, nil
+		}
+
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file \"%s\": %w", path, err)
+	}
+
+	if cp.Partitions == nil {
+		cp.Partitions = make(map[string]*Position)
+	}
+
+	return cp, nil
+}
+
+func (cp *Checkpoint) GetPath() string {
+	return cp.path
+}
+
+// Check returns error if checkpoint belongs to another product
+func (cp *Checkpoint) Check(domain string, product string) error {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	if len(cp.Product) == 0 {
+		cp.Domain = domain
+		cp.Product = product
+		return nil
+	}
+
+	if cp.Domain != domain || cp.Product != product {
+		return fmt.Errorf("checkpoint \"%s\" belongs to product \"%s\" of domain \"%s\"", cp.path, cp.Product, cp.Domain)
+	}
+
+	return nil
+}
+
+// PartitionNames returns sorted partition names
+func (cp *Checkpoint) PartitionNames() []string {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	names := make([]string, 0, len(cp.Partitions))
+	for name := range cp.Partitions {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, errA := strconv.Atoi(names[i])
+		b, errB := strconv.Atoi(names[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// Received returns the sequence up to which events of partition were received
+func (cp *Checkpoint) Received(partition int) uint64 {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	return cp.received(partition)
+}
+
+func (cp *Checkpoint) received(partition int) uint64 {
+
+	seq := cp.Covered
+	if pos, ok := cp.Partitions[strconv.Itoa(partition)]; ok && pos.Sequence > seq {
+		seq = pos.Sequence
+	}
+
+	return seq
+}
+
+// StartSequence returns sequence to resume subscription of partitions (-1 for
+// all partitions). It returns 0 if there is nothing to resume from.
+func (cp *Checkpoint) StartSequence(partitions []int) uint64 {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	var seq uint64
+	for i, p := range partitions {
+
+		// Unknown partitions were received up to covered sequence only
+		received := cp.Covered
+		if p != -1 {
+			received = cp.received(p)
+		}
+
+		if i == 0 || received < seq {
+			seq = received
+		}
+	}
+
+	if seq == 0 {
+		return 0
+	}
+
+	return seq + 1
+}
+
+// Track records event of partition which was received, positions don't
+// advance past it until it's acknowledged by Update. Events which failed and
+// will be redelivered must remain tracked.
+func (cp *Checkpoint) Track(partition int, seq uint64) {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	p := cp.getProgress(partition)
+	if _, ok := p.pending[seq]; ok {
+		return
+	}
+
+	p.pending[seq] = struct{}{}
+	heap.Push(&p.queue, seq)
+}
+
+func (cp *Checkpoint) getProgress(partition int) *progress {
+
+	if cp.progress == nil {
+		cp.progress = make(map[int]*progress)
+	}
+
+	p, ok := cp.progress[partition]
+	if !ok {
+		p = &progress{
+			pending: make(map[uint64]struct{}),
+		}
+		cp.progress[partition] = p
+	}
+
+	return p
+}
+
+// Update records event of partition which has been acknowledged. Position of
+// partition only advances over events which were acknowledged without gaps,
+// and covered sequence is updated as well if subscription receives all
+// partitions.
+func (cp *Checkpoint) Update(partition int, seq uint64, consumerSeq uint64, all bool) {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	p := cp.getProgress(partition)
+	delete(p.pending, seq)
+	if seq > p.acked {
+		p.acked = seq
+		p.consumerSeq = consumerSeq
+	}
+
+	now := time.Now()
+
+	name := strconv.Itoa(partition)
+	pos, ok := cp.Partitions[name]
+	if !ok {
+		pos = &Position{}
+		cp.Partitions[name] = pos
+	}
+
+	if seq := p.position(); seq > pos.Sequence {
+		pos.Sequence = seq
+		if seq == p.acked {
+			pos.ConsumerSequence = p.consumerSeq
+		}
+
+		pos.UpdatedAt = now
+	}
+
+	if all {
+		cp.cover(seq)
+	}
+
+	cp.UpdatedAt = now
+	cp.dirty = true
+}
+
+// Cover records that all partitions were received up to specific sequence,
+// except events which are still pending.
+func (cp *Checkpoint) Cover(seq uint64) {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	cp.cover(seq)
+}
+
+func (cp *Checkpoint) cover(seq uint64) {
+
+	if seq > cp.acked {
+		cp.acked = seq
+	}
+
+	covered := cp.acked
+	for _, p := range cp.progress {
+		if lowest := p.lowest(); lowest > 0 && lowest-1 < covered {
+			covered = lowest - 1
+		}
+	}
+
+	if covered > cp.Covered {
+		cp.Covered = covered
+		cp.UpdatedAt = time.Now()
+		cp.dirty = true
+	}
+}
+
+// Reset removes positions of specific partitions, or all positions if no
+// partition is specified.
+func (cp *Checkpoint) Reset(partitions []int) {
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	cp.dirty = true
+	cp.UpdatedAt = time.Now()
+
+	if len(partitions) == 0 {
+		cp.Covered = 0
+		cp.Partitions = make(map[string]*Position)
+		return
+	}
+
+	// Covered sequence no longer applies to partitions which were reset, so
+	// it's moved to other partitions.
+	for _, pos := range cp.Partitions {
+		if pos.Sequence < cp.Covered {
+			pos.Sequence = cp.Covered
+		}
+	}
+
+	cp.Covered = 0
+
+	for _, p := range partitions {
+		delete(cp.Partitions, strconv.Itoa(p))
+	}
+}
+
+// Save writes checkpoint to file atomically if it was changed
+func (cp *Checkpoint) Save() error {
+
+	cp.mutex.Lock()
+	if !cp.dirty {
+		cp.mutex.Unlock()
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	cp.dirty = false
+	cp.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(cp.path, append(data, '\n'))
+	if err != nil {
+		cp.mutex.Lock()
+		cp.dirty = true
+		cp.mutex.Unlock()
+	}
+
+	return err
+}
+
+// writeFile replaces file with a temporary file so that it will never be
+// partially written.
+func writeFile(filename string, data []byte) error {
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,218 @@
+package checkpoint
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestCheckpoint(t *testing.T) *Checkpoint {
+
+	cp, err := Load(filepath.Join(t.TempDir(), "test.checkpoint"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	return cp
+}
+
+func TestUpdate(t *testing.T) {
+
+	type ack struct {
+		partition int
+		seq       uint64
+	}
+
+	tests := []struct {
+		name     string
+		all      bool
+		received []ack
+		acked    []ack
+		want     map[int]uint64
+		covered  uint64
+	}{
+		{
+			name:     "in order",
+			all:      true,
+			received: []ack{{0, 1}, {1, 2}, {0, 3}},
+			acked:    []ack{{0, 1}, {1, 2}, {0, 3}},
+			want:     map[int]uint64{0: 3, 1: 3},
+			covered:  3,
+		},
+		{
+			name:     "failed event is not passed",
+			all:      true,
+			received: []ack{{0, 1}, {0, 2}, {0, 3}, {0, 4}},
+			acked:    []ack{{0, 1}, {0, 3}, {0, 4}},
+			want:     map[int]uint64{0: 1},
+			covered:  1,
+		},
+		{
+			name:     "out of order",
+			all:      true,
+			received: []ack{{0, 1}, {0, 2}, {0, 3}},
+			acked:    []ack{{0, 3}, {0, 2}, {0, 1}},
+			want:     map[int]uint64{0: 3},
+			covered:  3,
+		},
+		{
+			name:     "pending event of another partition",
+			all:      true,
+			received: []ack{{0, 1}, {1, 2}, {0, 3}},
+			acked:    []ack{{0, 1}, {0, 3}},
+			want:     map[int]uint64{0: 3, 1: 1},
+			covered:  1,
+		},
+		{
+			name:     "partitions are independent",
+			all:      false,
+			received: []ack{{0, 1}, {1, 2}, {0, 3}},
+			acked:    []ack{{0, 1}, {0, 3}},
+			want:     map[int]uint64{0: 3, 1: 0},
+			covered:  0,
+		},
+	}
+
+	for _, tt := range tests {
+
+		cp := newTestCheckpoint(t)
+
+		for _, r := range tt.received {
+			cp.Track(r.partition, r.seq)
+		}
+
+		for _, a := range tt.acked {
+			cp.Update(a.partition, a.seq, a.seq, tt.all)
+		}
+
+		for partition, want := range tt.want {
+			if got := cp.Received(partition); got != want {
+				t.Errorf("%s: Received(%d) = %d, want %d", tt.name, partition, got, want)
+			}
+		}
+
+		if cp.Covered != tt.covered {
+			t.Errorf("%s: Covered = %d, want %d", tt.name, cp.Covered, tt.covered)
+		}
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+
+	cp := newTestCheckpoint(t)
+
+	// Event 2 failed while 3 was acknowledged
+	for seq := uint64(1); seq <= 3; seq++ {
+		cp.Track(0, seq)
+	}
+
+	cp.Update(0, 1, 1, true)
+	cp.Update(0, 3, 3, true)
+
+	// Redelivery of event 2 must not be skipped
+	if got := cp.Received(0); got >= 2 {
+		t.Fatalf("Received(0) = %d, redelivered event 2 would be skipped", got)
+	}
+
+	cp.Track(0, 2)
+	cp.Update(0, 2, 4, true)
+
+	if got := cp.Received(0); got != 3 {
+		t.Errorf("Received(0) = %d, want 3", got)
+	}
+
+	// Events received before can be covered, but not past pending events
+	cp.Track(1, 5)
+	cp.Cover(7)
+
+	if cp.Covered != 4 {
+		t.Errorf("Covered = %d, want 4", cp.Covered)
+	}
+
+	cp.Update(1, 5, 5, true)
+
+	if cp.Covered != 7 {
+		t.Errorf("Covered = %d, want 7", cp.Covered)
+	}
+}
+
+func TestStartSequence(t *testing.T) {
+
+	cp := newTestCheckpoint(t)
+	cp.Covered = 5
+	cp.Partitions["0"] = &Position{Sequence: 10}
+	cp.Partitions["1"] = &Position{Sequence: 3}
+
+	tests := []struct {
+		partitions []int
+		want       uint64
+	}{
+		{[]int{0}, 11},
+		{[]int{1}, 6},
+		{[]int{0, 2}, 6},
+		{[]int{-1}, 6},
+	}
+
+	for _, tt := range tests {
+		if got := cp.StartSequence(tt.partitions); got != tt.want {
+			t.Errorf("StartSequence(%v) = %d, want %d", tt.partitions, got, tt.want)
+		}
+	}
+
+	if got := newTestCheckpoint(t).StartSequence([]int{-1}); got != 0 {
+		t.Errorf("StartSequence of empty checkpoint = %d, want 0", got)
+	}
+}
+
+func TestReset(t *testing.T) {
+
+	cp := newTestCheckpoint(t)
+	cp.Covered = 5
+	cp.Partitions["0"] = &Position{Sequence: 10}
+	cp.Partitions["1"] = &Position{Sequence: 3}
+
+	cp.Reset([]int{0})
+
+	if _, ok := cp.Partitions["0"]; ok {
+		t.Errorf("partition 0 was not reset")
+	}
+
+	if got := cp.Received(1); got != 5 {
+		t.Errorf("Received(1) = %d, want 5", got)
+	}
+
+	if got := cp.Received(0); got != 0 {
+		t.Errorf("Received(0) = %d, want 0", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+
+	cp := newTestCheckpoint(t)
+	cp.Domain = "default"
+	cp.Product = "accounts"
+	cp.Track(0, 1)
+	cp.Track(0, 2)
+	cp.Update(0, 1, 1, true)
+	cp.Update(0, 2, 2, true)
+
+	if err := cp.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(cp.GetPath())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if err := loaded.Check("default", "accounts"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+
+	if err := loaded.Check("default", "orders"); err == nil {
+		t.Errorf("Check of another product succeeded")
+	}
+
+	if loaded.Covered != 2 || loaded.Received(0) != 2 {
+		t.Errorf("loaded Covered = %d, Received(0) = %d, want 2", loaded.Covered, loaded.Received(0))
+	}
+}
@@ -0,0 +1,133 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultServerPort = 32803
+)
+
+var (
+	ErrInvalidServerAddress = errors.New("invalid server address")
+)
+
+var supportedSchemes = map[string]bool{
+	"nats": true,
+	"tls":  true,
+	"ws":   true,
+	"wss":  true,
+}
+
+// ParseServers parses comma-separated server addresses and returns a list of
+// normalized URLs. Addresses can be URLs with nats://, tls://, ws:// or wss://
+// scheme, or host with optional port, including IPv6 literals like [::1]:32803.
+func ParseServers(servers string) ([]string, error) {
+
+	urls := make([]string, 0)
+	for _, s := range strings.Split(servers, ",") {
+
+		s = strings.TrimSpace(s)
+		if len(s) == 0 {
+			continue
+		}
+
+		u, err := ParseServer(s)
+		if err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, u)
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("%w: no server specified", ErrInvalidServerAddress)
+	}
+
+	return urls, nil
+}
+
+// ParseServer parses a single server address and returns a normalized URL.
+func ParseServer(server string) (string, error) {
+
+	if !strings.Contains(server, "://") {
+		return parseHostPort(server)
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("%w: \"%s\": %v", ErrInvalidServerAddress, server, err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if !supportedSchemes[scheme] {
+		return "", fmt.Errorf("%w: \"%s\": unsupported scheme \"%s\"", ErrInvalidServerAddress, server, u.Scheme)
+	}
+
+	if len(u.Hostname()) == 0 {
+		return "", fmt.Errorf("%w: \"%s\": missing host", ErrInvalidServerAddress, server)
+	}
+
+	port := u.Port()
+	if len(port) == 0 {
+
+		// WebSocket uses default port of HTTP
+		if scheme == "nats" || scheme == "tls" {
+			port = strconv.Itoa(DefaultServerPort)
+		}
+	} else if err := validatePort(port); err != nil {
+		return "", fmt.Errorf("%w: \"%s\": %v", ErrInvalidServerAddress, server, err)
+	}
+
+	u.Scheme = scheme
+	u.Host = u.Hostname()
+	if len(port) > 0 {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	return u.String(), nil
+}
+
+func parseHostPort(server string) (string, error) {
+
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+
+		// Without port, IPv6 literals may be enclosed in brackets or not
+		host = server
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		}
+
+		port = strconv.Itoa(DefaultServerPort)
+
+		if strings.ContainsAny(host, "[]") || (strings.Contains(host, ":") && net.ParseIP(host) == nil) {
+			return "", fmt.Errorf("%w: \"%s\"", ErrInvalidServerAddress, server)
+		}
+	}
+
+	if len(host) == 0 {
+		return "", fmt.Errorf("%w: \"%s\": missing host", ErrInvalidServerAddress, server)
+	}
+
+	if err := validatePort(port); err != nil {
+		return "", fmt.Errorf("%w: \"%s\": %v", ErrInvalidServerAddress, server, err)
+	}
+
+	return "nats://" + net.JoinHostPort(host, port), nil
+}
+
+func validatePort(port string) error {
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port \"%s\"", port)
+	}
+
+	return nil
+}
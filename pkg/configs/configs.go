@@ -2,7 +2,6 @@ package configs
 
 import (
 	"runtime"
-	"strconv"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -10,11 +9,29 @@ import (
 
 type Config struct {
 	Host        string
+	Servers     []string
 	Domain      string
 	AccessToken string
+	Security    SecurityConfig
 }
 
-func GetConfig() *Config {
+// SecurityConfig contains settings of TLS and NATS authentication
+type SecurityConfig struct {
+	TLS                bool   `yaml:"tls,omitempty" json:"tls,omitempty"`
+	CAFile             string `yaml:"tlsCA,omitempty" json:"tlsCA,omitempty"`
+	CertFile           string `yaml:"tlsCert,omitempty" json:"tlsCert,omitempty"`
+	KeyFile            string `yaml:"tlsKey,omitempty" json:"tlsKey,omitempty"`
+	InsecureSkipVerify bool   `yaml:"tlsInsecure,omitempty" json:"tlsInsecure,omitempty"`
+	CredsFile          string `yaml:"creds,omitempty" json:"creds,omitempty"`
+	NKeyFile           string `yaml:"nkey,omitempty" json:"nkey,omitempty"`
+	User               string `yaml:"user,omitempty" json:"user,omitempty"`
+	Password           string `yaml:"password,omitempty" json:"password,omitempty"`
+}
+
+// GetConfig reads settings from environment variables and config file. Config
+// is always returned so that other settings are still available, even if the
+// host is invalid.
+func GetConfig() (*Config, error) {
 
 	// From the environment
 	viper.SetEnvPrefix("GRAVITY_CLI")
@@ -33,31 +50,40 @@ func GetConfig() *Config {
 	config := &Config{}
 
 	// Specify events from environment variable for watching
-	config.SetHost(viper.GetString("host"))
+	err := config.SetHost(viper.GetString("host"))
 	config.SetDomain(viper.GetString("domain"))
 	config.SetAccessToken(viper.GetString("accessToken"))
-
-	return config
+	config.SetSecurity(&SecurityConfig{
+		TLS:                viper.GetBool("tls.enabled"),
+		CAFile:             viper.GetString("tls.ca"),
+		CertFile:           viper.GetString("tls.cert"),
+		KeyFile:            viper.GetString("tls.key"),
+		InsecureSkipVerify: viper.GetBool("tls.insecure"),
+		CredsFile:          viper.GetString("creds"),
+		NKeyFile:           viper.GetString("nkey"),
+		User:               viper.GetString("user"),
+		Password:           viper.GetString("password"),
+	})
+
+	return config, err
 }
 
-func (config *Config) SetHost(host string) {
+func (config *Config) SetHost(host string) error {
 
 	if len(host) == 0 {
-		return
+		return nil
 	}
 
-	config.Host = host
-
-	parts := strings.Split(host, ":")
-	viper.Set("gravity.host", parts[0])
-
-	if len(parts) == 2 {
-		port, err := strconv.Atoi(parts[1])
-		if err == nil {
-			viper.Set("gravity.port", port)
-		}
+	servers, err := ParseServers(host)
+	if err != nil {
+		return err
 	}
 
+	config.Host = host
+	config.Servers = servers
+	viper.Set("gravity.servers", servers)
+
+	return nil
 }
 
 func (config *Config) SetDomain(domain string) {
@@ -69,3 +95,16 @@ func (config *Config) SetAccessToken(accessToken string) {
 	config.AccessToken = accessToken
 	viper.Set("gravity.accessToken", accessToken)
 }
+
+func (config *Config) SetSecurity(security *SecurityConfig) {
+	config.Security = *security
+	viper.Set("gravity.tls.enabled", security.TLS)
+	viper.Set("gravity.tls.ca", security.CAFile)
+	viper.Set("gravity.tls.cert", security.CertFile)
+	viper.Set("gravity.tls.key", security.KeyFile)
+	viper.Set("gravity.tls.insecure", security.InsecureSkipVerify)
+	viper.Set("gravity.creds", security.CredsFile)
+	viper.Set("gravity.nkey", security.NKeyFile)
+	viper.Set("gravity.user", security.User)
+	viper.Set("gravity.password", security.Password)
+}
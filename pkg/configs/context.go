@@ -0,0 +1,212 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	UserConfigDirName  = "gravity-cli"
+	UserConfigFileName = "config.yaml"
+	UserConfigEnv      = "GRAVITY_CLI_CONFIG"
+
+	// RedactedValue replaces secrets in output
+	RedactedValue = "****"
+)
+
+var (
+	ErrContextNotFound = errors.New("context not found")
+)
+
+// Context is a named set of connection settings
+type Context struct {
+	Host        string `yaml:"host,omitempty" json:"host,omitempty"`
+	Domain      string `yaml:"domain,omitempty" json:"domain,omitempty"`
+	AccessToken string `yaml:"token,omitempty" json:"token,omitempty"`
+
+	SecurityConfig `yaml:",inline"`
+}
+
+// Redacted returns a copy of context with secrets replaced by RedactedValue,
+// including passwords in server URLs.
+func (ctx *Context) Redacted() *Context {
+
+	c := *ctx
+
+	if len(c.AccessToken) > 0 {
+		c.AccessToken = RedactedValue
+	}
+
+	if len(c.Password) > 0 {
+		c.Password = RedactedValue
+	}
+
+	if len(c.Host) > 0 {
+		servers := strings.Split(c.Host, ",")
+		for i, server := range servers {
+			servers[i] = redactURL(server)
+		}
+
+		c.Host = strings.Join(servers, ",")
+	}
+
+	return &c
+}
+
+// redactURL replaces password of URL, address which is not a URL is kept
+func redactURL(address string) string {
+
+	u, err := url.Parse(strings.TrimSpace(address))
+	if err != nil || u.User == nil {
+		return address
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		return address
+	}
+
+	// Password is not escaped
+	u.User = url.User(u.User.Username())
+
+	return strings.Replace(u.String(), "@", ":"+RedactedValue+"@", 1)
+}
+
+// UserConfig is the per-user configuration which holds named contexts
+type UserConfig struct {
+	CurrentContext string              `yaml:"currentContext,omitempty"`
+	Contexts       map[string]*Context `yaml:"contexts,omitempty"`
+
+	path string
+}
+
+// GetUserConfigPath returns the path of per-user config file, it can be
+// overridden by GRAVITY_CLI_CONFIG environment variable.
+func GetUserConfigPath() (string, error) {
+
+	if p := os.Getenv(UserConfigEnv); len(p) > 0 {
+		return p, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, UserConfigDirName, UserConfigFileName), nil
+}
+
+// LoadUserConfig reads per-user config file. An empty config will be
+// returned if the file doesn't exist.
+func LoadUserConfig() (*UserConfig, error) {
+
+	p, err := GetUserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	uc := &UserConfig{
+		Contexts: make(map[string]*Context),
+		path:     p,
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return uc, nil
+		}
+
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(data, uc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", p, err)
+	}
+
+	if uc.Contexts == nil {
+		uc.Contexts = make(map[string]*Context)
+	}
+
+	return uc, nil
+}
+
+// Save writes config to per-user config file. The file may contain access
+// tokens so it's only readable by owner.
+func (uc *UserConfig) Save() error {
+
+	data, err := yaml.Marshal(uc)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(uc.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(uc.path, data, 0600)
+}
+
+func (uc *UserConfig) GetPath() string {
+	return uc.path
+}
+
+func (uc *UserConfig) GetContext(name string) (*Context, error) {
+
+	ctx, ok := uc.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrContextNotFound, name)
+	}
+
+	return ctx, nil
+}
+
+func (uc *UserConfig) SetContext(name string, ctx *Context) {
+	uc.Contexts[name] = ctx
+}
+
+func (uc *UserConfig) DeleteContext(name string) error {
+
+	if _, ok := uc.Contexts[name]; !ok {
+		return fmt.Errorf("%w: \"%s\"", ErrContextNotFound, name)
+	}
+
+	delete(uc.Contexts, name)
+
+	if uc.CurrentContext == name {
+		uc.CurrentContext = ""
+	}
+
+	return nil
+}
+
+func (uc *UserConfig) UseContext(name string) error {
+
+	if _, ok := uc.Contexts[name]; !ok {
+		return fmt.Errorf("%w: \"%s\"", ErrContextNotFound, name)
+	}
+
+	uc.CurrentContext = name
+
+	return nil
+}
+
+// ContextNames returns sorted names of contexts
+func (uc *UserConfig) ContextNames() []string {
+
+	names := make([]string, 0, len(uc.Contexts))
+	for name := range uc.Contexts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,126 @@
+package connector
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-sdk/v2/core"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// Client is connection to Gravity network. The connection is owned by CLI
+// instead of Gravity SDK client, so that NATS options of TLS and
+// authentication can be applied.
+type Client struct {
+	conn    *nats.Conn
+	token   string
+	options *Options
+
+	mutex sync.Mutex
+	js    nats.JetStreamContext
+}
+
+type Options struct {
+	Token                  string
+	PingInterval           time.Duration
+	MaxPingsOutstanding    int
+	MaxReconnects          int
+	PublishAsyncMaxPending int
+	ReconnectHandler       func()
+	DisconnectHandler      func()
+}
+
+func NewOptions() *Options {
+	return &Options{
+		PingInterval:           DefaultPingInterval * time.Second,
+		MaxPingsOutstanding:    DefaultMaxPingsOutstanding,
+		MaxReconnects:          DefaultMaxReconnects,
+		PublishAsyncMaxPending: DefaultPublishAsyncMaxPending,
+		ReconnectHandler:       func() {},
+		DisconnectHandler:      func() {},
+	}
+}
+
+// NewClient returns client which uses existing connection
+func NewClient(nc *nats.Conn, options *Options) *Client {
+	return &Client{
+		conn:    nc,
+		token:   options.Token,
+		options: options,
+	}
+}
+
+func (c *Client) Disconnect() {
+	c.conn.Close()
+}
+
+func (c *Client) GetConnection() *nats.Conn {
+	return c.conn
+}
+
+func (c *Client) GetJetStream() (nats.JetStreamContext, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.js == nil {
+		js, err := c.conn.JetStream(nats.PublishAsyncMaxPending(c.options.PublishAsyncMaxPending))
+		if err != nil {
+			return nil, err
+		}
+
+		c.js = js
+	}
+
+	return c.js, nil
+}
+
+// Request sends request to API of Gravity with access token
+func (c *Client) Request(apiPath string, payload []byte, timeout time.Duration) (*nats.Msg, error) {
+
+	msg := nats.NewMsg(apiPath)
+	msg.Data = payload
+
+	return c.RequestMsg(msg, timeout)
+}
+
+func (c *Client) RequestMsg(msg *nats.Msg, timeout time.Duration) (*nats.Msg, error) {
+
+	if len(c.token) > 0 {
+		msg.Header.Set("Authorization", c.token)
+	}
+
+	return c.conn.RequestMsg(msg, timeout)
+}
+
+// Call sends request of API and decodes its reply, the error of reply is
+// returned if API failed.
+func (c *Client) Call(apiPath string, req interface{}, reply interface{}) error {
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	msg, err := c.Request(apiPath, data, DefaultRequestTimeout)
+	if err != nil {
+		return err
+	}
+
+	var resp core.ErrorReply
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return err
+	}
+
+	if resp.Error != nil {
+		return errors.New(resp.Error.Message)
+	}
+
+	return json.Unmarshal(msg.Data, reply)
+}
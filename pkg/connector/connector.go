@@ -3,10 +3,11 @@ package connector
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
-	"github.com/BrobridgeOrg/gravity-sdk/v2/core"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -15,18 +16,19 @@ import (
 var logger *zap.Logger
 
 const (
-	DefaultHost                = "0.0.0.0"
-	DefaultPort                = 32803
-	DefaultPingInterval        = 10
-	DefaultMaxPingsOutstanding = 3
-	DefaultMaxReconnects       = -1
-	DefaultAccessToken         = ""
-	DefaultDomain              = "default"
+	DefaultHost                   = "0.0.0.0"
+	DefaultPort                   = 32803
+	DefaultPingInterval           = 10
+	DefaultMaxPingsOutstanding    = 3
+	DefaultMaxReconnects          = -1
+	DefaultPublishAsyncMaxPending = 10240
+	DefaultAccessToken            = ""
+	DefaultDomain                 = "default"
 )
 
 type Connector struct {
 	config *configs.Config
-	client *core.Client
+	client *Client
 	logger *zap.Logger
 	domain string
 }
@@ -63,8 +65,9 @@ func (c *Connector) initialize() error {
 	viper.SetDefault("gravity.accessToken", DefaultAccessToken)
 
 	// default settings
-	viper.SetDefault("gravity.host", DefaultHost)
-	viper.SetDefault("gravity.port", DefaultPort)
+	viper.SetDefault("gravity.servers", []string{
+		fmt.Sprintf("nats://%s:%d", DefaultHost, DefaultPort),
+	})
 	viper.SetDefault("gravity.pingInterval", DefaultPingInterval)
 	viper.SetDefault("gravity.maxPingsOutstanding", DefaultMaxPingsOutstanding)
 	viper.SetDefault("gravity.maxReconnects", DefaultMaxReconnects)
@@ -85,40 +88,81 @@ func (c *Connector) initialize() error {
 	return nil
 }
 
-func (c *Connector) CreateClient() (*core.Client, error) {
+func (c *Connector) CreateClient() (*Client, error) {
 
 	// Read configs
 	accessToken := viper.GetString("gravity.accessToken")
-	host := viper.GetString("gravity.host")
-	port := viper.GetInt("gravity.port")
+	servers := viper.GetStringSlice("gravity.servers")
 	pingInterval := viper.GetInt64("gravity.pingInterval")
 	maxPingsOutstanding := viper.GetInt("gravity.maxPingsOutstanding")
 	maxReconnects := viper.GetInt("gravity.maxReconnects")
 
 	// Preparing options
-	options := core.NewOptions()
+	options := NewOptions()
 	options.Token = accessToken
 	options.PingInterval = time.Duration(pingInterval) * time.Second
 	options.MaxPingsOutstanding = maxPingsOutstanding
 	options.MaxReconnects = maxReconnects
 
-	address := fmt.Sprintf("%s:%d", host, port)
+	// Multiple servers are separated by comma for failover
+	urls, err := applySecurity(servers)
+	if err != nil {
+		return nil, err
+	}
+
+	address := strings.Join(urls, ",")
 
 	//fmt.Printf("Connecting to Gravity Network: %s\n", address)
 
-	client := core.NewClient()
-	err := client.Connect(address, options)
+	nc, err := connect(address, options)
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	return NewClient(nc, options), nil
 }
 
-func (c *Connector) GetClient() *core.Client {
+func (c *Connector) GetClient() *Client {
 	return c.client
 }
 
 func (c *Connector) GetDomain() string {
 	return c.domain
 }
+
+// applySecurity applies security settings to server URLs, so that TLS is
+// used by servers without tls:// or wss:// scheme and user information is
+// carried by URLs.
+func applySecurity(servers []string) ([]string, error) {
+
+	enabledTLS := viper.GetBool("gravity.tls.enabled")
+	user := viper.GetString("gravity.user")
+	password := viper.GetString("gravity.password")
+
+	urls := make([]string, len(servers))
+	for i, server := range servers {
+
+		u, err := url.Parse(server)
+		if err != nil {
+			return nil, err
+		}
+
+		if enabledTLS {
+			switch u.Scheme {
+			case "nats":
+				u.Scheme = "tls"
+			case "ws":
+				u.Scheme = "wss"
+			}
+		}
+
+		// User information in URL takes precedence
+		if len(user) > 0 && u.User == nil {
+			u.User = url.UserPassword(user, password)
+		}
+
+		urls[i] = u.String()
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,93 @@
+package connector
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidSecurityOption = errors.New("invalid security option")
+)
+
+// connect connects to servers with security settings. Connection is
+// re-established in background once it was connected, but the first
+// connection isn't retried like Gravity SDK client, so that errors of
+// unavailable servers, TLS and authentication are reported immediately
+// instead of timeout of the first request.
+func connect(address string, options *Options) (*nats.Conn, error) {
+
+	secOpts, err := securityOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []nats.Option{
+		nats.PingInterval(options.PingInterval),
+		nats.MaxPingsOutstanding(options.MaxPingsOutstanding),
+		nats.MaxReconnects(options.MaxReconnects),
+		nats.ReconnectHandler(func(*nats.Conn) {
+			options.ReconnectHandler()
+		}),
+		nats.DisconnectHandler(func(*nats.Conn) {
+			options.DisconnectHandler()
+		}),
+	}
+
+	return nats.Connect(address, append(opts, secOpts...)...)
+}
+
+// securityOptions returns NATS options of TLS and authentication settings
+func securityOptions() ([]nats.Option, error) {
+
+	caFile := viper.GetString("gravity.tls.ca")
+	certFile := viper.GetString("gravity.tls.cert")
+	keyFile := viper.GetString("gravity.tls.key")
+	insecure := viper.GetBool("gravity.tls.insecure")
+	credsFile := viper.GetString("gravity.creds")
+	nkeyFile := viper.GetString("gravity.nkey")
+
+	opts := make([]nats.Option, 0)
+
+	// TLS is required if any of TLS settings is specified
+	if viper.GetBool("gravity.tls.enabled") || len(caFile) > 0 || len(certFile) > 0 || insecure {
+		opts = append(opts, nats.Secure(&tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: insecure,
+		}))
+	}
+
+	if len(caFile) > 0 {
+		opts = append(opts, nats.RootCAs(caFile))
+	}
+
+	if len(certFile) > 0 || len(keyFile) > 0 {
+		if len(certFile) == 0 || len(keyFile) == 0 {
+			return nil, fmt.Errorf("%w: --tls-cert and --tls-key must be specified together", ErrInvalidSecurityOption)
+		}
+
+		opts = append(opts, nats.ClientCert(certFile, keyFile))
+	}
+
+	if len(credsFile) > 0 && len(nkeyFile) > 0 {
+		return nil, fmt.Errorf("%w: --creds and --nkey cannot be specified together", ErrInvalidSecurityOption)
+	}
+
+	if len(credsFile) > 0 {
+		opts = append(opts, nats.UserCredentials(credsFile))
+	}
+
+	if len(nkeyFile) > 0 {
+		opt, err := nats.NkeyOptionFromSeed(nkeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, opt)
+	}
+
+	return opts, nil
+}
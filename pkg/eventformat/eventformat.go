@@ -0,0 +1,303 @@
+package eventformat
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	gravity_sdk_types_product_event "github.com/BrobridgeOrg/gravity-sdk/v2/types/product_event"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+type Format string
+
+const (
+	FormatJSON      Format = "json"
+	FormatNDJSON    Format = "ndjson"
+	FormatCSV       Format = "csv"
+	FormatRaw       Format = "raw"
+	FormatProtoText Format = "proto-text"
+	FormatHex       Format = "hex"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+)
+
+// ParseFormat parses name of format
+func ParseFormat(name string) (Format, error) {
+
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatJSON, FormatNDJSON, FormatCSV, FormatRaw, FormatProtoText, FormatHex:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("%w: \"%s\" (json, ndjson, csv, raw, proto-text or hex)", ErrUnsupportedFormat, name)
+}
+
+// IsBinary returns true if format writes original message rather than
+// decoded events.
+func (f Format) IsBinary() bool {
+	return f == FormatRaw || f == FormatProtoText || f == FormatHex
+}
+
+// Writer writes events to output. Text formats write outputs which are
+// decoded events or results of projection, while binary formats write the
+// original message.
+type Writer interface {
+	Write(msg *nats.Msg, outputs []interface{}) error
+}
+
+type Options struct {
+
+	// Columns of CSV, columns will be taken from the first output if it's empty
+	Columns []string
+}
+
+func NewWriter(format Format, w io.Writer, options *Options) (Writer, error) {
+
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{w: w, indent: true}, nil
+	case FormatNDJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w), columns: options.Columns}, nil
+	case FormatRaw:
+		return &rawWriter{w: w}, nil
+	case FormatProtoText:
+		return &protoTextWriter{w: w}, nil
+	case FormatHex:
+		return &hexWriter{w: w}, nil
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", ErrUnsupportedFormat, format)
+}
+
+type jsonWriter struct {
+	w      io.Writer
+	indent bool
+}
+
+func (jw *jsonWriter) Write(msg *nats.Msg, outputs []interface{}) error {
+
+	for _, output := range outputs {
+
+		var data []byte
+		var err error
+		if jw.indent {
+			data, err = json.MarshalIndent(output, "", "  ")
+		} else {
+			data, err = json.Marshal(output)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		data = append(data, '\n')
+		if _, err := jw.w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	columns       []string
+	headerWritten bool
+}
+
+func (cw *csvWriter) Write(msg *nats.Msg, outputs []interface{}) error {
+
+	for _, output := range outputs {
+
+		fields := make(map[string]string)
+		Flatten("", output, fields)
+
+		// Take columns from the first output
+		if len(cw.columns) == 0 {
+			for k := range fields {
+				cw.columns = append(cw.columns, k)
+			}
+
+			sort.Strings(cw.columns)
+		}
+
+		if !cw.headerWritten {
+			if err := cw.w.Write(cw.columns); err != nil {
+				return err
+			}
+
+			cw.headerWritten = true
+		}
+
+		row := make([]string, len(cw.columns))
+		for i, c := range cw.columns {
+			row[i] = fields[c]
+		}
+
+		if err := cw.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.w.Flush()
+
+	return cw.w.Error()
+}
+
+// Flatten converts nested maps to fields with dot-separated names. Arrays
+// and other values which are not scalar are encoded in JSON.
+func Flatten(prefix string, v interface{}, fields map[string]string) {
+
+	switch d := v.(type) {
+	case map[string]interface{}:
+		for k, val := range d {
+
+			name := k
+			if len(prefix) > 0 {
+				name = prefix + "." + k
+			}
+
+			Flatten(name, val, fields)
+		}
+	case nil:
+		fields[prefix] = ""
+	case string:
+		fields[prefix] = d
+	case []byte:
+		fields[prefix] = string(d)
+	case bool, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		fields[prefix] = fmt.Sprint(d)
+	default:
+		data, err := json.Marshal(d)
+		if err != nil {
+			fields[prefix] = fmt.Sprint(d)
+			return
+		}
+
+		// Values like time are encoded as JSON string
+		var s string
+		if json.Unmarshal(data, &s) == nil {
+			fields[prefix] = s
+			return
+		}
+
+		fields[prefix] = string(data)
+	}
+}
+
+// SchemaColumns returns sorted column names of fields in schema, fields of
+// map type are flattened with dot-separated names.
+func SchemaColumns(prefix string, schema map[string]interface{}) []string {
+
+	columns := make([]string, 0, len(schema))
+	for name, def := range schema {
+
+		if len(prefix) > 0 {
+			name = prefix + "." + name
+		}
+
+		d, _ := def.(map[string]interface{})
+		if t, _ := d["type"].(string); t == "map" {
+			if fields, ok := d["fields"].(map[string]interface{}); ok {
+				columns = append(columns, SchemaColumns(name, fields)...)
+				continue
+			}
+		}
+
+		columns = append(columns, name)
+	}
+
+	sort.Strings(columns)
+
+	return columns
+}
+
+// rawWriter writes original message with 4-byte big-endian length prefix
+type rawWriter struct {
+	w io.Writer
+}
+
+func (rw *rawWriter) Write(msg *nats.Msg, outputs []interface{}) error {
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(msg.Data)))
+
+	if _, err := rw.w.Write(size[:]); err != nil {
+		return err
+	}
+
+	_, err := rw.w.Write(msg.Data)
+
+	return err
+}
+
+func describeMsg(msg *nats.Msg) string {
+
+	md, err := msg.Metadata()
+	if err != nil {
+		return fmt.Sprintf("# subject: %s, size: %d bytes\n", msg.Subject, len(msg.Data))
+	}
+
+	return fmt.Sprintf("# seq: %d, subject: %s, size: %d bytes\n", md.Sequence.Stream, msg.Subject, len(msg.Data))
+}
+
+type protoTextWriter struct {
+	w io.Writer
+}
+
+func (pw *protoTextWriter) Write(msg *nats.Msg, outputs []interface{}) error {
+
+	var b strings.Builder
+	b.WriteString(describeMsg(msg))
+
+	opts := prototext.MarshalOptions{
+		Multiline: true,
+	}
+
+	var pe gravity_sdk_types_product_event.ProductEvent
+	err := proto.Unmarshal(msg.Data, &pe)
+	if err != nil {
+		fmt.Fprintf(&b, "# failed to parse product event: %v\n", err)
+	} else {
+		b.WriteString(opts.Format(&pe))
+
+		// Content of event
+		r, err := pe.GetContent()
+		if err != nil {
+			fmt.Fprintf(&b, "# failed to parse content: %v\n", err)
+		} else {
+			b.WriteString("# content\n")
+			b.WriteString(opts.Format(r))
+		}
+	}
+
+	b.WriteString("\n")
+
+	_, err = io.WriteString(pw.w, b.String())
+
+	return err
+}
+
+type hexWriter struct {
+	w io.Writer
+}
+
+func (hw *hexWriter) Write(msg *nats.Msg, outputs []interface{}) error {
+
+	_, err := io.WriteString(hw.w, describeMsg(msg)+hex.Dump(msg.Data)+"\n")
+
+	return err
+}
@@ -0,0 +1,261 @@
+package eventformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	product_event_type "github.com/BrobridgeOrg/gravity-sdk/v2/types/product_event"
+	record_type "github.com/BrobridgeOrg/gravity-sdk/v2/types/record"
+	"github.com/nats-io/nats.go"
+)
+
+func TestParseFormat(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		format Format
+		binary bool
+	}{
+		{"json", FormatJSON, false},
+		{"NDJSON", FormatNDJSON, false},
+		{"csv", FormatCSV, false},
+		{"raw", FormatRaw, true},
+		{"proto-text", FormatProtoText, true},
+		{"hex", FormatHex, true},
+	}
+
+	for _, tt := range tests {
+
+		f, err := ParseFormat(tt.name)
+		if err != nil || f != tt.format || f.IsBinary() != tt.binary {
+			t.Errorf("ParseFormat(%s) = %s, %v", tt.name, f, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+
+	created := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+
+	fields := make(map[string]string)
+	Flatten("", map[string]interface{}{
+		"id":      uint64(18446744073709551615),
+		"name":    "fred",
+		"balance": 12.5,
+		"enabled": true,
+		"count":   json.Number("42"),
+		"note":    nil,
+		"raw":     []byte("abc"),
+		"tags":    []interface{}{"vip", 1},
+		"created": created,
+		"address": map[string]interface{}{
+			"city": "Taipei",
+			"geo":  map[string]interface{}{"lat": 25.03},
+		},
+	}, fields)
+
+	want := map[string]string{
+		"id":              "18446744073709551615",
+		"name":            "fred",
+		"balance":         "12.5",
+		"enabled":         "true",
+		"count":           "42",
+		"note":            "",
+		"raw":             "abc",
+		"tags":            `["vip",1]`,
+		"created":         "2024-03-01T08:30:00Z",
+		"address.city":    "Taipei",
+		"address.geo.lat": "25.03",
+	}
+
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Flatten = %v, want %v", fields, want)
+	}
+}
+
+func TestSchemaColumns(t *testing.T) {
+
+	schema := map[string]interface{}{
+		"name": map[string]interface{}{"type": "string"},
+		"id":   map[string]interface{}{"type": "uint"},
+		"address": map[string]interface{}{
+			"type": "map",
+			"fields": map[string]interface{}{
+				"zip":  map[string]interface{}{"type": "string"},
+				"city": map[string]interface{}{"type": "string"},
+			},
+		},
+		"meta": map[string]interface{}{"type": "map"},
+	}
+
+	want := []string{"address.city", "address.zip", "id", "meta", "name"}
+	if got := SchemaColumns("", schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaColumns = %v, want %v", got, want)
+	}
+}
+
+func TestTextWriters(t *testing.T) {
+
+	outputs := []interface{}{
+		map[string]interface{}{"id": 1, "name": "fred", "address": map[string]interface{}{"city": "Taipei"}},
+		map[string]interface{}{"id": 2, "name": "wilma, flintstone", "extra": true},
+	}
+
+	tests := []struct {
+		format  Format
+		columns []string
+		want    string
+	}{
+		{
+			FormatNDJSON,
+			nil,
+			`{"address":{"city":"Taipei"},"id":1,"name":"fred"}` + "\n" +
+				`{"extra":true,"id":2,"name":"wilma, flintstone"}` + "\n",
+		},
+		{
+			FormatJSON,
+			nil,
+			"{\n  \"address\": {\n    \"city\": \"Taipei\"\n  },\n  \"id\": 1,\n  \"name\": \"fred\"\n}\n" +
+				"{\n  \"extra\": true,\n  \"id\": 2,\n  \"name\": \"wilma, flintstone\"\n}\n",
+		},
+		{
+			// Columns are taken from the first output
+			FormatCSV,
+			nil,
+			"address.city,id,name\nTaipei,1,fred\n,2,\"wilma, flintstone\"\n",
+		},
+		{
+			FormatCSV,
+			[]string{"id", "name", "email"},
+			"id,name,email\n1,fred,\n2,\"wilma, flintstone\",\n",
+		},
+	}
+
+	for _, tt := range tests {
+
+		var buf bytes.Buffer
+		w, err := NewWriter(tt.format, &buf, &Options{Columns: tt.columns})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Outputs of separate messages
+		for _, output := range outputs {
+			if err := w.Write(&nats.Msg{}, []interface{}{output}); err != nil {
+				t.Fatalf("%s: %v", tt.format, err)
+			}
+		}
+
+		if buf.String() != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.format, buf.String(), tt.want)
+		}
+	}
+}
+
+func newTestMsg(t *testing.T) *nats.Msg {
+
+	r := record_type.NewRecord()
+	err := record_type.UnmarshalMapData(map[string]interface{}{
+		"id":   uint64(4),
+		"name": "fred",
+	}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pe := &product_event_type.ProductEvent{
+		EventName:   "accountCreated",
+		Table:       "accounts",
+		Method:      product_event_type.Method_INSERT,
+		PrimaryKeys: []string{"id"},
+	}
+
+	if err := pe.SetContent(r); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := product_event_type.Marshal(pe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &nats.Msg{
+		Subject: "$GVT.default.DP.accounts.0.EVENT.accountCreated",
+		Data:    data,
+	}
+}
+
+func TestBinaryWriters(t *testing.T) {
+
+	msg := newTestMsg(t)
+
+	// Raw messages are prefixed with length
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatRaw, &buf, &Options{})
+	for i := 0; i < 2; i++ {
+		if err := w.Write(msg, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := buf.Bytes()
+	for i := 0; i < 2; i++ {
+
+		size := binary.BigEndian.Uint32(data)
+		if int(size) != len(msg.Data) || !bytes.Equal(data[4:4+size], msg.Data) {
+			t.Fatalf("raw message %d doesn't match", i)
+		}
+
+		data = data[4+size:]
+	}
+
+	if len(data) != 0 {
+		t.Errorf("%d bytes left", len(data))
+	}
+
+	// Proto text
+	buf.Reset()
+	w, _ = NewWriter(FormatProtoText, &buf, &Options{})
+	if err := w.Write(msg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{"# subject: " + msg.Subject, "accountCreated", "# content", "fred"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("proto-text output doesn't contain %q:\n%s", s, out)
+		}
+	}
+
+	// Invalid messages are reported in output
+	buf.Reset()
+	if err := w.Write(&nats.Msg{Subject: "x", Data: []byte{0xff, 0xff}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "# failed to parse product event") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// Hex dump
+	buf.Reset()
+	w, _ = NewWriter(FormatHex, &buf, &Options{})
+	if err := w.Write(&nats.Msg{Subject: "x", Data: []byte("hello")}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# subject: x, size: 5 bytes\n00000000  68 65 6c 6c 6f                                    |hello|\n\n"
+	if buf.String() != want {
+		t.Errorf("hex output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,275 @@
+package generator
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidSchema = errors.New("invalid schema")
+)
+
+var firstNames = []string{"Fred", "Alice", "Bob", "Carol", "David", "Eve", "Grace", "Henry", "Ivy", "Jack", "Kate", "Leo", "Mia", "Noah", "Olivia"}
+var lastNames = []string{"Chien", "Smith", "Chen", "Wang", "Lin", "Johnson", "Brown", "Lee", "Garcia", "Miller", "Davis", "Wilson"}
+var cities = []string{"Taipei", "Tokyo", "Singapore", "London", "Berlin", "New York", "San Francisco", "Sydney", "Paris", "Toronto"}
+var countries = []string{"Taiwan", "Japan", "Singapore", "United Kingdom", "Germany", "United States", "Australia", "France", "Canada"}
+var streets = []string{"Main St", "Park Ave", "Oak St", "Maple Ave", "Cedar Rd", "Elm St", "Lake Dr", "Hill Rd"}
+var words = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet", "kilo", "lima"}
+
+// Faker synthesizes records from product schema. Fields of primary key are
+// derived from sequence number so that each record is distinct. Faker isn't
+// safe for concurrent use.
+type Faker struct {
+	schema     map[string]interface{}
+	primaryKey map[string]bool
+	rand       *rand.Rand
+
+	// Time values are generated within recent year before it
+	now time.Time
+}
+
+func NewFaker(schema map[string]interface{}, primaryKey []string) (*Faker, error) {
+
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("%w: no fields", ErrInvalidSchema)
+	}
+
+	f := &Faker{
+		schema:     schema,
+		primaryKey: make(map[string]bool, len(primaryKey)),
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:        time.Now().UTC(),
+	}
+
+	for _, k := range primaryKey {
+		f.primaryKey[k] = true
+	}
+
+	// Validate schema by generating a record
+	_, err := f.Generate(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Seed makes records reproducible, the same records are generated by fakers
+// with the same seed and schema. Time values are relative to creation time of
+// faker.
+func (f *Faker) Seed(seed int64) {
+	f.rand = rand.New(rand.NewSource(seed))
+}
+
+// Generate returns a record for specific sequence number
+func (f *Faker) Generate(seq uint64) (map[string]interface{}, error) {
+
+	record := make(map[string]interface{}, len(f.schema))
+	for _, name := range sortedKeys(f.schema) {
+
+		d, ok := f.schema[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: field \"%s\"", ErrInvalidSchema, name)
+		}
+
+		if f.primaryKey[name] {
+			v, err := fakeKey(name, d, seq)
+			if err != nil {
+				return nil, err
+			}
+
+			record[name] = v
+			continue
+		}
+
+		v, err := f.fakeValue(name, d)
+		if err != nil {
+			return nil, err
+		}
+
+		record[name] = v
+	}
+
+	return record, nil
+}
+
+func fakeKey(name string, def map[string]interface{}, seq uint64) (interface{}, error) {
+
+	switch t, _ := def["type"].(string); t {
+	case "uint", "int":
+		return seq, nil
+	case "float":
+		return float64(seq), nil
+	case "string", "binary", "any":
+		return fmt.Sprintf("%s-%d", name, seq), nil
+	}
+
+	return nil, fmt.Errorf("%w: field \"%s\" cannot be primary key", ErrInvalidSchema, name)
+}
+
+func (f *Faker) fakeValue(name string, def map[string]interface{}) (interface{}, error) {
+
+	t, ok := def["type"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: field \"%s\" has no type", ErrInvalidSchema, name)
+	}
+
+	lname := strings.ToLower(name)
+
+	switch t {
+	case "uint":
+		if strings.Contains(lname, "age") {
+			return uint64(18 + f.rand.Intn(60)), nil
+		}
+
+		return uint64(f.rand.Intn(100000)), nil
+	case "int":
+		return int64(f.rand.Intn(200000) - 100000), nil
+	case "float":
+		if strings.Contains(lname, "price") || strings.Contains(lname, "amount") {
+			return float64(f.rand.Intn(1000000)) / 100, nil
+		}
+
+		return f.rand.Float64() * 1000, nil
+	case "bool":
+		return f.rand.Intn(2) == 1, nil
+	case "string", "any":
+		return f.fakeString(lname), nil
+	case "time":
+		return f.fakeTime(lname), nil
+	case "binary":
+		b := make([]byte, 16)
+		f.rand.Read(b)
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "map":
+		return f.fakeMap(name, def)
+	case "array":
+		return f.fakeArray(name, def)
+	}
+
+	return nil, fmt.Errorf("%w: field \"%s\" has unsupported type \"%s\"", ErrInvalidSchema, name, t)
+}
+
+func (f *Faker) fakeMap(name string, def map[string]interface{}) (interface{}, error) {
+
+	fields, ok := def["fields"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: map field \"%s\" has no fields", ErrInvalidSchema, name)
+	}
+
+	m := make(map[string]interface{}, len(fields))
+	for _, k := range sortedKeys(fields) {
+
+		d, ok := fields[k].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: field \"%s.%s\"", ErrInvalidSchema, name, k)
+		}
+
+		value, err := f.fakeValue(k, d)
+		if err != nil {
+			return nil, err
+		}
+
+		m[k] = value
+	}
+
+	return m, nil
+}
+
+func (f *Faker) fakeArray(name string, def map[string]interface{}) (interface{}, error) {
+
+	// Subtype can be a type name or a definition
+	var subDef map[string]interface{}
+	switch st := def["subtype"].(type) {
+	case string:
+		subDef = map[string]interface{}{
+			"type":   st,
+			"fields": def["fields"],
+		}
+	case map[string]interface{}:
+		subDef = st
+	default:
+		return nil, fmt.Errorf("%w: array field \"%s\" has no subtype", ErrInvalidSchema, name)
+	}
+
+	n := 1 + f.rand.Intn(3)
+	items := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+
+		v, err := f.fakeValue(name, subDef)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, v)
+	}
+
+	return items, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (f *Faker) pickString(items []string) string {
+	return items[f.rand.Intn(len(items))]
+}
+
+// fakeString generates string which looks real according to field name
+func (f *Faker) fakeString(name string) string {
+
+	switch {
+	case strings.Contains(name, "email") || strings.Contains(name, "mail"):
+		return fmt.Sprintf("%s.%s@example.com", strings.ToLower(f.pickString(firstNames)), strings.ToLower(f.pickString(lastNames)))
+	case strings.Contains(name, "phone") || strings.Contains(name, "mobile") || strings.Contains(name, "tel"):
+		return fmt.Sprintf("+886-9%02d-%03d-%03d", f.rand.Intn(100), f.rand.Intn(1000), f.rand.Intn(1000))
+	case strings.Contains(name, "address"):
+		return fmt.Sprintf("%d %s, %s", 1+f.rand.Intn(999), f.pickString(streets), f.pickString(cities))
+	case strings.Contains(name, "city"):
+		return f.pickString(cities)
+	case strings.Contains(name, "country"):
+		return f.pickString(countries)
+	case strings.Contains(name, "url") || strings.Contains(name, "website"):
+		return fmt.Sprintf("https://%s.example.com/%s", f.pickString(words), f.pickString(words))
+	case strings.Contains(name, "uuid") || strings.Contains(name, "guid"):
+		id, _ := uuid.NewRandomFromReader(f.rand)
+		return id.String()
+	case name == "firstname" || name == "first_name":
+		return f.pickString(firstNames)
+	case name == "lastname" || name == "last_name":
+		return f.pickString(lastNames)
+	case strings.Contains(name, "name"):
+		return f.pickString(firstNames) + " " + f.pickString(lastNames)
+	case strings.Contains(name, "type") || strings.Contains(name, "status") || strings.Contains(name, "category"):
+		return f.pickString(words)
+	}
+
+	return f.pickString(words) + "-" + f.pickString(words)
+}
+
+// fakeTime generates time within recent year in RFC3339 format
+func (f *Faker) fakeTime(name string) string {
+
+	now := f.now
+
+	if strings.Contains(name, "updated") || strings.Contains(name, "modified") {
+		return now.Add(-time.Duration(f.rand.Int63n(int64(24 * time.Hour)))).Format(time.RFC3339Nano)
+	}
+
+	return now.Add(-time.Duration(f.rand.Int63n(int64(365 * 24 * time.Hour)))).Format(time.RFC3339Nano)
+}
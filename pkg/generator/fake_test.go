@@ -0,0 +1,365 @@
+package generator
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func field(t string) map[string]interface{} {
+	return map[string]interface{}{"type": t}
+}
+
+func TestFakerTypes(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		field string
+		def   map[string]interface{}
+		check func(v interface{}) bool
+	}{
+		{
+			name:  "uint",
+			field: "count",
+			def:   field("uint"),
+			check: func(v interface{}) bool {
+				_, ok := v.(uint64)
+				return ok
+			},
+		},
+		{
+			name:  "uint of age",
+			field: "age",
+			def:   field("uint"),
+			check: func(v interface{}) bool {
+				n, ok := v.(uint64)
+				return ok && n >= 18 && n < 78
+			},
+		},
+		{
+			name:  "int",
+			field: "balance",
+			def:   field("int"),
+			check: func(v interface{}) bool {
+				_, ok := v.(int64)
+				return ok
+			},
+		},
+		{
+			name:  "float",
+			field: "score",
+			def:   field("float"),
+			check: func(v interface{}) bool {
+				n, ok := v.(float64)
+				return ok && n >= 0 && n < 1000
+			},
+		},
+		{
+			name:  "bool",
+			field: "enabled",
+			def:   field("bool"),
+			check: func(v interface{}) bool {
+				_, ok := v.(bool)
+				return ok
+			},
+		},
+		{
+			name:  "string",
+			field: "note",
+			def:   field("string"),
+			check: func(v interface{}) bool {
+				s, ok := v.(string)
+				return ok && len(s) > 0
+			},
+		},
+		{
+			name:  "string of email",
+			field: "email",
+			def:   field("string"),
+			check: func(v interface{}) bool {
+				s, _ := v.(string)
+				return strings.HasSuffix(s, "@example.com")
+			},
+		},
+		{
+			name:  "any",
+			field: "extra",
+			def:   field("any"),
+			check: func(v interface{}) bool {
+				_, ok := v.(string)
+				return ok
+			},
+		},
+		{
+			name:  "time",
+			field: "created_at",
+			def:   field("time"),
+			check: func(v interface{}) bool {
+				s, _ := v.(string)
+				ts, err := time.Parse(time.RFC3339Nano, s)
+				return err == nil && !ts.After(time.Now()) && time.Since(ts) <= 366*24*time.Hour
+			},
+		},
+		{
+			name:  "binary",
+			field: "data",
+			def:   field("binary"),
+			check: func(v interface{}) bool {
+				s, _ := v.(string)
+				b, err := base64.StdEncoding.DecodeString(s)
+				return err == nil && len(b) == 16
+			},
+		},
+	}
+
+	for _, tt := range tests {
+
+		f, err := NewFaker(map[string]interface{}{tt.field: tt.def}, nil)
+		if err != nil {
+			t.Fatalf("%s: NewFaker: %v", tt.name, err)
+		}
+
+		for i := 0; i < 20; i++ {
+
+			record, err := f.Generate(uint64(i))
+			if err != nil {
+				t.Fatalf("%s: Generate: %v", tt.name, err)
+			}
+
+			if v := record[tt.field]; !tt.check(v) {
+				t.Errorf("%s: Generate() = %#v", tt.name, v)
+				break
+			}
+		}
+	}
+}
+
+func TestFakerNested(t *testing.T) {
+
+	schema := map[string]interface{}{
+		"profile": map[string]interface{}{
+			"type": "map",
+			"fields": map[string]interface{}{
+				"city": field("string"),
+				"address": map[string]interface{}{
+					"type": "map",
+					"fields": map[string]interface{}{
+						"zip": field("uint"),
+					},
+				},
+			},
+		},
+		"tags": map[string]interface{}{
+			"type":    "array",
+			"subtype": "string",
+		},
+		"orders": map[string]interface{}{
+			"type":    "array",
+			"subtype": "map",
+			"fields": map[string]interface{}{
+				"amount": field("float"),
+			},
+		},
+		"scores": map[string]interface{}{
+			"type":    "array",
+			"subtype": field("int"),
+		},
+	}
+
+	f, err := NewFaker(schema, nil)
+	if err != nil {
+		t.Fatalf("NewFaker: %v", err)
+	}
+
+	record, err := f.Generate(1)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	profile, ok := record["profile"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("profile = %#v, want map", record["profile"])
+	}
+
+	if _, ok := profile["city"].(string); !ok {
+		t.Errorf("profile.city = %#v, want string", profile["city"])
+	}
+
+	address, ok := profile["address"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("profile.address = %#v, want map", profile["address"])
+	}
+
+	if _, ok := address["zip"].(uint64); !ok {
+		t.Errorf("profile.address.zip = %#v, want uint64", address["zip"])
+	}
+
+	tests := []struct {
+		name  string
+		check func(v interface{}) bool
+	}{
+		{
+			name: "tags",
+			check: func(v interface{}) bool {
+				_, ok := v.(string)
+				return ok
+			},
+		},
+		{
+			name: "orders",
+			check: func(v interface{}) bool {
+				m, ok := v.(map[string]interface{})
+				if !ok {
+					return false
+				}
+
+				_, ok = m["amount"].(float64)
+				return ok
+			},
+		},
+		{
+			name: "scores",
+			check: func(v interface{}) bool {
+				_, ok := v.(int64)
+				return ok
+			},
+		},
+	}
+
+	for _, tt := range tests {
+
+		items, ok := record[tt.name].([]interface{})
+		if !ok || len(items) < 1 || len(items) > 3 {
+			t.Errorf("%s = %#v, want array of 1 to 3 items", tt.name, record[tt.name])
+			continue
+		}
+
+		for _, item := range items {
+			if !tt.check(item) {
+				t.Errorf("item of %s = %#v", tt.name, item)
+			}
+		}
+	}
+}
+
+func TestFakerPrimaryKey(t *testing.T) {
+
+	tests := []struct {
+		name string
+		def  map[string]interface{}
+		want interface{}
+		err  error
+	}{
+		{name: "uint", def: field("uint"), want: uint64(5)},
+		{name: "int", def: field("int"), want: uint64(5)},
+		{name: "float", def: field("float"), want: float64(5)},
+		{name: "string", def: field("string"), want: "id-5"},
+		{name: "bool", def: field("bool"), err: ErrInvalidSchema},
+	}
+
+	for _, tt := range tests {
+
+		f, err := NewFaker(map[string]interface{}{"id": tt.def}, []string{"id"})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: NewFaker() error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		record, err := f.Generate(5)
+		if err != nil {
+			t.Fatalf("%s: Generate: %v", tt.name, err)
+		}
+
+		if record["id"] != tt.want {
+			t.Errorf("%s: id = %#v, want %#v", tt.name, record["id"], tt.want)
+		}
+	}
+}
+
+func TestFakerInvalidSchema(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		schema map[string]interface{}
+	}{
+		{name: "no fields", schema: map[string]interface{}{}},
+		{name: "invalid field", schema: map[string]interface{}{"id": "uint"}},
+		{name: "no type", schema: map[string]interface{}{"id": map[string]interface{}{}}},
+		{name: "unsupported type", schema: map[string]interface{}{"id": field("decimal")}},
+		{name: "map without fields", schema: map[string]interface{}{"profile": field("map")}},
+		{name: "array without subtype", schema: map[string]interface{}{"tags": field("array")}},
+	}
+
+	for _, tt := range tests {
+
+		_, err := NewFaker(tt.schema, nil)
+		if !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%s: NewFaker() error = %v, want %v", tt.name, err, ErrInvalidSchema)
+		}
+	}
+}
+
+func TestFakerSeed(t *testing.T) {
+
+	schema := map[string]interface{}{
+		"id":         field("uint"),
+		"name":       field("string"),
+		"email":      field("string"),
+		"uuid":       field("string"),
+		"score":      field("float"),
+		"data":       field("binary"),
+		"created_at": field("time"),
+		"tags": map[string]interface{}{
+			"type":    "array",
+			"subtype": "string",
+		},
+		"profile": map[string]interface{}{
+			"type": "map",
+			"fields": map[string]interface{}{
+				"city": field("string"),
+				"age":  field("uint"),
+			},
+		},
+	}
+
+	generate := func(seed int64, now time.Time) []map[string]interface{} {
+
+		f, err := NewFaker(schema, []string{"id"})
+		if err != nil {
+			t.Fatalf("NewFaker: %v", err)
+		}
+
+		f.Seed(seed)
+		f.now = now
+
+		var records []map[string]interface{}
+		for i := uint64(1); i <= 10; i++ {
+			record, err := f.Generate(i)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+
+			records = append(records, record)
+		}
+
+		return records
+	}
+
+	now := time.Now().UTC()
+
+	a := generate(42, now)
+	if b := generate(42, now); !reflect.DeepEqual(a, b) {
+		t.Errorf("records of the same seed are different:\n%v\n%v", a, b)
+	}
+
+	if c := generate(43, now); reflect.DeepEqual(a, c) {
+		t.Errorf("records of different seeds are the same")
+	}
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var (
+	ErrInvalidPayload = errors.New("generated payload is not valid JSON")
+)
+
+// Generator generates payloads from Go template. Functions available in
+// template:
+//
+//	seq                    sequence number of current message
+//	randInt min max        random integer in [min, max]
+//	randFloat min max      random float in [min, max)
+//	randString n           random alphanumeric string with length n
+//	randBool               random boolean
+//	uuid                   random UUID
+//	now                    current time in RFC3339 format
+//	unix / unixMilli       current Unix time in seconds / milliseconds
+//	timestamp layout       current time in specific layout
+//	pick a b ...           random item of arguments
+//	json v                 value encoded in JSON
+type Generator struct {
+	tmpl *template.Template
+	seq  uint64
+}
+
+// Parse parses template text. The returned generator isn't safe for
+// concurrent use, use Clone to create generators for workers.
+func Parse(text string) (*Generator, error) {
+
+	g := &Generator{}
+
+	tmpl, err := template.New("payload").Funcs(g.funcs()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	g.tmpl = tmpl
+
+	return g, nil
+}
+
+// Clone returns a generator which shares parsed template
+func (g *Generator) Clone() (*Generator, error) {
+
+	c := &Generator{}
+
+	tmpl, err := g.tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	c.tmpl = tmpl.Funcs(c.funcs())
+
+	return c, nil
+}
+
+func (g *Generator) funcs() template.FuncMap {
+	return template.FuncMap{
+		"seq": func() uint64 {
+			return g.seq
+		},
+		"randInt": func(min int64, max int64) int64 {
+			if max <= min {
+				return min
+			}
+
+			return min + rand.Int63n(max-min+1)
+		},
+		"randFloat": func(min float64, max float64) float64 {
+			return min + rand.Float64()*(max-min)
+		},
+		"randString": func(n int) string {
+			var b strings.Builder
+			for i := 0; i < n; i++ {
+				b.WriteByte(letters[rand.Intn(len(letters))])
+			}
+
+			return b.String()
+		},
+		"randBool": func() bool {
+			return rand.Intn(2) == 1
+		},
+		"uuid": func() string {
+			return uuid.New().String()
+		},
+		"now": func() string {
+			return time.Now().UTC().Format(time.RFC3339Nano)
+		},
+		"unix": func() int64 {
+			return time.Now().Unix()
+		},
+		"unixMilli": func() int64 {
+			return time.Now().UnixMilli()
+		},
+		"timestamp": func(layout string) string {
+			return time.Now().UTC().Format(layout)
+		},
+		"pick": func(items ...interface{}) interface{} {
+			if len(items) == 0 {
+				return nil
+			}
+
+			return items[rand.Intn(len(items))]
+		},
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// Generate executes template with specific sequence number and returns payload
+// which should be valid JSON.
+func (g *Generator) Generate(seq uint64) ([]byte, error) {
+
+	g.seq = seq
+
+	var buf bytes.Buffer
+	err := g.tmpl.Execute(&buf, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := bytes.TrimSpace(buf.Bytes())
+	if !json.Valid(payload) {
+		return nil, ErrInvalidPayload
+	}
+
+	return payload, nil
+}
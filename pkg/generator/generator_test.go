@@ -0,0 +1,238 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerate(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		text  string
+		check func(v interface{}) bool
+	}{
+		{
+			name: "seq",
+			text: `{{ seq }}`,
+			check: func(v interface{}) bool {
+				return v == float64(7)
+			},
+		},
+		{
+			name: "randInt",
+			text: `{{ randInt 1 3 }}`,
+			check: func(v interface{}) bool {
+				n, ok := v.(float64)
+				return ok && n >= 1 && n <= 3 && n == float64(int(n))
+			},
+		},
+		{
+			name: "randInt with max less than min",
+			text: `{{ randInt 5 1 }}`,
+			check: func(v interface{}) bool {
+				return v == float64(5)
+			},
+		},
+		{
+			name: "randFloat",
+			text: `{{ randFloat 0.5 1.5 }}`,
+			check: func(v interface{}) bool {
+				n, ok := v.(float64)
+				return ok && n >= 0.5 && n < 1.5
+			},
+		},
+		{
+			name: "randString",
+			text: `"{{ randString 16 }}"`,
+			check: func(v interface{}) bool {
+				s, ok := v.(string)
+				if !ok || len(s) != 16 {
+					return false
+				}
+
+				for _, c := range s {
+					if !strings.ContainsRune(letters, c) {
+						return false
+					}
+				}
+
+				return true
+			},
+		},
+		{
+			name: "randBool",
+			text: `{{ randBool }}`,
+			check: func(v interface{}) bool {
+				_, ok := v.(bool)
+				return ok
+			},
+		},
+		{
+			name: "uuid",
+			text: `"{{ uuid }}"`,
+			check: func(v interface{}) bool {
+				s, _ := v.(string)
+				_, err := uuid.Parse(s)
+				return err == nil
+			},
+		},
+		{
+			name: "now",
+			text: `"{{ now }}"`,
+			check: func(v interface{}) bool {
+				s, _ := v.(string)
+				ts, err := time.Parse(time.RFC3339Nano, s)
+				return err == nil && time.Since(ts) < time.Minute
+			},
+		},
+		{
+			name: "unix",
+			text: `{{ unix }}`,
+			check: func(v interface{}) bool {
+				n, _ := v.(float64)
+				return time.Since(time.Unix(int64(n), 0)) < time.Minute
+			},
+		},
+		{
+			name: "unixMilli",
+			text: `{{ unixMilli }}`,
+			check: func(v interface{}) bool {
+				n, _ := v.(float64)
+				return time.Since(time.UnixMilli(int64(n))) < time.Minute
+			},
+		},
+		{
+			name: "timestamp",
+			text: `"{{ timestamp "2006-01-02" }}"`,
+			check: func(v interface{}) bool {
+				return v == time.Now().UTC().Format("2006-01-02")
+			},
+		},
+		{
+			name: "pick",
+			text: `{{ pick "personal" "business" | json }}`,
+			check: func(v interface{}) bool {
+				return v == "personal" || v == "business"
+			},
+		},
+		{
+			name: "json escapes string",
+			text: `{{ json "say \"hi\"\n" }}`,
+			check: func(v interface{}) bool {
+				return v == "say \"hi\"\n"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+
+		g, err := Parse(tt.text)
+		if err != nil {
+			t.Fatalf("%s: Parse(%s): %v", tt.name, tt.text, err)
+		}
+
+		// Random values are checked several times
+		for i := 0; i < 20; i++ {
+
+			payload, err := g.Generate(7)
+			if err != nil {
+				t.Fatalf("%s: Generate: %v", tt.name, err)
+			}
+
+			var v interface{}
+			if err := json.Unmarshal(payload, &v); err != nil {
+				t.Fatalf("%s: invalid payload %s: %v", tt.name, payload, err)
+			}
+
+			if !tt.check(v) {
+				t.Errorf("%s: Generate() = %s", tt.name, payload)
+				break
+			}
+		}
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+
+	if _, err := Parse(`{"id":{{ seq }`); err == nil {
+		t.Errorf("Parse() of invalid template should fail")
+	}
+
+	if _, err := Parse(`{{ unknown }}`); err == nil {
+		t.Errorf("Parse() of unknown function should fail")
+	}
+
+	g, err := Parse(`{"name":{{ randString 4 }}}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if _, err := g.Generate(1); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Generate() error = %v, want %v", err, ErrInvalidPayload)
+	}
+}
+
+func TestClone(t *testing.T) {
+
+	g, err := Parse(`{"id":{{ seq }}}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if _, err := g.Generate(1); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	// Clones have their own sequence, so they can be used by workers
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for w := 0; w < 4; w++ {
+
+		c, err := g.Clone()
+		if err != nil {
+			t.Fatalf("Clone: %v", err)
+		}
+
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				seq := uint64(w*1000 + i)
+				payload, err := c.Generate(seq)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				var v struct {
+					ID uint64 `json:"id"`
+				}
+				json.Unmarshal(payload, &v)
+				if v.ID != seq {
+					errs <- errors.New("clone generated " + string(payload))
+					return
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Generate() of clone: %v", err)
+	}
+
+	// Generator which was cloned is not affected
+	if g.seq != 1 {
+		t.Errorf("seq of generator = %d, want 1", g.seq)
+	}
+}
@@ -0,0 +1,191 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultConcurrency = 1
+	DefaultRetries     = 3
+	DefaultBackoff     = time.Second
+	DefaultMaxBackoff  = 30 * time.Second
+)
+
+var (
+	ErrCanceled = errors.New("canceled")
+)
+
+type Options struct {
+
+	// Name of jobs in messages of retry
+	Name string
+
+	// Command is executed by shell so that arguments can be quoted
+	Command string
+
+	Concurrency int
+
+	// Retries after the first failure with exponential backoff
+	Retries    int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Timeout of each execution, it's unlimited if zero
+	Timeout time.Duration
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Name:        "Command",
+		Concurrency: DefaultConcurrency,
+		Retries:     DefaultRetries,
+		Backoff:     DefaultBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+	}
+}
+
+// Job is an execution of command with input on stdin and extra environment
+// variables. Done is called with nil error if command exited with zero status.
+// Run replaces the command if it's specified.
+type Job struct {
+	Input []byte
+	Env   []string
+	Run   func(ctx context.Context) error
+	Done  func(err error)
+}
+
+// Executor runs jobs with limited concurrency, jobs are started in the order
+// they were submitted.
+type Executor struct {
+	options *Options
+	slots   chan struct{}
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func New(options *Options) *Executor {
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Executor{
+		options: options,
+		slots:   make(chan struct{}, concurrency),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Submit runs job in background, it blocks if all slots are busy. Jobs which
+// are submitted after Close are done with ErrCanceled.
+func (e *Executor) Submit(job *Job) {
+
+	if e.ctx.Err() != nil {
+		job.Done(ErrCanceled)
+		return
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+	case <-e.ctx.Done():
+		job.Done(ErrCanceled)
+		return
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer func() {
+			<-e.slots
+			e.wg.Done()
+		}()
+
+		job.Done(e.run(job))
+	}()
+}
+
+func (e *Executor) run(job *Job) error {
+
+	backoff := e.options.Backoff
+
+	var err error
+	for attempt := 0; attempt <= e.options.Retries; attempt++ {
+
+		if attempt > 0 {
+			fmt.Fprintf(e.options.Stderr, "%s failed: %v, retrying in %s (%d/%d)\n", e.options.Name, err, backoff, attempt, e.options.Retries)
+
+			select {
+			case <-time.After(backoff):
+			case <-e.ctx.Done():
+				return fmt.Errorf("%w: %v", ErrCanceled, err)
+			}
+
+			backoff *= 2
+			if e.options.MaxBackoff > 0 && backoff > e.options.MaxBackoff {
+				backoff = e.options.MaxBackoff
+			}
+		}
+
+		err = e.exec(job)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (e *Executor) exec(job *Job) error {
+
+	// Running jobs are not canceled when executor is closed
+	ctx := context.Background()
+	if e.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.options.Timeout)
+		defer cancel()
+	}
+
+	if job.Run != nil {
+		return job.Run(ctx)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", e.options.Command)
+	cmd.Stdin = bytes.NewReader(job.Input)
+	cmd.Stdout = e.options.Stdout
+	cmd.Stderr = e.options.Stderr
+	cmd.Env = append(os.Environ(), job.Env...)
+
+	// Children of killed shell may keep output open
+	cmd.WaitDelay = time.Second
+
+	return cmd.Run()
+}
+
+// Wait waits for all jobs to be done
+func (e *Executor) Wait() {
+	e.wg.Wait()
+}
+
+// Close stops retrying and waits for running commands to exit. Jobs which
+// are waiting for retry are done with ErrCanceled.
+func (e *Executor) Close() {
+	e.cancel()
+	e.wg.Wait()
+}
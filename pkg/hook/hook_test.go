@@ -0,0 +1,246 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestExecutor(configure func(opts *Options)) *Executor {
+
+	opts := NewOptions()
+	opts.Backoff = time.Millisecond
+	opts.MaxBackoff = 2 * time.Millisecond
+	opts.Stdout = io.Discard
+	opts.Stderr = io.Discard
+	if configure != nil {
+		configure(opts)
+	}
+
+	return New(opts)
+}
+
+// submit runs job and waits for result
+func submit(e *Executor, job *Job) error {
+
+	result := make(chan error, 1)
+	job.Done = func(err error) {
+		result <- err
+	}
+
+	e.Submit(job)
+
+	return <-result
+}
+
+func TestCommand(t *testing.T) {
+
+	var stdout bytes.Buffer
+	e := newTestExecutor(func(opts *Options) {
+		opts.Command = `read line; echo "$GRAVITY_SEQ $line"`
+		opts.Stdout = &stdout
+	})
+	defer e.Close()
+
+	err := submit(e, &Job{
+		Input: []byte("{\"id\":1}\n"),
+		Env:   []string{"GRAVITY_SEQ=42"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if got := strings.TrimSpace(stdout.String()); got != `42 {"id":1}` {
+		t.Errorf("stdout = %q", got)
+	}
+}
+
+func TestRetries(t *testing.T) {
+
+	tests := []struct {
+		failures int32
+		retries  int
+		ok       bool
+	}{
+		{0, 0, true},
+		{1, 0, false},
+		{2, 3, true},
+		{3, 3, true},
+		{4, 3, false},
+	}
+
+	for _, tt := range tests {
+
+		e := newTestExecutor(func(opts *Options) {
+			opts.Retries = tt.retries
+		})
+
+		var attempts int32
+		err := submit(e, &Job{
+			Run: func(ctx context.Context) error {
+				if atomic.AddInt32(&attempts, 1) <= tt.failures {
+					return errors.New("failed")
+				}
+
+				return nil
+			},
+		})
+
+		e.Close()
+
+		if tt.ok != (err == nil) {
+			t.Errorf("%d failures, %d retries: unexpected result %v", tt.failures, tt.retries, err)
+		}
+
+		want := tt.failures + 1
+		if want > int32(tt.retries)+1 {
+			want = int32(tt.retries) + 1
+		}
+
+		if attempts != want {
+			t.Errorf("%d failures, %d retries: %d attempts, want %d", tt.failures, tt.retries, attempts, want)
+		}
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+
+	e := newTestExecutor(func(opts *Options) {
+		opts.Command = "exit 3"
+		opts.Retries = 1
+	})
+	defer e.Close()
+
+	if err := submit(e, &Job{}); err == nil {
+		t.Errorf("non-zero exit status was not reported")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+
+	e := newTestExecutor(func(opts *Options) {
+		opts.Command = "sleep 10"
+		opts.Retries = 0
+		opts.Timeout = 50 * time.Millisecond
+	})
+	defer e.Close()
+
+	startTime := time.Now()
+	if err := submit(e, &Job{}); err == nil {
+		t.Errorf("command was not killed after timeout")
+	}
+
+	if elapsed := time.Since(startTime); elapsed > 5*time.Second {
+		t.Errorf("command ran for %s", elapsed)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+
+	for _, concurrency := range []int{1, 3} {
+
+		e := newTestExecutor(func(opts *Options) {
+			opts.Concurrency = concurrency
+		})
+
+		var running int32
+		var max int32
+		var mutex sync.Mutex
+		var started []int
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			i := i
+			wg.Add(1)
+			e.Submit(&Job{
+				Run: func(ctx context.Context) error {
+					mutex.Lock()
+					started = append(started, i)
+					mutex.Unlock()
+
+					n := atomic.AddInt32(&running, 1)
+					for {
+						m := atomic.LoadInt32(&max)
+						if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+							break
+						}
+					}
+
+					time.Sleep(5 * time.Millisecond)
+					atomic.AddInt32(&running, -1)
+
+					return nil
+				},
+				Done: func(err error) {
+					wg.Done()
+				},
+			})
+		}
+
+		wg.Wait()
+		e.Close()
+
+		if max > int32(concurrency) {
+			t.Errorf("concurrency %d: %d jobs were running at the same time", concurrency, max)
+		}
+
+		// Jobs are started in the order they were submitted
+		if concurrency == 1 {
+			for i, n := range started {
+				if n != i {
+					t.Errorf("concurrency 1: jobs started in order %v", started)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestCloseCancelsRetries(t *testing.T) {
+
+	e := newTestExecutor(func(opts *Options) {
+		opts.Retries = 5
+		opts.Backoff = time.Hour
+		opts.MaxBackoff = time.Hour
+	})
+
+	result := make(chan error, 1)
+	e.Submit(&Job{
+		Run: func(ctx context.Context) error {
+			return errors.New("failed")
+		},
+		Done: func(err error) {
+			result <- err
+		},
+	})
+
+	// Wait for the first attempt
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		e.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Close didn't cancel retry")
+	}
+
+	if err := <-result; !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected ErrCanceled, got %v", err)
+	}
+
+	// Jobs submitted after close are canceled
+	if err := submit(e, &Job{Run: func(ctx context.Context) error { return nil }}); !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected ErrCanceled after close, got %v", err)
+	}
+}
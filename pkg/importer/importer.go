@@ -0,0 +1,315 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+)
+
+type Options struct {
+	Format Format
+
+	// Header indicates the first row of CSV is column names
+	Header bool
+
+	// Columns specifies column names of CSV, it overrides header row
+	Columns []string
+
+	// Types are type hints of fields which are used to convert values
+	Types map[string]string
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Format: FormatCSV,
+		Header: true,
+		Types:  make(map[string]string),
+	}
+}
+
+// Record is a single record of input. Offset is the 1-based sequence number of
+// record which is used to resume import.
+type Record struct {
+	Offset int64
+	Line   int
+	Data   map[string]interface{}
+	Err    error
+}
+
+// Reader reads records from input. It returns io.EOF if there are no more
+// records. Records which cannot be converted are returned with Err rather than
+// breaking the whole import.
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// ParseFormat parses format name, format will be detected by file extension
+// if name is empty.
+func ParseFormat(name string, filename string) (Format, error) {
+
+	if len(name) == 0 {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			return FormatCSV, nil
+		case ".json":
+			return FormatJSON, nil
+		case ".ndjson", ".jsonl":
+			return FormatNDJSON, nil
+		}
+
+		return "", fmt.Errorf("%w: cannot detect format of \"%s\"", ErrUnsupportedFormat, filename)
+	}
+
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("%w: \"%s\"", ErrUnsupportedFormat, name)
+}
+
+func NewReader(r io.Reader, options *Options) (Reader, error) {
+
+	switch options.Format {
+	case FormatCSV:
+		return newCSVReader(r, options)
+	case FormatJSON:
+		return newJSONReader(r, options)
+	case FormatNDJSON:
+		return newNDJSONReader(r, options), nil
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", ErrUnsupportedFormat, options.Format)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	options *Options
+	columns []string
+	offset  int64
+}
+
+func newCSVReader(r io.Reader, options *Options) (*csvReader, error) {
+
+	cr := &csvReader{
+		reader:  csv.NewReader(r),
+		options: options,
+		columns: options.Columns,
+	}
+
+	// Number of fields will be checked for each record
+	cr.reader.FieldsPerRecord = -1
+
+	if options.Header {
+		header, err := cr.reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("missing header row")
+			}
+
+			return nil, err
+		}
+
+		if len(cr.columns) == 0 {
+			cr.columns = header
+		}
+	}
+
+	if len(cr.columns) == 0 {
+		return nil, errors.New("require column names for CSV without header row")
+	}
+
+	for i, c := range cr.columns {
+		cr.columns[i] = strings.TrimSpace(c)
+	}
+
+	return cr, nil
+}
+
+func (cr *csvReader) Read() (*Record, error) {
+
+	fields, err := cr.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	cr.offset++
+	line, _ := cr.reader.FieldPos(0)
+
+	record := &Record{
+		Offset: cr.offset,
+		Line:   line,
+	}
+
+	if len(fields) != len(cr.columns) {
+		record.Err = fmt.Errorf("expected %d fields but got %d", len(cr.columns), len(fields))
+		return record, nil
+	}
+
+	data := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+
+		name := cr.columns[i]
+
+		// Fields without type hint are strings
+		t, ok := cr.options.Types[name]
+		if !ok {
+			data[name] = field
+			continue
+		}
+
+		v, err := Convert(t, field)
+		if err != nil {
+			record.Err = fmt.Errorf("field \"%s\": %w", name, err)
+			return record, nil
+		}
+
+		data[name] = v
+	}
+
+	record.Data = data
+
+	return record, nil
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+	options *Options
+	offset  int64
+}
+
+func newJSONReader(r io.Reader, options *Options) (*jsonReader, error) {
+
+	jr := &jsonReader{
+		decoder: json.NewDecoder(r),
+		options: options,
+	}
+
+	jr.decoder.UseNumber()
+
+	// Input should be an array of objects
+	t, err := jr.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := t.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("input is not a JSON array")
+	}
+
+	return jr, nil
+}
+
+func (jr *jsonReader) Read() (*Record, error) {
+
+	if !jr.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	err := jr.decoder.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	jr.offset++
+
+	record := &Record{
+		Offset: jr.offset,
+	}
+
+	record.Data, record.Err = decodeObject(raw, jr.options.Types)
+
+	return record, nil
+}
+
+type ndjsonReader struct {
+	reader  *bufio.Reader
+	options *Options
+	line    int
+	offset  int64
+}
+
+func newNDJSONReader(r io.Reader, options *Options) *ndjsonReader {
+	return &ndjsonReader{
+		reader:  bufio.NewReader(r),
+		options: options,
+	}
+}
+
+func (nr *ndjsonReader) Read() (*Record, error) {
+
+	for {
+		line, err := nr.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			nr.line++
+
+			// Ignore empty lines
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				nr.offset++
+
+				record := &Record{
+					Offset: nr.offset,
+					Line:   nr.line,
+				}
+
+				record.Data, record.Err = decodeObject(line, nr.options.Types)
+
+				return record, nil
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func decodeObject(data []byte, types map[string]string) (map[string]interface{}, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	err := dec.Decode(&obj)
+	if err != nil || obj == nil {
+		return nil, errors.New("invalid JSON object")
+	}
+
+	// Only string values will be converted by type hints
+	for name, t := range types {
+
+		s, ok := obj[name].(string)
+		if !ok {
+			continue
+		}
+
+		v, err := Convert(t, s)
+		if err != nil {
+			return nil, fmt.Errorf("field \"%s\": %w", name, err)
+		}
+
+		obj[name] = v
+	}
+
+	return obj, nil
+}
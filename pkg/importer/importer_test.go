@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		filename string
+		want     Format
+		ok       bool
+	}{
+		{"", "accounts.csv", FormatCSV, true},
+		{"", "ACCOUNTS.JSON", FormatJSON, true},
+		{"", "accounts.jsonl", FormatNDJSON, true},
+		{"", "accounts.ndjson", FormatNDJSON, true},
+		{"NDJSON", "accounts.csv", FormatNDJSON, true},
+		{"", "accounts.txt", "", false},
+		{"xml", "accounts.csv", "", false},
+	}
+
+	for _, tt := range tests {
+
+		f, err := ParseFormat(tt.name, tt.filename)
+		if tt.ok != (err == nil) || f != tt.want {
+			t.Errorf("ParseFormat(%q, %q) = %s, %v", tt.name, tt.filename, f, err)
+		}
+
+		if !tt.ok && !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("ParseFormat(%q, %q): expected ErrUnsupportedFormat, got %v", tt.name, tt.filename, err)
+		}
+	}
+}
+
+type readResult struct {
+	Offset int64
+	Line   int
+	Data   map[string]interface{}
+	Err    bool
+}
+
+func readAll(t *testing.T, input string, options *Options) []readResult {
+
+	r, err := NewReader(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+
+	results := make([]readResult, 0)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+
+		results = append(results, readResult{
+			Offset: record.Offset,
+			Line:   record.Line,
+			Data:   record.Data,
+			Err:    record.Err != nil,
+		})
+	}
+
+	return results
+}
+
+func TestCSVReader(t *testing.T) {
+
+	input := "id, name ,created\n" +
+		"1,fred,2024-03-01\n" +
+		"2,\"wilma, flintstone\",\n" +
+		"x,barney,2024-03-01\n" +
+		"4,betty\n" +
+		"5,\"bamm\nbamm\",2024-03-02\n"
+
+	options := NewOptions()
+	options.Types = map[string]string{"id": "uint", "created": "time"}
+
+	want := []readResult{
+		{1, 2, map[string]interface{}{"id": uint64(1), "name": "fred", "created": "2024-03-01T00:00:00Z"}, false},
+		{2, 3, map[string]interface{}{"id": uint64(2), "name": "wilma, flintstone", "created": nil}, false},
+		{3, 4, nil, true},
+		{4, 5, nil, true},
+		{5, 6, map[string]interface{}{"id": uint64(5), "name": "bamm\nbamm", "created": "2024-03-02T00:00:00Z"}, false},
+	}
+
+	if got := readAll(t, input, options); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v, want %+v", got, want)
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+
+	tests := []struct {
+		header  bool
+		columns []string
+		input   string
+		want    map[string]interface{}
+	}{
+		{false, []string{"id", "name"}, "1,fred\n", map[string]interface{}{"id": "1", "name": "fred"}},
+		{true, []string{"id", "name"}, "a,b\n1,fred\n", map[string]interface{}{"id": "1", "name": "fred"}},
+		{true, nil, "a,b\n1,fred\n", map[string]interface{}{"a": "1", "b": "fred"}},
+	}
+
+	for _, tt := range tests {
+
+		options := NewOptions()
+		options.Header = tt.header
+		options.Columns = tt.columns
+
+		got := readAll(t, tt.input, options)
+		if len(got) != 1 || !reflect.DeepEqual(got[0].Data, tt.want) {
+			t.Errorf("header %v, columns %v: records = %+v", tt.header, tt.columns, got)
+		}
+	}
+
+	// Columns are required without header
+	options := NewOptions()
+	options.Header = false
+	if _, err := NewReader(strings.NewReader("1,fred\n"), options); err == nil {
+		t.Errorf("CSV without columns was accepted")
+	}
+
+	if _, err := NewReader(strings.NewReader(""), NewOptions()); err == nil {
+		t.Errorf("CSV without header was accepted")
+	}
+}
+
+func TestJSONReader(t *testing.T) {
+
+	input := `[
+		{"id": 1, "name": "fred", "created": "2024-03-01"},
+		{"id": "2", "balance": 12345678901234567890},
+		"not an object",
+		{"id": 4, "created": "yesterday"}
+	]`
+
+	options := NewOptions()
+	options.Format = FormatJSON
+	options.Types = map[string]string{"id": "uint", "created": "time"}
+
+	want := []readResult{
+		{1, 0, map[string]interface{}{"id": json.Number("1"), "name": "fred", "created": "2024-03-01T00:00:00Z"}, false},
+		{2, 0, map[string]interface{}{"id": uint64(2), "balance": json.Number("12345678901234567890")}, false},
+		{3, 0, nil, true},
+		{4, 0, nil, true},
+	}
+
+	if got := readAll(t, input, options); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v, want %+v", got, want)
+	}
+
+	if _, err := NewReader(strings.NewReader(`{"id": 1}`), options); err == nil {
+		t.Errorf("JSON object was accepted as array")
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+
+	input := "{\"id\": 1}\n" +
+		"\n" +
+		"  \n" +
+		"{\"id\": \"2\"}\r\n" +
+		"{invalid}\n" +
+		"{\"id\": 4}"
+
+	options := NewOptions()
+	options.Format = FormatNDJSON
+	options.Types = map[string]string{"id": "int"}
+
+	want := []readResult{
+		{1, 1, map[string]interface{}{"id": json.Number("1")}, false},
+		{2, 4, map[string]interface{}{"id": int64(2)}, false},
+		{3, 5, nil, true},
+		{4, 6, map[string]interface{}{"id": json.Number("4")}, false},
+	}
+
+	if got := readAll(t, input, options); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v, want %+v", got, want)
+	}
+}
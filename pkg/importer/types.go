@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+)
+
+var supportedTypes = map[string]bool{
+	"uint":   true,
+	"int":    true,
+	"float":  true,
+	"bool":   true,
+	"string": true,
+	"time":   true,
+	"binary": true,
+	"map":    true,
+	"array":  true,
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Convert converts string value to specific type which is compatible with
+// types of product schema. Empty string will be converted to null except
+// for string type.
+func Convert(t string, value string) (interface{}, error) {
+
+	if !supportedTypes[t] {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrUnsupportedType, t)
+	}
+
+	if t == "string" {
+		return value, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	switch t {
+	case "uint":
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uint \"%s\"", value)
+		}
+
+		return v, nil
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int \"%s\"", value)
+		}
+
+		return v, nil
+	case "float":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float \"%s\"", value)
+		}
+
+		return v, nil
+	case "bool":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool \"%s\"", value)
+		}
+
+		return v, nil
+	case "time":
+		return parseTime(value)
+	case "map", "array":
+		var v interface{}
+		err := json.Unmarshal([]byte(value), &v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s \"%s\"", t, value)
+		}
+
+		return v, nil
+	}
+
+	return value, nil
+}
+
+func parseTime(value string) (interface{}, error) {
+
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t.Format(time.RFC3339Nano), nil
+		}
+	}
+
+	// Unix timestamp in seconds
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC().Format(time.RFC3339Nano), nil
+	}
+
+	return nil, fmt.Errorf("invalid time \"%s\"", value)
+}
+
+// TypesFromSchema returns types of top-level fields in product schema
+func TypesFromSchema(schema map[string]interface{}) map[string]string {
+
+	types := make(map[string]string, len(schema))
+	for name, def := range schema {
+
+		d, ok := def.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if t, ok := d["type"].(string); ok && supportedTypes[t] {
+			types[name] = t
+		}
+	}
+
+	return types
+}
+
+// ParseTypeHints parses type hints in "field=type" format
+func ParseTypeHints(hints []string) (map[string]string, error) {
+
+	types := make(map[string]string, len(hints))
+	for _, hint := range hints {
+
+		parts := strings.SplitN(hint, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid type hint \"%s\"", hint)
+		}
+
+		t := strings.TrimSpace(parts[1])
+		if !supportedTypes[t] {
+			return nil, fmt.Errorf("%w: \"%s\"", ErrUnsupportedType, t)
+		}
+
+		types[strings.TrimSpace(parts[0])] = t
+	}
+
+	return types, nil
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+
+	tests := []struct {
+		t     string
+		value string
+		want  interface{}
+		ok    bool
+	}{
+		{"string", "", "", true},
+		{"string", " fred ", " fred ", true},
+		{"uint", "42", uint64(42), true},
+		{"uint", " 18446744073709551615 ", uint64(18446744073709551615), true},
+		{"uint", "-1", nil, false},
+		{"uint", "1.5", nil, false},
+		{"int", "-42", int64(-42), true},
+		{"int", "9223372036854775808", nil, false},
+		{"float", "3.14", 3.14, true},
+		{"float", "1e3", 1000.0, true},
+		{"float", "pi", nil, false},
+		{"bool", "true", true, true},
+		{"bool", "0", false, true},
+		{"bool", "yes", nil, false},
+		{"time", "2024-03-01T08:30:00+08:00", "2024-03-01T08:30:00+08:00", true},
+		{"time", "2024-03-01T08:30:00.123Z", "2024-03-01T08:30:00.123Z", true},
+		{"time", "2024-03-01 08:30:00+08:00", "2024-03-01T08:30:00+08:00", true},
+		{"time", "2024-03-01 08:30:00", "2024-03-01T08:30:00Z", true},
+		{"time", "2024-03-01T08:30:00.5", "2024-03-01T08:30:00.5Z", true},
+		{"time", "2024-03-01", "2024-03-01T00:00:00Z", true},
+		{"time", "1709253000", "2024-03-01T00:30:00Z", true},
+		{"time", "yesterday", nil, false},
+		{"map", `{"a":1}`, map[string]interface{}{"a": 1.0}, true},
+		{"map", `{"a":`, nil, false},
+		{"array", `[1,"a"]`, []interface{}{1.0, "a"}, true},
+		{"binary", "aGVsbG8=", "aGVsbG8=", true},
+
+		// Empty values are null except for strings
+		{"int", "", nil, true},
+		{"time", "  ", nil, true},
+		{"map", "", nil, true},
+	}
+
+	for _, tt := range tests {
+
+		v, err := Convert(tt.t, tt.value)
+		if tt.ok != (err == nil) {
+			t.Errorf("Convert(%s, %q): unexpected result %v, %v", tt.t, tt.value, v, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v, tt.want) {
+			t.Errorf("Convert(%s, %q) = %#v, want %#v", tt.t, tt.value, v, tt.want)
+		}
+	}
+
+	if _, err := Convert("decimal", "1"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestTypesFromSchema(t *testing.T) {
+
+	schema := map[string]interface{}{
+		"id":      map[string]interface{}{"type": "uint"},
+		"name":    map[string]interface{}{"type": "string"},
+		"address": map[string]interface{}{"type": "map", "fields": map[string]interface{}{}},
+		"amount":  map[string]interface{}{"type": "decimal"},
+		"invalid": "int",
+	}
+
+	want := map[string]string{
+		"id":      "uint",
+		"name":    "string",
+		"address": "map",
+	}
+
+	if got := TypesFromSchema(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("TypesFromSchema = %v, want %v", got, want)
+	}
+}
+
+func TestParseTypeHints(t *testing.T) {
+
+	tests := []struct {
+		hints []string
+		want  map[string]string
+		ok    bool
+	}{
+		{[]string{}, map[string]string{}, true},
+		{[]string{"id=uint", " created = time "}, map[string]string{"id": "uint", "created": "time"}, true},
+		{[]string{"id"}, nil, false},
+		{[]string{"=int"}, nil, false},
+		{[]string{"id=decimal"}, nil, false},
+	}
+
+	for _, tt := range tests {
+
+		got, err := ParseTypeHints(tt.hints)
+		if tt.ok != (err == nil) {
+			t.Errorf("ParseTypeHints(%v): unexpected result %v", tt.hints, err)
+			continue
+		}
+
+		if tt.ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTypeHints(%v) = %v, want %v", tt.hints, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package manifest
+
+import (
+	"sort"
+	"strings"
+
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type ChangeType int
+
+const (
+	ChangeAdded ChangeType = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+// Change describes a field-level difference between live setting and manifest.
+type Change struct {
+	Type    ChangeType
+	Path    string
+	Live    interface{}
+	Desired interface{}
+}
+
+// IsText returns true if change should be rendered as text diff.
+func (c *Change) IsText() bool {
+
+	live, ok := c.Live.(string)
+	if !ok {
+		return false
+	}
+
+	desired, ok := c.Desired.(string)
+	if !ok {
+		return false
+	}
+
+	return strings.Contains(live, "\n") || strings.Contains(desired, "\n")
+}
+
+// TextDiff returns unified diff for multi-line string values.
+func (c *Change) TextDiff() string {
+
+	live, _ := c.Live.(string)
+	desired, _ := c.Desired.(string)
+
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(live),
+		B:        difflib.SplitLines(desired),
+		FromFile: "live",
+		ToFile:   "manifest",
+		Context:  3,
+	})
+
+	return diff
+}
+
+type differ struct {
+	changes []*Change
+}
+
+func (d *differ) add(path string, desired interface{}) {
+	d.changes = append(d.changes, &Change{
+		Type:    ChangeAdded,
+		Path:    path,
+		Desired: desired,
+	})
+}
+
+func (d *differ) remove(path string, live interface{}) {
+	d.changes = append(d.changes, &Change{
+		Type: ChangeRemoved,
+		Path: path,
+		Live: live,
+	})
+}
+
+func (d *differ) compare(path string, live interface{}, desired interface{}) {
+
+	if EqualJSON(live, desired) {
+		return
+	}
+
+	d.changes = append(d.changes, &Change{
+		Type:    ChangeModified,
+		Path:    path,
+		Live:    live,
+		Desired: desired,
+	})
+}
+
+// compareTree walks into maps for reporting nested differences of schemas.
+func (d *differ) compareTree(path string, live interface{}, desired interface{}) {
+
+	lm, lok := live.(map[string]interface{})
+	dm, dok := desired.(map[string]interface{})
+	if !lok || !dok {
+		d.compare(path, live, desired)
+		return
+	}
+
+	keys := make(map[string]bool)
+	for k := range lm {
+		keys[k] = true
+	}
+
+	for k := range dm {
+		keys[k] = true
+	}
+
+	for _, k := range sortedKeys(keys) {
+
+		lv, inLive := lm[k]
+		dv, inDesired := dm[k]
+		p := path + "." + k
+
+		switch {
+		case !inLive:
+			d.add(p, dv)
+		case !inDesired:
+			d.remove(p, lv)
+		default:
+			d.compareTree(p, lv, dv)
+		}
+	}
+}
+
+// Diff compares live product setting with manifest and the stream which
+// events of product should be stored in. A nil setting means product doesn't
+// exist.
+func (p *Product) Diff(setting *product_sdk.ProductSetting, stream string) []*Change {
+
+	d := &differ{
+		changes: make([]*Change, 0),
+	}
+
+	if setting == nil {
+		d.add("product", p.Name)
+		return d.changes
+	}
+
+	d.compare("stream", setting.Stream, stream)
+	d.compare("desc", setting.Description, p.Description)
+	d.compare("enabled", setting.Enabled, p.Enabled)
+	d.compare("snapshot", setting.EnabledSnapshot, p.IsSnapshotEnabled())
+
+	if isEmpty(setting.Schema) || isEmpty(p.Schema) {
+		d.compare("schema", setting.Schema, p.Schema)
+	} else {
+		d.compareTree("schema", setting.Schema, p.Schema)
+	}
+
+	// Rules
+	declared := make(map[string]*Rule, len(p.Rules))
+	names := make(map[string]bool)
+	for _, r := range p.Rules {
+		declared[r.Name] = r
+		names[r.Name] = true
+	}
+
+	for name := range setting.Rules {
+		names[name] = true
+	}
+
+	for _, name := range sortedKeys(names) {
+
+		path := "rules." + name
+		rule, inLive := setting.Rules[name]
+		r, inDesired := declared[name]
+
+		switch {
+		case !inLive:
+			d.add(path, r.Event)
+		case !inDesired:
+			d.remove(path, rule.Event)
+		default:
+			r.diff(d, path, rule)
+		}
+	}
+
+	return d.changes
+}
+
+func (r *Rule) diff(d *differ, path string, rule *product_sdk.Rule) {
+
+	d.compare(path+".desc", rule.Description, r.Description)
+	d.compare(path+".event", rule.Event, r.Event)
+	d.compare(path+".method", rule.Method, r.Method)
+	d.compare(path+".enabled", rule.Enabled, r.Enabled)
+
+	if len(rule.PrimaryKey) > 0 || len(r.PrimaryKey) > 0 {
+		d.compare(path+".pk", strings.Join(rule.PrimaryKey, ","), strings.Join(r.PrimaryKey, ","))
+	}
+
+	if isEmpty(rule.SchemaConfig) || isEmpty(r.Schema) {
+		d.compare(path+".schema", rule.SchemaConfig, r.Schema)
+	} else {
+		d.compareTree(path+".schema", rule.SchemaConfig, r.Schema)
+	}
+
+	var script string
+	if rule.HandlerConfig != nil {
+		script = rule.HandlerConfig.Script
+	}
+
+	d.compare(path+".handler", script, r.Handler)
+}
+
+// Diff compares live token setting with manifest. A nil setting means
+// token doesn't exist.
+func (t *Token) Diff(setting *token_sdk.TokenSetting) []*Change {
+
+	d := &differ{
+		changes: make([]*Change, 0),
+	}
+
+	if setting == nil {
+		d.add("token", t.ID)
+		return d.changes
+	}
+
+	d.compare("desc", setting.Description, t.Description)
+	d.compare("enabled", setting.Enabled, t.Enabled)
+
+	declared := make(map[string]bool, len(t.Permissions))
+	perms := make(map[string]bool)
+	for _, perm := range t.Permissions {
+		declared[perm] = true
+		perms[perm] = true
+	}
+
+	for perm := range setting.Permissions {
+		perms[perm] = true
+	}
+
+	for _, perm := range sortedKeys(perms) {
+
+		_, inLive := setting.Permissions[perm]
+
+		switch {
+		case !inLive:
+			d.add("permissions."+perm, perm)
+		case !declared[perm]:
+			d.remove("permissions."+perm, perm)
+		}
+	}
+
+	return d.changes
+}
+
+func sortedKeys(m map[string]bool) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,256 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+)
+
+const testStream = "GVT_default_DP_accounts"
+
+func newTestProduct() *Product {
+	return &Product{
+		Kind:        KindProduct,
+		Name:        "accounts",
+		Description: "Accounts",
+		Enabled:     true,
+		Schema: map[string]interface{}{
+			"id":   map[string]interface{}{"type": "int"},
+			"name": map[string]interface{}{"type": "string"},
+		},
+		Rules: []*Rule{
+			{
+				Name:       "create",
+				Event:      "accountCreated",
+				Method:     "create",
+				Enabled:    true,
+				PrimaryKey: []string{"id"},
+				Handler:    "return source\n",
+			},
+			{
+				Name:   "delete",
+				Event:  "accountDeleted",
+				Method: "delete",
+			},
+		},
+	}
+}
+
+type changeSummary struct {
+	Type ChangeType
+	Path string
+}
+
+func TestProductDiff(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		modify func(setting *product_sdk.ProductSetting)
+		want   []changeSummary
+	}{
+		{
+			"unchanged",
+			func(setting *product_sdk.ProductSetting) {},
+			[]changeSummary{},
+		},
+		{
+			"stream",
+			func(setting *product_sdk.ProductSetting) {
+				setting.Stream = "GVT_default_DP_others"
+			},
+			[]changeSummary{{ChangeModified, "stream"}},
+		},
+		{
+			"fields",
+			func(setting *product_sdk.ProductSetting) {
+				setting.Description = "Users"
+				setting.Enabled = false
+				setting.EnabledSnapshot = false
+			},
+			[]changeSummary{
+				{ChangeModified, "desc"},
+				{ChangeModified, "enabled"},
+				{ChangeModified, "snapshot"},
+			},
+		},
+		{
+			"nested schema",
+			func(setting *product_sdk.ProductSetting) {
+				setting.Schema = map[string]interface{}{
+					"id":    map[string]interface{}{"type": "uint"},
+					"email": map[string]interface{}{"type": "string"},
+				}
+			},
+			[]changeSummary{
+				{ChangeRemoved, "schema.email"},
+				{ChangeModified, "schema.id.type"},
+				{ChangeAdded, "schema.name"},
+			},
+		},
+		{
+			"schema removed",
+			func(setting *product_sdk.ProductSetting) {
+				setting.Schema = nil
+			},
+			[]changeSummary{{ChangeModified, "schema"}},
+		},
+		{
+			"rules",
+			func(setting *product_sdk.ProductSetting) {
+				delete(setting.Rules, "delete")
+				setting.Rules["update"] = &product_sdk.Rule{Name: "update", Event: "accountUpdated"}
+				rule := setting.Rules["create"]
+				rule.Method = "update"
+				rule.PrimaryKey = []string{"id", "name"}
+				rule.HandlerConfig = nil
+			},
+			[]changeSummary{
+				{ChangeModified, "rules.create.method"},
+				{ChangeModified, "rules.create.pk"},
+				{ChangeModified, "rules.create.handler"},
+				{ChangeAdded, "rules.delete"},
+				{ChangeRemoved, "rules.update"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+
+		p := newTestProduct()
+		setting := p.NewSetting(testStream)
+		tt.modify(setting)
+
+		got := make([]changeSummary, 0)
+		for _, c := range p.Diff(setting, testStream) {
+			got = append(got, changeSummary{c.Type, c.Path})
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: changes = %v, want %v", tt.name, got, tt.want)
+		}
+
+		// Applying manifest resolves all differences
+		changed := p.ApplyTo(setting, testStream)
+		if changed != (len(tt.want) > 0) {
+			t.Errorf("%s: ApplyTo returned %v", tt.name, changed)
+		}
+
+		if changes := p.Diff(setting, testStream); len(changes) > 0 {
+			t.Errorf("%s: %d changes after apply", tt.name, len(changes))
+		}
+	}
+}
+
+func TestProductDiffNotExist(t *testing.T) {
+
+	changes := newTestProduct().Diff(nil, testStream)
+	if len(changes) != 1 || changes[0].Type != ChangeAdded || changes[0].Path != "product" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestChangeTextDiff(t *testing.T) {
+
+	tests := []struct {
+		change *Change
+		text   bool
+	}{
+		{&Change{Live: "a", Desired: "b"}, false},
+		{&Change{Live: 1, Desired: "b\n"}, false},
+		{&Change{Live: "return a\n", Desired: "return b\n"}, true},
+		{&Change{Live: "", Desired: "line1\nline2\n"}, true},
+	}
+
+	for _, tt := range tests {
+
+		if tt.change.IsText() != tt.text {
+			t.Errorf("IsText(%v => %v) = %v", tt.change.Live, tt.change.Desired, !tt.text)
+			continue
+		}
+
+		if !tt.text {
+			continue
+		}
+
+		diff := tt.change.TextDiff()
+		if !strings.Contains(diff, "--- live") || !strings.Contains(diff, "+++ manifest") {
+			t.Errorf("unexpected diff:\n%s", diff)
+		}
+	}
+}
+
+func TestTokenDiff(t *testing.T) {
+
+	token := &Token{
+		Kind:        KindToken,
+		ID:          "reader",
+		Description: "Reader",
+		Enabled:     true,
+		Permissions: []string{"PRODUCT.LIST", "PRODUCT.SUBSCRIBE"},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(setting *token_sdk.TokenSetting)
+		want   []changeSummary
+	}{
+		{
+			"unchanged",
+			func(setting *token_sdk.TokenSetting) {},
+			[]changeSummary{},
+		},
+		{
+			"fields",
+			func(setting *token_sdk.TokenSetting) {
+				setting.Description = ""
+				setting.Enabled = false
+			},
+			[]changeSummary{
+				{ChangeModified, "desc"},
+				{ChangeModified, "enabled"},
+			},
+		},
+		{
+			"permissions",
+			func(setting *token_sdk.TokenSetting) {
+				delete(setting.Permissions, "PRODUCT.LIST")
+				setting.Permissions["ADMIN"] = &token_sdk.Permission{}
+			},
+			[]changeSummary{
+				{ChangeRemoved, "permissions.ADMIN"},
+				{ChangeAdded, "permissions.PRODUCT.LIST"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+
+		setting := token.NewSetting()
+		tt.modify(setting)
+
+		got := make([]changeSummary, 0)
+		for _, c := range token.Diff(setting) {
+			got = append(got, changeSummary{c.Type, c.Path})
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: changes = %v, want %v", tt.name, got, tt.want)
+		}
+
+		if changed := token.ApplyTo(setting); changed != (len(tt.want) > 0) {
+			t.Errorf("%s: ApplyTo returned %v", tt.name, changed)
+		}
+
+		if changes := token.Diff(setting); len(changes) > 0 {
+			t.Errorf("%s: %d changes after apply", tt.name, len(changes))
+		}
+	}
+
+	changes := token.Diff(nil)
+	if len(changes) != 1 || changes[0].Type != ChangeAdded {
+		t.Errorf("unexpected changes of missing token %v", changes)
+	}
+}
@@ -0,0 +1,203 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+	"gopkg.in/yaml.v2"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// FromProductSetting creates manifest from live product setting.
+func FromProductSetting(setting *product_sdk.ProductSetting) *Product {
+
+	snapshot := setting.EnabledSnapshot
+
+	p := &Product{
+		Kind:        KindProduct,
+		Name:        setting.Name,
+		Description: setting.Description,
+		Enabled:     setting.Enabled,
+		Snapshot:    &snapshot,
+		Schema:      setting.Schema,
+		Rules:       make([]*Rule, 0, len(setting.Rules)),
+	}
+
+	for _, rule := range setting.Rules {
+
+		r := &Rule{
+			Name:        rule.Name,
+			Description: rule.Description,
+			Event:       rule.Event,
+			Method:      rule.Method,
+			Enabled:     rule.Enabled,
+			PrimaryKey:  rule.PrimaryKey,
+			Schema:      rule.SchemaConfig,
+		}
+
+		if rule.HandlerConfig != nil {
+			r.Handler = rule.HandlerConfig.Script
+		}
+
+		p.Rules = append(p.Rules, r)
+	}
+
+	sort.Slice(p.Rules, func(i, j int) bool {
+		return p.Rules[i].Name < p.Rules[j].Name
+	})
+
+	return p
+}
+
+// FromTokenSetting creates manifest from live token setting.
+func FromTokenSetting(setting *token_sdk.TokenSetting) *Token {
+
+	perms := make([]string, 0, len(setting.Permissions))
+	for perm := range setting.Permissions {
+		perms = append(perms, perm)
+	}
+
+	sort.Strings(perms)
+
+	return &Token{
+		Kind:        KindToken,
+		ID:          setting.ID,
+		Description: setting.Description,
+		Enabled:     setting.Enabled,
+		Permissions: perms,
+	}
+}
+
+// Export writes product manifest to directory. Schemas and handler scripts
+// are written to separate files which are referenced by the manifest.
+// Returns the path of manifest file.
+func (p *Product) Export(dir string) (string, error) {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// Copy manifest to avoid modifying the original one
+	out := *p
+	out.Rules = make([]*Rule, len(p.Rules))
+
+	if len(p.Schema) > 0 {
+
+		out.SchemaFile = "schema.json"
+		out.Schema = nil
+
+		err := writeJSONFile(filepath.Join(dir, out.SchemaFile), p.Schema)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for i, r := range p.Rules {
+
+		rule := *r
+		name := SafeFilename(r.Name)
+
+		if len(r.Schema) > 0 {
+
+			rule.SchemaFile = filepath.Join("rules", name+".schema.json")
+			rule.Schema = nil
+
+			err := writeJSONFile(filepath.Join(dir, rule.SchemaFile), r.Schema)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if len(r.Handler) > 0 {
+
+			rule.HandlerFile = filepath.Join("rules", name+".handler.js")
+			rule.Handler = ""
+
+			err := writeFile(filepath.Join(dir, rule.HandlerFile), []byte(r.Handler))
+			if err != nil {
+				return "", err
+			}
+		}
+
+		out.Rules[i] = &rule
+	}
+
+	filename := filepath.Join(dir, "product.yaml")
+	err = writeYAMLFile(filename, &out)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// Export writes token manifest to directory. Returns the path of manifest file.
+func (t *Token) Export(dir string) (string, error) {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	filename := filepath.Join(dir, SafeFilename(t.ID)+".yaml")
+	err = writeYAMLFile(filename, t)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// SafeFilename replaces characters which are not safe to be used in filename.
+// If name has to be changed, a short hash of the original name is appended
+// so that different names never share the same filename.
+func SafeFilename(name string) string {
+
+	filename := unsafeFilenameChars.ReplaceAllString(name, "_")
+	if filename == name && name != "." && name != ".." && len(name) > 0 {
+		return filename
+	}
+
+	sum := sha256.Sum256([]byte(name))
+
+	return filename + "-" + hex.EncodeToString(sum[:4])
+}
+
+func writeFile(filename string, data []byte) error {
+
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, 0644)
+}
+
+func writeJSONFile(filename string, v interface{}) error {
+
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filename, append(data, '\n'))
+}
+
+func writeYAMLFile(filename string, v interface{}) error {
+
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filename, data)
+}
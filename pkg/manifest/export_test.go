@@ -0,0 +1,203 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+)
+
+func TestSafeFilename(t *testing.T) {
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"accounts", "accounts"},
+		{"sales-2024.v1_a", "sales-2024.v1_a"},
+		{"a/b", "a_b-"},
+		{"a b", "a_b-"},
+		{"../../etc/passwd", ".._.._etc_passwd-"},
+		{".", ".-"},
+		{"..", "..-"},
+		{"", "-"},
+		{"帳號", "__-"},
+	}
+
+	for _, tt := range tests {
+
+		got := SafeFilename(tt.name)
+
+		if strings.HasSuffix(tt.want, "-") {
+			if !strings.HasPrefix(got, tt.want) || len(got) != len(tt.want)+8 {
+				t.Errorf("SafeFilename(%q) = %q, want %q with hash suffix", tt.name, got, tt.want)
+			}
+		} else if got != tt.want {
+			t.Errorf("SafeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+
+		if got == "." || got == ".." || strings.ContainsAny(got, "/\\") {
+			t.Errorf("SafeFilename(%q) = %q is not safe", tt.name, got)
+		}
+	}
+
+	// Names which are sanitized to the same string don't collide
+	names := []string{"a/b", "a b", "a_b", "a:b", "a\\b"}
+	seen := make(map[string]string)
+	for _, name := range names {
+
+		filename := SafeFilename(name)
+		if other, ok := seen[filename]; ok {
+			t.Errorf("SafeFilename(%q) and SafeFilename(%q) = %q", name, other, filename)
+		}
+
+		seen[filename] = name
+	}
+
+	// Result is stable
+	if SafeFilename("a/b") != SafeFilename("a/b") {
+		t.Errorf("SafeFilename is not deterministic")
+	}
+}
+
+func TestExport(t *testing.T) {
+
+	stream := "GVT_default_DP_accounts"
+
+	p := &Product{
+		Kind:        KindProduct,
+		Name:        "accounts",
+		Description: "Accounts",
+		Enabled:     true,
+		Schema: map[string]interface{}{
+			"id": map[string]interface{}{"type": "int"},
+		},
+		Rules: []*Rule{
+			{
+				Name:    "create/v1",
+				Event:   "accountCreated",
+				Method:  "create",
+				Enabled: true,
+				Schema: map[string]interface{}{
+					"id": map[string]interface{}{"type": "int"},
+				},
+				Handler: "return source\n",
+			},
+			{
+				Name:    "create v1",
+				Event:   "accountCreatedV1",
+				Method:  "create",
+				Enabled: true,
+				Handler: "return {}\n",
+			},
+			{
+				Name:       "delete",
+				Event:      "accountDeleted",
+				Method:     "delete",
+				PrimaryKey: []string{"id"},
+			},
+		},
+	}
+
+	setting := p.NewSetting(stream)
+
+	dir := t.TempDir()
+	filename, err := FromProductSetting(setting).Export(dir)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// Rules of similar names are written to different files
+	files, err := filepath.Glob(filepath.Join(dir, "rules", "*.handler.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Errorf("handler files = %v", files)
+	}
+
+	m, err := Load(filename)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(m.Products) != 1 {
+		t.Fatalf("%d products loaded", len(m.Products))
+	}
+
+	if changes := m.Products[0].Diff(setting, stream); len(changes) > 0 {
+		for _, c := range changes {
+			t.Errorf("exported product differs: %s: %v => %v", c.Path, c.Live, c.Desired)
+		}
+	}
+
+	// Export doesn't modify manifest
+	if len(p.Rules[0].Handler) == 0 || len(p.Rules[0].HandlerFile) > 0 {
+		t.Errorf("manifest was modified: %+v", p.Rules[0])
+	}
+}
+
+func TestExportToken(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for _, id := range []string{"..", "a/b", "a_b"} {
+
+		token := &Token{Kind: KindToken, ID: id}
+		filename, err := token.Export(dir)
+		if err != nil {
+			t.Fatalf("Export(%q): %v", id, err)
+		}
+
+		if filepath.Dir(filename) != dir {
+			t.Errorf("Export(%q) = %s, outside of %s", id, filename, dir)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Errorf("%d files exported, want 3", len(entries))
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Tokens) != 3 {
+		t.Errorf("%d tokens loaded, want 3", len(m.Tokens))
+	}
+}
+
+func TestFromProductSetting(t *testing.T) {
+
+	setting := &product_sdk.ProductSetting{
+		Name:            "accounts",
+		EnabledSnapshot: false,
+		Rules: map[string]*product_sdk.Rule{
+			"b": {Name: "b", Event: "e2", Method: "update"},
+			"a": {Name: "a", Event: "e1", Method: "create", HandlerConfig: &product_sdk.HandlerConfig{Script: "return source"}},
+		},
+	}
+
+	p := FromProductSetting(setting)
+
+	if p.IsSnapshotEnabled() {
+		t.Errorf("snapshot should be disabled")
+	}
+
+	if len(p.Rules) != 2 || p.Rules[0].Name != "a" || p.Rules[1].Name != "b" {
+		t.Fatalf("rules are not sorted by name")
+	}
+
+	if p.Rules[0].Handler != "return source" {
+		t.Errorf("handler = %q", p.Rules[0].Handler)
+	}
+}
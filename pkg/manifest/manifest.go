@@ -0,0 +1,270 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	KindProduct = "product"
+	KindToken   = "token"
+)
+
+var (
+	ErrUnknownKind = errors.New("unknown manifest kind")
+	ErrNoManifest  = errors.New("no manifest found")
+)
+
+type Manifests struct {
+	Products []*Product
+	Tokens   []*Token
+}
+
+type header struct {
+	Kind string `yaml:"kind"`
+}
+
+// Load reads manifests from files or directories. Directories are walked
+// recursively and every *.yaml and *.yml file will be loaded in order.
+func Load(paths ...string) (*Manifests, error) {
+
+	m := &Manifests{
+		Products: make([]*Product, 0),
+		Tokens:   make([]*Token, 0),
+	}
+
+	for _, p := range paths {
+
+		files, err := findFiles(p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, filename := range files {
+			err := m.loadFile(filename)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+		}
+	}
+
+	if len(m.Products) == 0 && len(m.Tokens) == 0 {
+		return nil, ErrNoManifest
+	}
+
+	return m, nil
+}
+
+func findFiles(path string) ([]string, error) {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := make([]string, 0)
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml":
+			files = append(files, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (m *Manifests) loadFile(filename string) error {
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	baseDir := filepath.Dir(filename)
+
+	// A file may contain multiple documents
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if doc == nil {
+			continue
+		}
+
+		raw, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		var h header
+		err = yaml.Unmarshal(raw, &h)
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(h.Kind) {
+		case KindProduct:
+			p := &Product{}
+			err := yaml.UnmarshalStrict(raw, p)
+			if err != nil {
+				return err
+			}
+
+			err = p.resolve(baseDir)
+			if err != nil {
+				return err
+			}
+
+			m.Products = append(m.Products, p)
+		case KindToken:
+			t := &Token{}
+			err := yaml.UnmarshalStrict(raw, t)
+			if err != nil {
+				return err
+			}
+
+			err = t.validate()
+			if err != nil {
+				return err
+			}
+
+			m.Tokens = append(m.Tokens, t)
+		default:
+			return fmt.Errorf("%w: \"%s\"", ErrUnknownKind, h.Kind)
+		}
+	}
+
+	return nil
+}
+
+func readSchemaFile(filename string) (map[string]interface{}, error) {
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	var schema map[string]interface{}
+	err = json.Unmarshal(data, &schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema format: %s", filename)
+	}
+
+	return schema, nil
+}
+
+func resolvePath(baseDir string, filename string) string {
+
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+
+	return filepath.Join(baseDir, filename)
+}
+
+// normalize converts values decoded from YAML to the same types that
+// encoding/json produces, so schemas can be compared with server settings.
+func normalize(v interface{}) interface{} {
+
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			m[k] = normalize(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(val))
+		for i, e := range val {
+			l[i] = normalize(e)
+		}
+		return l
+	}
+
+	return v
+}
+
+func normalizeSchema(schema map[string]interface{}) map[string]interface{} {
+
+	if schema == nil {
+		return nil
+	}
+
+	return normalize(schema).(map[string]interface{})
+}
+
+// EqualJSON compares two values by their JSON representation.
+func EqualJSON(a interface{}, b interface{}) bool {
+
+	// Treat empty maps the same as nil
+	if isEmpty(a) && isEmpty(b) {
+		return true
+	}
+
+	da, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	db, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(da, db)
+}
+
+func isEmpty(v interface{}) bool {
+
+	switch val := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(val) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+
+	dir := t.TempDir()
+	for name, content := range files {
+
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+
+	dir := writeTestFiles(t, map[string]string{
+		"products/accounts.yaml": `
+kind: product
+name: accounts
+enabled: true
+schemaFile: schema.json
+rules:
+- name: create
+  event: accountCreated
+  method: create
+  schema:
+    id:
+      type: int
+  handlerFile: rules/create.js
+`,
+		"products/schema.json":     `{"id":{"type":"int"}}`,
+		"products/rules/create.js": "return source\n",
+		"tokens.yml": `
+kind: token
+id: reader
+permissions:
+- PRODUCT.LIST
+---
+kind: Token
+id: writer
+`,
+		"README.md": "not a manifest",
+	})
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(m.Products) != 1 || len(m.Tokens) != 2 {
+		t.Fatalf("%d products and %d tokens loaded", len(m.Products), len(m.Tokens))
+	}
+
+	p := m.Products[0]
+	if !EqualJSON(p.Schema, map[string]interface{}{"id": map[string]interface{}{"type": "int"}}) {
+		t.Errorf("schema = %v", p.Schema)
+	}
+
+	r := p.Rules[0]
+	if r.Handler != "return source\n" {
+		t.Errorf("handler = %q", r.Handler)
+	}
+
+	// Schema decoded from YAML is comparable with JSON
+	if _, ok := r.Schema["id"].(map[string]interface{}); !ok {
+		t.Errorf("rule schema was not normalized: %#v", r.Schema)
+	}
+
+	if !p.IsSnapshotEnabled() {
+		t.Errorf("snapshot should be enabled by default")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{"unknown kind", "kind: job\nname: a\n", ErrUnknownKind.Error()},
+		{"no product name", "kind: product\n", "require product name"},
+		{"no token id", "kind: token\n", "require token id"},
+		{"unknown field", "kind: token\nid: a\nscope: all\n", "not found"},
+		{
+			"duplicate rule",
+			"kind: product\nname: a\nrules:\n- {name: r, event: e, method: create}\n- {name: r, event: e, method: create}\n",
+			"duplicate rule",
+		},
+		{"no event", "kind: product\nname: a\nrules:\n- {name: r, method: create}\n", "require event"},
+		{"no method", "kind: product\nname: a\nrules:\n- {name: r, event: e}\n", "require method"},
+		{
+			"schema and schemaFile",
+			"kind: product\nname: a\nschema: {id: {type: int}}\nschemaFile: schema.json\n",
+			"cannot be used together",
+		},
+		{"missing schema file", "kind: product\nname: a\nschemaFile: missing.json\n", "failed to read schema file"},
+		{
+			"missing handler file",
+			"kind: product\nname: a\nrules:\n- {name: r, event: e, method: create, handlerFile: missing.js}\n",
+			"failed to read handler file",
+		},
+	}
+
+	for _, tt := range tests {
+
+		dir := writeTestFiles(t, map[string]string{"manifest.yaml": tt.manifest})
+
+		_, err := Load(dir)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	// Directory without manifests
+	if _, err := Load(t.TempDir()); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("expected ErrNoManifest, got %v", err)
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+
+	p := newTestProduct()
+
+	setting := p.NewSetting(testStream)
+	if setting.Stream != testStream || !setting.EnabledSnapshot || len(setting.Rules) != 2 {
+		t.Fatalf("unexpected setting %+v", setting)
+	}
+
+	rule := setting.Rules["create"]
+	if rule.Product != p.Name || len(rule.ID) == 0 || rule.HandlerConfig == nil || rule.HandlerConfig.Script != "return source\n" {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+
+	if p.ApplyTo(setting, testStream) {
+		t.Errorf("ApplyTo changed setting which matches manifest")
+	}
+
+	// Rules which are not declared are removed, handler is cleared
+	p.Rules = p.Rules[:1]
+	p.Rules[0].Handler = ""
+
+	if !p.ApplyTo(setting, testStream) {
+		t.Fatalf("ApplyTo didn't change setting")
+	}
+
+	if _, ok := setting.Rules["delete"]; ok {
+		t.Errorf("undeclared rule was not removed")
+	}
+
+	if setting.Rules["create"].HandlerConfig != nil {
+		t.Errorf("handler was not cleared")
+	}
+
+	// Existing rules keep their ID
+	if setting.Rules["create"].ID != rule.ID {
+		t.Errorf("rule ID changed")
+	}
+}
+
+func TestEqualJSON(t *testing.T) {
+
+	tests := []struct {
+		a     interface{}
+		b     interface{}
+		equal bool
+	}{
+		{nil, map[string]interface{}{}, true},
+		{map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1.0}, true},
+		{map[string]interface{}{"a": 1}, map[string]interface{}{"a": "1"}, false},
+		{[]string{"a"}, []interface{}{"a"}, true},
+		{true, false, false},
+	}
+
+	for _, tt := range tests {
+		if EqualJSON(tt.a, tt.b) != tt.equal {
+			t.Errorf("EqualJSON(%v, %v) = %v", tt.a, tt.b, !tt.equal)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	"github.com/google/uuid"
+)
+
+type Product struct {
+	Kind        string                 `yaml:"kind"`
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"desc,omitempty"`
+	Enabled     bool                   `yaml:"enabled"`
+	Snapshot    *bool                  `yaml:"snapshot,omitempty"`
+	Schema      map[string]interface{} `yaml:"schema,omitempty"`
+	SchemaFile  string                 `yaml:"schemaFile,omitempty"`
+	Rules       []*Rule                `yaml:"rules,omitempty"`
+}
+
+type Rule struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"desc,omitempty"`
+	Event       string                 `yaml:"event"`
+	Method      string                 `yaml:"method"`
+	Enabled     bool                   `yaml:"enabled"`
+	PrimaryKey  []string               `yaml:"pk,omitempty"`
+	Schema      map[string]interface{} `yaml:"schema,omitempty"`
+	SchemaFile  string                 `yaml:"schemaFile,omitempty"`
+	Handler     string                 `yaml:"handler,omitempty"`
+	HandlerFile string                 `yaml:"handlerFile,omitempty"`
+}
+
+func (p *Product) resolve(baseDir string) error {
+
+	if len(p.Name) == 0 {
+		return errors.New("require product name")
+	}
+
+	p.Schema = normalizeSchema(p.Schema)
+
+	if len(p.SchemaFile) > 0 {
+
+		if p.Schema != nil {
+			return fmt.Errorf("product \"%s\": schema and schemaFile cannot be used together", p.Name)
+		}
+
+		schema, err := readSchemaFile(resolvePath(baseDir, p.SchemaFile))
+		if err != nil {
+			return err
+		}
+
+		p.Schema = schema
+	}
+
+	names := make(map[string]bool)
+	for _, r := range p.Rules {
+
+		if len(r.Name) == 0 {
+			return fmt.Errorf("product \"%s\": require rule name", p.Name)
+		}
+
+		if names[r.Name] {
+			return fmt.Errorf("product \"%s\": duplicate rule \"%s\"", p.Name, r.Name)
+		}
+
+		names[r.Name] = true
+
+		err := r.resolve(baseDir)
+		if err != nil {
+			return fmt.Errorf("product \"%s\": %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) resolve(baseDir string) error {
+
+	if len(r.Event) == 0 {
+		return fmt.Errorf("rule \"%s\": require event", r.Name)
+	}
+
+	if len(r.Method) == 0 {
+		return fmt.Errorf("rule \"%s\": require method", r.Name)
+	}
+
+	r.Schema = normalizeSchema(r.Schema)
+
+	if len(r.SchemaFile) > 0 {
+
+		if r.Schema != nil {
+			return fmt.Errorf("rule \"%s\": schema and schemaFile cannot be used together", r.Name)
+		}
+
+		schema, err := readSchemaFile(resolvePath(baseDir, r.SchemaFile))
+		if err != nil {
+			return err
+		}
+
+		r.Schema = schema
+	}
+
+	if len(r.HandlerFile) > 0 {
+
+		if len(r.Handler) > 0 {
+			return fmt.Errorf("rule \"%s\": handler and handlerFile cannot be used together", r.Name)
+		}
+
+		script, err := os.ReadFile(resolvePath(baseDir, r.HandlerFile))
+		if err != nil {
+			return fmt.Errorf("failed to read handler file: %w", err)
+		}
+
+		r.Handler = string(script)
+	}
+
+	return nil
+}
+
+// IsSnapshotEnabled returns whether snapshot should be enabled, it's enabled by default.
+func (p *Product) IsSnapshotEnabled() bool {
+
+	if p.Snapshot == nil {
+		return true
+	}
+
+	return *p.Snapshot
+}
+
+// NewSetting creates a new product setting from manifest. Events of product
+// are stored in the given stream.
+func (p *Product) NewSetting(stream string) *product_sdk.ProductSetting {
+
+	setting := &product_sdk.ProductSetting{
+		Name:  p.Name,
+		Rules: make(map[string]*product_sdk.Rule),
+	}
+
+	p.ApplyTo(setting, stream)
+
+	return setting
+}
+
+// ApplyTo updates product setting to match manifest. Rules which are not
+// declared in manifest will be removed. Returns true if setting was changed.
+func (p *Product) ApplyTo(setting *product_sdk.ProductSetting, stream string) bool {
+
+	changed := false
+
+	if setting.Stream != stream {
+		setting.Stream = stream
+		changed = true
+	}
+
+	if setting.Description != p.Description {
+		setting.Description = p.Description
+		changed = true
+	}
+
+	if setting.Enabled != p.Enabled {
+		setting.Enabled = p.Enabled
+		changed = true
+	}
+
+	if setting.EnabledSnapshot != p.IsSnapshotEnabled() {
+		setting.EnabledSnapshot = p.IsSnapshotEnabled()
+		changed = true
+	}
+
+	if !EqualJSON(setting.Schema, p.Schema) {
+		setting.Schema = p.Schema
+		changed = true
+	}
+
+	if setting.Rules == nil {
+		setting.Rules = make(map[string]*product_sdk.Rule)
+	}
+
+	// Remove rules which are not declared
+	declared := make(map[string]*Rule, len(p.Rules))
+	for _, r := range p.Rules {
+		declared[r.Name] = r
+	}
+
+	for name := range setting.Rules {
+		if _, ok := declared[name]; !ok {
+			delete(setting.Rules, name)
+			changed = true
+		}
+	}
+
+	// Create or update rules
+	for _, r := range p.Rules {
+
+		rule, ok := setting.Rules[r.Name]
+		if !ok {
+			setting.Rules[r.Name] = r.NewRule(p.Name)
+			changed = true
+			continue
+		}
+
+		if r.ApplyTo(rule) {
+			rule.UpdatedAt = time.Now()
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// NewRule creates a new rule of product from manifest.
+func (r *Rule) NewRule(productName string) *product_sdk.Rule {
+
+	rule := product_sdk.NewRule()
+	rule.Name = r.Name
+	rule.Product = productName
+	rule.UpdatedAt = time.Now()
+	rule.CreatedAt = time.Now()
+
+	// Unique ID
+	id, _ := uuid.NewUUID()
+	rule.ID = id.String()
+
+	r.ApplyTo(rule)
+
+	return rule
+}
+
+// ApplyTo updates rule to match manifest. Returns true if rule was changed.
+func (r *Rule) ApplyTo(rule *product_sdk.Rule) bool {
+
+	changed := false
+
+	if rule.Description != r.Description {
+		rule.Description = r.Description
+		changed = true
+	}
+
+	if rule.Event != r.Event {
+		rule.Event = r.Event
+		changed = true
+	}
+
+	if rule.Method != r.Method {
+		rule.Method = r.Method
+		changed = true
+	}
+
+	if rule.Enabled != r.Enabled {
+		rule.Enabled = r.Enabled
+		changed = true
+	}
+
+	pk := r.PrimaryKey
+	if pk == nil {
+		pk = []string{}
+	}
+
+	if !(len(rule.PrimaryKey) == 0 && len(pk) == 0) && !reflect.DeepEqual(rule.PrimaryKey, pk) {
+		rule.PrimaryKey = pk
+		changed = true
+	}
+
+	if !EqualJSON(rule.SchemaConfig, r.Schema) {
+		rule.SchemaConfig = r.Schema
+		changed = true
+	}
+
+	var script string
+	if rule.HandlerConfig != nil {
+		script = rule.HandlerConfig.Script
+	}
+
+	if script != r.Handler {
+
+		if len(r.Handler) == 0 {
+			rule.HandlerConfig = nil
+		} else {
+			rule.HandlerConfig = &product_sdk.HandlerConfig{
+				Type:   "script",
+				Script: r.Handler,
+			}
+		}
+
+		changed = true
+	}
+
+	return changed
+}
@@ -0,0 +1,76 @@
+package manifest
+
+import (
+	"errors"
+
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+)
+
+type Token struct {
+	Kind        string   `yaml:"kind"`
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"desc,omitempty"`
+	Enabled     bool     `yaml:"enabled"`
+	Permissions []string `yaml:"permissions,omitempty"`
+}
+
+func (t *Token) validate() error {
+
+	if len(t.ID) == 0 {
+		return errors.New("require token id")
+	}
+
+	return nil
+}
+
+// NewSetting creates a new token setting from manifest.
+func (t *Token) NewSetting() *token_sdk.TokenSetting {
+
+	setting := &token_sdk.TokenSetting{
+		ID: t.ID,
+	}
+
+	t.ApplyTo(setting)
+
+	return setting
+}
+
+// ApplyTo updates token setting to match manifest. Permissions which are not
+// declared in manifest will be revoked. Returns true if setting was changed.
+func (t *Token) ApplyTo(setting *token_sdk.TokenSetting) bool {
+
+	changed := false
+
+	if setting.Description != t.Description {
+		setting.Description = t.Description
+		changed = true
+	}
+
+	if setting.Enabled != t.Enabled {
+		setting.Enabled = t.Enabled
+		changed = true
+	}
+
+	if setting.Permissions == nil {
+		setting.Permissions = make(map[string]*token_sdk.Permission)
+	}
+
+	declared := make(map[string]bool, len(t.Permissions))
+	for _, perm := range t.Permissions {
+		declared[perm] = true
+
+		if _, ok := setting.Permissions[perm]; !ok {
+			setting.Permissions[perm] = &token_sdk.Permission{}
+			changed = true
+		}
+	}
+
+	for perm := range setting.Permissions {
+		if !declared[perm] {
+			delete(setting.Permissions, perm)
+			changed = true
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,501 @@
+package materializer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	PositionTable = "_gravity_position"
+)
+
+var (
+	ErrNoPrimaryKey      = errors.New("primary key is required")
+	ErrNoSchema          = errors.New("schema is required")
+	ErrMissingPrimaryKey = errors.New("missing field of primary key")
+	ErrUnsupportedMethod = errors.New("unsupported method")
+)
+
+type Options struct {
+	Table      string
+	PrimaryKey []string
+	Schema     map[string]interface{}
+}
+
+type Column struct {
+	Name string
+	Type string
+
+	// SQLite type
+	SQLType string
+}
+
+type Stats struct {
+	Upserted uint64
+	Deleted  uint64
+}
+
+// Materializer applies events of product to a SQLite table, records are
+// upserted or deleted by primary key. Changes are applied in a transaction
+// along with the position of stream, so it can resume after restart.
+type Materializer struct {
+	db         *sql.DB
+	product    string
+	table      string
+	primaryKey []string
+	columns    map[string]*Column
+
+	tx    *sql.Tx
+	stmts map[string]*sql.Stmt
+	stats Stats
+}
+
+func Open(path string, product string, options *Options) (*Materializer, error) {
+
+	if len(options.Schema) == 0 {
+		return nil, ErrNoSchema
+	}
+
+	if len(options.PrimaryKey) == 0 {
+		return nil, ErrNoPrimaryKey
+	}
+
+	table := options.Table
+	if len(table) == 0 {
+		table = product
+	}
+
+	m := &Materializer{
+		product:    product,
+		table:      table,
+		primaryKey: options.PrimaryKey,
+		columns:    make(map[string]*Column),
+		stmts:      make(map[string]*sql.Stmt),
+	}
+
+	for name, def := range options.Schema {
+
+		d, _ := def.(map[string]interface{})
+		t, _ := d["type"].(string)
+
+		m.columns[name] = &Column{
+			Name:    name,
+			Type:    t,
+			SQLType: sqlType(t),
+		}
+	}
+
+	for _, k := range m.primaryKey {
+		if _, ok := m.columns[k]; !ok {
+			return nil, fmt.Errorf("%w: field \"%s\" of primary key is not in schema", ErrNoPrimaryKey, k)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single connection for transactions and pragmas, statements are prepared
+	// in transaction so they will not wait for another connection.
+	db.SetMaxOpenConns(1)
+	m.db = db
+
+	err = m.initialize()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func sqlType(t string) string {
+
+	switch t {
+	case "int", "uint", "bool":
+		return "INTEGER"
+	case "float":
+		return "REAL"
+	case "binary":
+		return "BLOB"
+	}
+
+	// Strings, time in RFC3339 and JSON of map, array and any
+	return "TEXT"
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// ColumnNames returns sorted column names
+func (m *Materializer) ColumnNames() []string {
+
+	names := make([]string, 0, len(m.columns))
+	for name := range m.columns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (m *Materializer) initialize() error {
+
+	_, err := m.db.Exec("PRAGMA journal_mode=WAL")
+	if err != nil {
+		return err
+	}
+
+	// Table of product, columns of primary key come first
+	defs := make([]string, 0, len(m.columns)+1)
+	for _, name := range m.primaryKey {
+		defs = append(defs, quote(name)+" "+m.columns[name].SQLType)
+	}
+
+	for _, name := range m.ColumnNames() {
+		if !m.isPrimaryKey(name) {
+			defs = append(defs, quote(name)+" "+m.columns[name].SQLType)
+		}
+	}
+
+	pk := make([]string, len(m.primaryKey))
+	for i, k := range m.primaryKey {
+		pk[i] = quote(k)
+	}
+
+	defs = append(defs, "PRIMARY KEY ("+strings.Join(pk, ", ")+")")
+
+	_, err = m.db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quote(m.table), strings.Join(defs, ", ")))
+	if err != nil {
+		return err
+	}
+
+	// Columns which were added to schema later
+	rows, err := m.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", quote(m.table)))
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid int
+		var name, typ string
+		var notNull, pk int
+		var dflt interface{}
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+
+		existing[name] = true
+	}
+
+	rows.Close()
+
+	for _, name := range m.ColumnNames() {
+		if existing[name] {
+			continue
+		}
+
+		_, err := m.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quote(m.table), quote(name), m.columns[name].SQLType))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Position of stream
+	_, err = m.db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\"table\" TEXT PRIMARY KEY, product TEXT, seq INTEGER, updated_at TEXT)", PositionTable))
+
+	return err
+}
+
+func (m *Materializer) GetTable() string {
+	return m.table
+}
+
+// Position returns the last stream sequence which has been applied, it's 0 if
+// nothing was applied.
+func (m *Materializer) Position() (uint64, error) {
+
+	var seq uint64
+	err := m.db.QueryRow(fmt.Sprintf("SELECT seq FROM %s WHERE \"table\" = ?", PositionTable), m.table).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return seq, err
+}
+
+func (m *Materializer) Stats() Stats {
+	return m.stats
+}
+
+func (m *Materializer) begin() error {
+
+	if m.tx != nil {
+		return nil
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	m.tx = tx
+
+	return nil
+}
+
+// stmt returns prepared statement of current transaction
+func (m *Materializer) stmt(key string, query func() string) (*sql.Stmt, error) {
+
+	if s, ok := m.stmts[key]; ok {
+		return s, nil
+	}
+
+	s, err := m.tx.Prepare(query())
+	if err != nil {
+		return nil, err
+	}
+
+	m.stmts[key] = s
+
+	return s, nil
+}
+
+// Apply applies record with method of product event (INSERT, UPDATE, DELETE
+// or TRUNCATE) in current transaction.
+func (m *Materializer) Apply(method string, record map[string]interface{}) error {
+
+	if err := m.begin(); err != nil {
+		return err
+	}
+
+	switch method {
+	case "INSERT", "UPDATE":
+		return m.upsert(record)
+	case "DELETE":
+		return m.delete(record)
+	case "TRUNCATE":
+		_, err := m.tx.Exec(fmt.Sprintf("DELETE FROM %s", quote(m.table)))
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedMethod, method)
+}
+
+func (m *Materializer) upsert(record map[string]interface{}) error {
+
+	// Fields which are not in schema are ignored
+	names := make([]string, 0, len(record))
+	for name := range record {
+		if _, ok := m.columns[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	args := make([]interface{}, 0, len(names))
+	for _, k := range m.primaryKey {
+		if v, ok := record[k]; !ok || v == nil {
+			return fmt.Errorf("%w: %s", ErrMissingPrimaryKey, k)
+		}
+	}
+
+	for _, name := range names {
+		v, err := m.value(m.columns[name], record[name])
+		if err != nil {
+			return err
+		}
+
+		args = append(args, v)
+	}
+
+	// Fields which are not in record are kept for partial updates
+	stmt, err := m.stmt("upsert:"+strings.Join(names, ","), func() string {
+
+		cols := make([]string, len(names))
+		placeholders := make([]string, len(names))
+		updates := make([]string, 0, len(names))
+		for i, name := range names {
+			cols[i] = quote(name)
+			placeholders[i] = "?"
+			if !m.isPrimaryKey(name) {
+				updates = append(updates, quote(name)+" = excluded."+quote(name))
+			}
+		}
+
+		pk := make([]string, len(m.primaryKey))
+		for i, k := range m.primaryKey {
+			pk[i] = quote(k)
+		}
+
+		action := "DO NOTHING"
+		if len(updates) > 0 {
+			action = "DO UPDATE SET " + strings.Join(updates, ", ")
+		}
+
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+			quote(m.table),
+			strings.Join(cols, ", "),
+			strings.Join(placeholders, ", "),
+			strings.Join(pk, ", "),
+			action,
+		)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec(args...)
+	if err != nil {
+		return err
+	}
+
+	m.stats.Upserted++
+
+	return nil
+}
+
+func (m *Materializer) delete(record map[string]interface{}) error {
+
+	args := make([]interface{}, len(m.primaryKey))
+	for i, k := range m.primaryKey {
+		v, ok := record[k]
+		if !ok || v == nil {
+			return fmt.Errorf("%w: %s", ErrMissingPrimaryKey, k)
+		}
+
+		val, err := m.value(m.columns[k], v)
+		if err != nil {
+			return err
+		}
+
+		args[i] = val
+	}
+
+	stmt, err := m.stmt("delete", func() string {
+
+		conds := make([]string, len(m.primaryKey))
+		for i, k := range m.primaryKey {
+			conds[i] = quote(k) + " = ?"
+		}
+
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", quote(m.table), strings.Join(conds, " AND "))
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec(args...)
+	if err != nil {
+		return err
+	}
+
+	m.stats.Deleted++
+
+	return nil
+}
+
+func (m *Materializer) isPrimaryKey(name string) bool {
+
+	for _, k := range m.primaryKey {
+		if k == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// value converts value of record to value of SQLite
+func (m *Materializer) value(c *Column, v interface{}) (interface{}, error) {
+
+	switch d := v.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return d.UTC().Format(time.RFC3339Nano), nil
+	case uint64:
+		if d > math.MaxInt64 {
+			return fmt.Sprint(d), nil
+		}
+
+		return int64(d), nil
+	case uint:
+		return m.value(c, uint64(d))
+	case uint32:
+		return int64(d), nil
+	case string, []byte, bool, int, int8, int16, int32, int64, uint8, uint16, float32, float64:
+		return d, nil
+	}
+
+	// Map, array and other values are stored in JSON
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert field \"%s\": %w", c.Name, err)
+	}
+
+	return string(data), nil
+}
+
+// Commit commits changes of current transaction with position of stream. The
+// position is kept if seq is 0.
+func (m *Materializer) Commit(seq uint64) error {
+
+	if err := m.begin(); err != nil {
+		return err
+	}
+
+	if seq > 0 {
+		_, err := m.tx.Exec(fmt.Sprintf("INSERT INTO %s (\"table\", product, seq, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (\"table\") DO UPDATE SET product = excluded.product, seq = excluded.seq, updated_at = excluded.updated_at", PositionTable),
+			m.table,
+			m.product,
+			seq,
+			time.Now().UTC().Format(time.RFC3339Nano),
+		)
+		if err != nil {
+			m.Rollback()
+			return err
+		}
+	}
+
+	err := m.tx.Commit()
+	m.tx = nil
+	m.stmts = make(map[string]*sql.Stmt)
+
+	return err
+}
+
+// Rollback discards changes of current transaction
+func (m *Materializer) Rollback() error {
+
+	if m.tx == nil {
+		return nil
+	}
+
+	err := m.tx.Rollback()
+	m.tx = nil
+	m.stmts = make(map[string]*sql.Stmt)
+
+	return err
+}
+
+func (m *Materializer) Close() error {
+
+	m.Rollback()
+
+	return m.db.Close()
+}
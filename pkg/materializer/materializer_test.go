@@ -0,0 +1,389 @@
+package materializer
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testSchema = map[string]interface{}{
+	"id":   map[string]interface{}{"type": "uint"},
+	"name": map[string]interface{}{"type": "string"},
+	"tags": map[string]interface{}{"type": "array"},
+}
+
+func openTestMaterializer(t *testing.T, path string, schema map[string]interface{}) *Materializer {
+
+	m, err := Open(path, "accounts", &Options{
+		PrimaryKey: []string{"id"},
+		Schema:     schema,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	return m
+}
+
+// names returns names of records by id
+func names(t *testing.T, m *Materializer) map[int64]string {
+
+	rows, err := m.db.Query(`SELECT id, name FROM accounts ORDER BY id`)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	defer rows.Close()
+
+	records := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var name sql.NullString
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+
+		records[id] = name.String
+	}
+
+	return records
+}
+
+func TestOpen(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options *Options
+		want    error
+	}{
+		{
+			name:    "no schema",
+			options: &Options{PrimaryKey: []string{"id"}},
+			want:    ErrNoSchema,
+		},
+		{
+			name:    "no primary key",
+			options: &Options{Schema: testSchema},
+			want:    ErrNoPrimaryKey,
+		},
+		{
+			name:    "primary key is not in schema",
+			options: &Options{PrimaryKey: []string{"uid"}, Schema: testSchema},
+			want:    ErrNoPrimaryKey,
+		},
+	}
+
+	for _, tt := range tests {
+
+		_, err := Open(filepath.Join(t.TempDir(), "test.db"), "accounts", tt.options)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: Open() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+
+	type change struct {
+		method string
+		record map[string]interface{}
+	}
+
+	tests := []struct {
+		name    string
+		changes []change
+		want    map[int64]string
+		stats   Stats
+	}{
+		{
+			name: "insert",
+			changes: []change{
+				{"INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"}},
+				{"INSERT", map[string]interface{}{"id": uint64(2), "name": "armani"}},
+			},
+			want:  map[int64]string{1: "fred", 2: "armani"},
+			stats: Stats{Upserted: 2},
+		},
+		{
+			name: "update",
+			changes: []change{
+				{"INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"}},
+				{"UPDATE", map[string]interface{}{"id": uint64(1), "name": "fred2"}},
+			},
+			want:  map[int64]string{1: "fred2"},
+			stats: Stats{Upserted: 2},
+		},
+		{
+			name: "partial update keeps other fields",
+			changes: []change{
+				{"INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"}},
+				{"UPDATE", map[string]interface{}{"id": uint64(1), "tags": []interface{}{"a"}}},
+			},
+			want:  map[int64]string{1: "fred"},
+			stats: Stats{Upserted: 2},
+		},
+		{
+			name: "delete",
+			changes: []change{
+				{"INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"}},
+				{"INSERT", map[string]interface{}{"id": uint64(2), "name": "armani"}},
+				{"DELETE", map[string]interface{}{"id": uint64(1)}},
+			},
+			want:  map[int64]string{2: "armani"},
+			stats: Stats{Upserted: 2, Deleted: 1},
+		},
+		{
+			name: "truncate",
+			changes: []change{
+				{"INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"}},
+				{"TRUNCATE", nil},
+				{"INSERT", map[string]interface{}{"id": uint64(2), "name": "armani"}},
+			},
+			want:  map[int64]string{2: "armani"},
+			stats: Stats{Upserted: 2},
+		},
+	}
+
+	for _, tt := range tests {
+
+		m := openTestMaterializer(t, filepath.Join(t.TempDir(), "test.db"), testSchema)
+
+		for _, c := range tt.changes {
+			if err := m.Apply(c.method, c.record); err != nil {
+				t.Fatalf("%s: Apply(%s, %v): %v", tt.name, c.method, c.record, err)
+			}
+		}
+
+		if err := m.Commit(uint64(len(tt.changes))); err != nil {
+			t.Fatalf("%s: Commit: %v", tt.name, err)
+		}
+
+		if got := names(t, m); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: records = %v, want %v", tt.name, got, tt.want)
+		}
+
+		if got := m.Stats(); got != tt.stats {
+			t.Errorf("%s: Stats() = %+v, want %+v", tt.name, got, tt.stats)
+		}
+
+		m.Close()
+	}
+}
+
+func TestApplyJSON(t *testing.T) {
+
+	m := openTestMaterializer(t, filepath.Join(t.TempDir(), "test.db"), testSchema)
+	defer m.Close()
+
+	err := m.Apply("INSERT", map[string]interface{}{"id": uint64(1), "tags": []interface{}{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if err := m.Commit(1); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	var tags string
+	if err := m.db.QueryRow(`SELECT tags FROM accounts WHERE id = 1`).Scan(&tags); err != nil {
+		t.Fatalf("QueryRow: %v", err)
+	}
+
+	if want := `["a","b"]`; tags != want {
+		t.Errorf("tags = %s, want %s", tags, want)
+	}
+}
+
+func TestSchemaEvolution(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	m := openTestMaterializer(t, path, testSchema)
+	m.Apply("INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"})
+	if err := m.Commit(1); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	m.Close()
+
+	// A new field is added to schema
+	schema := map[string]interface{}{
+		"email": map[string]interface{}{"type": "string"},
+	}
+	for name, def := range testSchema {
+		schema[name] = def
+	}
+
+	m = openTestMaterializer(t, path, schema)
+	defer m.Close()
+
+	err := m.Apply("INSERT", map[string]interface{}{"id": uint64(2), "name": "armani", "email": "armani@example.com"})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if err := m.Commit(2); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	tests := []struct {
+		id    int64
+		email sql.NullString
+	}{
+		{1, sql.NullString{}},
+		{2, sql.NullString{String: "armani@example.com", Valid: true}},
+	}
+
+	for _, tt := range tests {
+
+		var email sql.NullString
+		if err := m.db.QueryRow(`SELECT email FROM accounts WHERE id = ?`, tt.id).Scan(&email); err != nil {
+			t.Fatalf("QueryRow(%d): %v", tt.id, err)
+		}
+
+		if email != tt.email {
+			t.Errorf("email of %d = %v, want %v", tt.id, email, tt.email)
+		}
+	}
+}
+
+// TestFailedEvent follows materialize command, changes before the failed
+// event are committed and it stops, or the failed event is committed past
+// with --skip-errors.
+func TestFailedEvent(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		skipErrors bool
+		want       map[int64]string
+		position   uint64
+	}{
+		{
+			name:       "stop",
+			skipErrors: false,
+			want:       map[int64]string{1: "fred"},
+			position:   1,
+		},
+		{
+			name:       "skip errors",
+			skipErrors: true,
+			want:       map[int64]string{1: "fred", 3: "armani"},
+			position:   3,
+		},
+	}
+
+	events := []struct {
+		method string
+		record map[string]interface{}
+		err    error
+	}{
+		{"INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"}, nil},
+		{"INSERT", map[string]interface{}{"name": "unknown"}, ErrMissingPrimaryKey},
+		{"INSERT", map[string]interface{}{"id": uint64(3), "name": "armani"}, nil},
+	}
+
+	for _, tt := range tests {
+
+		path := filepath.Join(t.TempDir(), "test.db")
+		m := openTestMaterializer(t, path, testSchema)
+
+		var lastSeq uint64
+		for i, e := range events {
+
+			seq := uint64(i + 1)
+			err := m.Apply(e.method, e.record)
+			if !errors.Is(err, e.err) {
+				t.Errorf("%s: Apply(%v) error = %v, want %v", tt.name, e.record, err, e.err)
+			}
+
+			if err != nil && !tt.skipErrors {
+				break
+			}
+
+			lastSeq = seq
+		}
+
+		if err := m.Commit(lastSeq); err != nil {
+			t.Fatalf("%s: Commit: %v", tt.name, err)
+		}
+
+		m.Close()
+
+		// Materialization resumes after the committed position
+		m = openTestMaterializer(t, path, testSchema)
+
+		if got := names(t, m); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: records = %v, want %v", tt.name, got, tt.want)
+		}
+
+		if pos, err := m.Position(); err != nil || pos != tt.position {
+			t.Errorf("%s: Position() = %d, %v, want %d", tt.name, pos, err, tt.position)
+		}
+
+		m.Close()
+	}
+}
+
+func TestUnsupportedMethod(t *testing.T) {
+
+	m := openTestMaterializer(t, filepath.Join(t.TempDir(), "test.db"), testSchema)
+	defer m.Close()
+
+	err := m.Apply("MERGE", map[string]interface{}{"id": uint64(1)})
+	if !errors.Is(err, ErrUnsupportedMethod) {
+		t.Errorf("Apply(MERGE) error = %v, want %v", err, ErrUnsupportedMethod)
+	}
+}
+
+func TestPosition(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	m := openTestMaterializer(t, path, testSchema)
+
+	if pos, err := m.Position(); err != nil || pos != 0 {
+		t.Errorf("Position() of new database = %d, %v, want 0", pos, err)
+	}
+
+	m.Apply("INSERT", map[string]interface{}{"id": uint64(1), "name": "fred"})
+	if err := m.Commit(5); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	// Position is kept by commit without sequence
+	m.Apply("INSERT", map[string]interface{}{"id": uint64(2), "name": "armani"})
+	if err := m.Commit(0); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	// Changes which are not committed are discarded
+	m.Apply("INSERT", map[string]interface{}{"id": uint64(3), "name": "lucas"})
+	m.Close()
+
+	m = openTestMaterializer(t, path, testSchema)
+	defer m.Close()
+
+	if pos, err := m.Position(); err != nil || pos != 5 {
+		t.Errorf("Position() after reopen = %d, %v, want 5", pos, err)
+	}
+
+	want := map[int64]string{1: "fred", 2: "armani"}
+	if got := names(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+
+	// Rollback keeps position
+	m.Apply("DELETE", map[string]interface{}{"id": uint64(1)})
+	if err := m.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if pos, err := m.Position(); err != nil || pos != 5 {
+		t.Errorf("Position() after rollback = %d, %v, want 5", pos, err)
+	}
+
+	if got := names(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("records after rollback = %v, want %v", got, want)
+	}
+}
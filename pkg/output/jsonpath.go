@@ -0,0 +1,71 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var (
+	ErrInvalidJSONPath = errors.New("invalid jsonpath expression")
+)
+
+// executeJSONPath executes template of kubectl JSONPath, e.g.
+// '{range .items[*]}{.name}{"\t"}{.enabled}{"\n"}{end}', with the same
+// implementation as kubectl. Missing keys result in nothing.
+func executeJSONPath(w io.Writer, expression string, data interface{}) error {
+
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jp.Parse(expression); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSONPath, err)
+	}
+
+	err := jp.Execute(w, nativeNumbers(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSONPath, err)
+	}
+
+	return nil
+}
+
+// nativeNumbers replaces json.Number with int64, uint64 or float64, so numbers
+// can be compared by filters without losing precision of large integers.
+func nativeNumbers(v interface{}) interface{} {
+
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			return n
+		}
+
+		if n, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+			return n
+		}
+
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+
+		return val.String()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			m[k] = nativeNumbers(e)
+		}
+
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, e := range val {
+			list[i] = nativeNumbers(e)
+		}
+
+		return list
+	}
+
+	return v
+}
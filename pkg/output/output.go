@@ -0,0 +1,180 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FormatTable      = "table"
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatJSONPath   = "jsonpath"
+	FormatGoTemplate = "go-template"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported output format")
+	ErrEmptyExpression   = errors.New("output format requires an expression")
+)
+
+type Printer struct {
+	format     string
+	expression string
+}
+
+// New parses output specification like "json", "yaml", "jsonpath={.name}"
+// or "go-template={{.name}}" and returns printer for it.
+func New(spec string) (*Printer, error) {
+
+	if len(spec) == 0 {
+		spec = FormatTable
+	}
+
+	format := spec
+	expression := ""
+
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) == 2 {
+		format = parts[0]
+		expression = parts[1]
+	}
+
+	switch format {
+	case FormatTable, FormatJSON, FormatYAML:
+		if len(expression) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, spec)
+		}
+	case FormatJSONPath, FormatGoTemplate:
+		if len(expression) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrEmptyExpression, format)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	p := &Printer{
+		format:     format,
+		expression: expression,
+	}
+
+	return p, nil
+}
+
+func (p *Printer) GetFormat() string {
+	return p.format
+}
+
+func (p *Printer) IsTable() bool {
+	return p.format == FormatTable
+}
+
+// Print writes object to writer with machine-readable format. Objects will be
+// converted to generic JSON data first, so field names always follow JSON tags.
+func (p *Printer) Print(w io.Writer, obj interface{}) error {
+
+	data, err := toGeneric(obj)
+	if err != nil {
+		return err
+	}
+
+	switch p.format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case FormatYAML:
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(out)
+		return err
+	case FormatJSONPath:
+		return p.printJSONPath(w, data)
+	case FormatGoTemplate:
+		return p.printGoTemplate(w, data)
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, p.format)
+}
+
+func (p *Printer) printJSONPath(w io.Writer, data interface{}) error {
+
+	var buf bytes.Buffer
+	err := executeJSONPath(&buf, p.expression, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, buf.String())
+	return err
+}
+
+func (p *Printer) printGoTemplate(w io.Writer, data interface{}) error {
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(p.expression)
+	if err != nil {
+		return fmt.Errorf("invalid go-template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, buf.String())
+	return err
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
+	},
+	"join": func(sep string, v []interface{}) string {
+		s := make([]string, len(v))
+		for i, e := range v {
+			s[i] = fmt.Sprint(e)
+		}
+
+		return strings.Join(s, sep)
+	},
+}
+
+func toGeneric(obj interface{}) (interface{}, error) {
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep numbers as they are to avoid losing precision of large counters
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	err = dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
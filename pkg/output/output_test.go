@@ -0,0 +1,206 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type testRule struct {
+	Name    string `json:"name"`
+	Event   string `json:"event"`
+	Enabled bool   `json:"enabled"`
+	Count   uint64 `json:"count"`
+}
+
+type testProduct struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Rules  []testRule        `json:"rules"`
+}
+
+var testData = &testProduct{
+	Name:   "accounts",
+	Labels: map[string]string{"team": "core", "env": "prod"},
+	Rules: []testRule{
+		{Name: "create", Event: "accountCreated", Enabled: true, Count: 18446744073709551615},
+		{Name: "update", Event: "accountUpdated", Enabled: false, Count: 10},
+		{Name: "delete", Event: "accountDeleted", Enabled: true, Count: 3},
+	},
+}
+
+func TestNew(t *testing.T) {
+
+	tests := []struct {
+		spec   string
+		format string
+		err    error
+	}{
+		{"", FormatTable, nil},
+		{"table", FormatTable, nil},
+		{"json", FormatJSON, nil},
+		{"yaml", FormatYAML, nil},
+		{"jsonpath={.name}", FormatJSONPath, nil},
+		{"go-template={{.name}}", FormatGoTemplate, nil},
+		{"json={.name}", "", ErrUnsupportedFormat},
+		{"xml", "", ErrUnsupportedFormat},
+		{"jsonpath", "", ErrEmptyExpression},
+		{"go-template=", "", ErrEmptyExpression},
+	}
+
+	for _, tt := range tests {
+
+		p, err := New(tt.spec)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("New(%q): expected %v, got %v", tt.spec, tt.err, err)
+			}
+
+			continue
+		}
+
+		if err != nil || p.GetFormat() != tt.format {
+			t.Errorf("New(%q) = %v, %v", tt.spec, p, err)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"json", "{\n  \"labels\": {\n    \"env\": \"prod\",\n    \"team\": \"core\"\n  },\n  \"name\": \"accounts\",\n  \"rules\": [\n" +
+			"    {\n      \"count\": 18446744073709551615,\n      \"enabled\": true,\n      \"event\": \"accountCreated\",\n      \"name\": \"create\"\n    },\n" +
+			"    {\n      \"count\": 10,\n      \"enabled\": false,\n      \"event\": \"accountUpdated\",\n      \"name\": \"update\"\n    },\n" +
+			"    {\n      \"count\": 3,\n      \"enabled\": true,\n      \"event\": \"accountDeleted\",\n      \"name\": \"delete\"\n    }\n  ]\n}\n"},
+
+		// Field names follow JSON tags
+		{"go-template={{.name}}: {{range .rules}}{{.name}} {{end}}", "accounts: create update delete \n"},
+		{"go-template={{json .labels}}", "{\"env\":\"prod\",\"team\":\"core\"}\n"},
+		{"go-template={{index .rules 0 \"count\"}}", "18446744073709551615\n"},
+	}
+
+	for _, tt := range tests {
+
+		p, err := New(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := p.Print(&buf, testData); err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+
+		if buf.String() != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.spec, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"{.name}", "accounts"},
+		{"name: {.name}", "name: accounts"},
+		{"{$.name}", "accounts"},
+		{"{['name']}", "accounts"},
+		{"{.labels.team}", "core"},
+		{"{.labels}", `{"env":"prod","team":"core"}`},
+		{"{.labels.*}", "prod core"},
+		{"{.rules[0].name}", "create"},
+		{"{.rules[-1].name}", "delete"},
+		{"{.rules[*].name}", "create update delete"},
+		{"{.rules[1:].name}", "update delete"},
+		{"{.rules[:2].event}", "accountCreated accountUpdated"},
+		{"{.rules[0].count}", "18446744073709551615"},
+		{"{.rules[0].enabled}", "true"},
+		{"{..event}", "accountCreated accountUpdated accountDeleted"},
+		{"{.rules[?(@.enabled)].name}", "create update delete"},
+		{"{.rules[?(@.enabled == true)].name}", "create delete"},
+		{"{.rules[?(@.count < 10)].name}", "delete"},
+		{"{.rules[?(@.count >= 10)].name}", "create update"},
+		{"{.rules[?(@.name == 'update')].event}", "accountUpdated"},
+		{`{.rules[?(@.name != "update")].event}`, "accountCreated accountDeleted"},
+		{"{.rules[?(@.enabled == false)].name}", "update"},
+		{`{range .rules[*]}{.name}{"\t"}{.enabled}{"\n"}{end}`, "create\ttrue\nupdate\tfalse\ndelete\ttrue\n"},
+		{`{range .rules[*]}[{.name}]{end}`, "[create][update][delete]"},
+		{`{range .rules[?(@.enabled == true)]}{.name}={.event}{" "}{end}`, "create=accountCreated delete=accountDeleted "},
+		{"{.rules[0:3:2].name}", "create delete"},
+		{`{"{}"}`, "{}"},
+
+		// Missing keys result in nothing
+		{"{.missing}", ""},
+		{"{.name.first}", ""},
+		{"{range .missing}{.name}{end}", ""},
+	}
+
+	for _, tt := range tests {
+
+		p, err := New("jsonpath=" + tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := p.Print(&buf, testData); err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+
+		if got := buf.String(); got != tt.want+"\n" {
+			t.Errorf("%s = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPathList(t *testing.T) {
+
+	p, err := New(`jsonpath={range [*]}{.name}{"\n"}{end}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.Print(&buf, testData.Rules); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "create\nupdate\ndelete\n\n" {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestInvalidJSONPath(t *testing.T) {
+
+	tests := []string{
+		"{.name",
+		"{.name}{end}",
+		"{.rules[}",
+		"{.rules[a]}",
+		"{.rules[10].name}",
+		"{.rules[1:2:3:4]}",
+		"{.rules[?(@.name == x)]}",
+		`{"unterminated}`,
+		"{name}",
+	}
+
+	for _, expr := range tests {
+
+		p, err := New("jsonpath=" + expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := p.Print(&buf, testData); !errors.Is(err, ErrInvalidJSONPath) {
+			t.Errorf("%s: expected ErrInvalidJSONPath, got %v (%q)", expr, err, buf.String())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package product
+
+import (
+	"fmt"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+)
+
+// Client calls product APIs with connection of CLI. Requests and replies are
+// the same as product client of Gravity SDK.
+type Client struct {
+	client *connector.Client
+	domain string
+}
+
+func NewClient(client *connector.Client, domain string) *Client {
+	return &Client{
+		client: client,
+		domain: domain,
+	}
+}
+
+func (c *Client) call(api string, req interface{}, reply interface{}) error {
+	return c.client.Call(fmt.Sprintf(product_sdk.ProductAPI+".%s", c.domain, api), req, reply)
+}
+
+func (c *Client) CreateProduct(setting *product_sdk.ProductSetting) (*product_sdk.ProductSetting, error) {
+
+	if len(setting.Name) == 0 {
+		return nil, product_sdk.ErrInvalidProductName
+	}
+
+	if len(setting.Stream) == 0 {
+		setting.Stream = fmt.Sprintf(product_sdk.ProductEventStream, c.domain, setting.Name)
+	}
+
+	reply := &product_sdk.CreateProductReply{}
+	err := c.call("CREATE", &product_sdk.CreateProductRequest{
+		Setting: setting,
+	}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Setting, nil
+}
+
+func (c *Client) DeleteProduct(name string) error {
+	return c.call("DELETE", &product_sdk.DeleteProductRequest{
+		Name: name,
+	}, &product_sdk.DeleteProductReply{})
+}
+
+func (c *Client) UpdateProduct(name string, setting *product_sdk.ProductSetting) (*product_sdk.ProductSetting, error) {
+
+	reply := &product_sdk.UpdateProductReply{}
+	err := c.call("UPDATE", &product_sdk.UpdateProductRequest{
+		Name:    name,
+		Setting: setting,
+	}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Setting, nil
+}
+
+// PurgeProduct removes all events of product without deleting it
+func (c *Client) PurgeProduct(name string) error {
+	return c.call("PURGE", &product_sdk.PurgeProductRequest{
+		Name: name,
+	}, &product_sdk.PurgeProductReply{})
+}
+
+func (c *Client) GetProduct(name string) (*product_sdk.ProductInfo, error) {
+
+	reply := &product_sdk.InfoProductReply{}
+	err := c.call("INFO", &product_sdk.InfoProductRequest{
+		Name: name,
+	}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return &product_sdk.ProductInfo{
+		Setting: reply.Setting,
+		State:   reply.State,
+	}, nil
+}
+
+func (c *Client) ListProducts() ([]*product_sdk.ProductInfo, error) {
+
+	reply := &product_sdk.ListProductsReply{}
+	err := c.call("LIST", &product_sdk.ListProductsRequest{}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Products, nil
+}
@@ -2,11 +2,9 @@ package product
 
 import (
 	"context"
-	"errors"
 
 	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
-	"github.com/BrobridgeOrg/gravity-sdk/v2/product"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
@@ -16,7 +14,7 @@ var logger *zap.Logger
 type Product struct {
 	config        *configs.Config
 	connector     *connector.Connector
-	productClient *product.ProductClient
+	productClient *Client
 }
 
 func New(lifecycle fx.Lifecycle, config *configs.Config, l *zap.Logger, c *connector.Connector) *Product {
@@ -31,18 +29,7 @@ func New(lifecycle fx.Lifecycle, config *configs.Config, l *zap.Logger, c *conne
 	lifecycle.Append(
 		fx.Hook{
 			OnStart: func(context.Context) error {
-				pcOpts := product.NewOptions()
-				pcOpts.Domain = c.GetDomain()
-				productClient := product.NewProductClient(
-					c.GetClient(),
-					pcOpts,
-				)
-
-				if productClient == nil {
-					return errors.New("Failed to create product client")
-				}
-
-				p.productClient = productClient
+				p.productClient = NewClient(c.GetClient(), c.GetDomain())
 
 				return nil
 			},
@@ -55,6 +42,6 @@ func New(lifecycle fx.Lifecycle, config *configs.Config, l *zap.Logger, c *conne
 	return p
 }
 
-func (p *Product) GetClient() *product.ProductClient {
+func (p *Product) GetClient() *Client {
 	return p.productClient
 }
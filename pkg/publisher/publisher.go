@@ -0,0 +1,239 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	DomainEventSubject = "$GVT.%s.EVENT.%s"
+
+	DefaultMaxInflight     = 1024
+	DefaultCompleteTimeout = 30 * time.Second
+)
+
+// message is domain event in the same format as adapter of Gravity SDK
+type message struct {
+	EventName string `json:"event"`
+	Payload   []byte `json:"payload"`
+}
+
+// NewMsg returns message of domain event with metadata in headers
+func NewMsg(domain string, event string, payload []byte, meta map[string]string) *nats.Msg {
+
+	data, _ := json.Marshal(&message{
+		EventName: event,
+		Payload:   payload,
+	})
+
+	msg := nats.NewMsg(fmt.Sprintf(DomainEventSubject, domain, event))
+	msg.Data = data
+
+	for k, v := range meta {
+		msg.Header.Add(k, v)
+	}
+
+	return msg
+}
+
+type Options struct {
+	Domain      string
+	MaxInflight int
+
+	// Rate limits messages per second, zero means unlimited
+	Rate float64
+
+	// ErrorHandler will be called when failed to publish message
+	ErrorHandler func(event string, err error)
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Domain:       "default",
+		MaxInflight:  DefaultMaxInflight,
+		ErrorHandler: func(string, error) {},
+	}
+}
+
+type Stats struct {
+	Published uint64
+	Failed    uint64
+}
+
+type pending struct {
+	event  string
+	future nats.PubAckFuture
+}
+
+// Publisher publishes domain events asynchronously with a bounded number of
+// messages waiting for acknowledgement.
+type Publisher struct {
+	js        nats.JetStreamContext
+	options   *Options
+	pending   chan *pending
+	abort     chan struct{}
+	wg        sync.WaitGroup
+	published uint64
+	failed    uint64
+	inflight  sync.WaitGroup
+	closeOnce sync.Once
+
+	// Rate limiting
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func New(client *connector.Client, options *Options) (*Publisher, error) {
+
+	js, err := client.GetJetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	maxInflight := options.MaxInflight
+	if maxInflight <= 0 {
+		maxInflight = DefaultMaxInflight
+	}
+
+	p := &Publisher{
+		js:      js,
+		options: options,
+		pending: make(chan *pending, maxInflight),
+		abort:   make(chan struct{}),
+	}
+
+	if options.Rate > 0 {
+		p.interval = time.Duration(float64(time.Second) / options.Rate)
+	}
+
+	p.wg.Add(1)
+	go p.collect()
+
+	return p, nil
+}
+
+func (p *Publisher) collect() {
+
+	defer p.wg.Done()
+
+	for m := range p.pending {
+		select {
+		case <-m.future.Ok():
+			atomic.AddUint64(&p.published, 1)
+		case err := <-m.future.Err():
+			p.fail(m.event, err)
+		case <-p.abort:
+			p.fail(m.event, nats.ErrTimeout)
+		}
+
+		p.inflight.Done()
+	}
+}
+
+func (p *Publisher) fail(event string, err error) {
+
+	atomic.AddUint64(&p.failed, 1)
+
+	if p.options.ErrorHandler != nil {
+		p.options.ErrorHandler(event, err)
+	}
+}
+
+// Publish sends event asynchronously. It blocks if there are too many messages
+// waiting for acknowledgement.
+func (p *Publisher) Publish(event string, payload []byte, meta map[string]string) error {
+
+	p.wait()
+
+	future, err := p.js.PublishMsgAsync(NewMsg(p.options.Domain, event, payload, meta))
+	if err != nil {
+		p.fail(event, err)
+		return err
+	}
+
+	p.inflight.Add(1)
+	p.pending <- &pending{
+		event:  event,
+		future: future,
+	}
+
+	return nil
+}
+
+// wait blocks until next message is allowed to be sent by rate limit
+func (p *Publisher) wait() {
+
+	if p.interval == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Schedule is absolute so that oversleeping will not slow down the rate,
+	// but it won't catch up more than one second after a stall.
+	now := time.Now()
+	if p.next.Before(now.Add(-time.Second)) {
+		p.next = now
+	}
+
+	d := p.next.Sub(now)
+	p.next = p.next.Add(p.interval)
+
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// Flush waits until all published messages are acknowledged or failed.
+func (p *Publisher) Flush(timeout time.Duration) error {
+
+	select {
+	case <-p.js.PublishAsyncComplete():
+	case <-time.After(timeout):
+		return nats.ErrTimeout
+	}
+
+	p.inflight.Wait()
+
+	return nil
+}
+
+// Fail records a message which cannot be published, like invalid input.
+func (p *Publisher) Fail(event string, err error) {
+	p.fail(event, err)
+}
+
+// Close waits for all pending messages and returns statistics.
+func (p *Publisher) Close() Stats {
+
+	p.closeOnce.Do(func() {
+
+		close(p.pending)
+
+		// Messages which are not acknowledged in time are treated as failures
+		select {
+		case <-p.js.PublishAsyncComplete():
+		case <-time.After(DefaultCompleteTimeout):
+			close(p.abort)
+		}
+
+		p.wg.Wait()
+	})
+
+	return p.Stats()
+}
+
+func (p *Publisher) Stats() Stats {
+	return Stats{
+		Published: atomic.LoadUint64(&p.published),
+		Failed:    atomic.LoadUint64(&p.failed),
+	}
+}
@@ -0,0 +1,205 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/itchyny/gojq"
+)
+
+// Query is a compiled jq expression
+type Query struct {
+	expr string
+	code *gojq.Code
+}
+
+func Compile(expr string) (*Query, error) {
+
+	q, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression \"%s\": %w", expr, err)
+	}
+
+	code, err := gojq.Compile(q)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression \"%s\": %w", expr, err)
+	}
+
+	return &Query{
+		expr: expr,
+		code: code,
+	}, nil
+}
+
+// Run evaluates expression and returns all outputs
+func (q *Query) Run(v interface{}) ([]interface{}, error) {
+
+	input, err := Normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.run(input)
+}
+
+func (q *Query) run(input interface{}) ([]interface{}, error) {
+
+	results := make([]interface{}, 0, 1)
+
+	iter := q.code.Run(input)
+	for {
+		r, ok := iter.Next()
+		if !ok {
+			break
+		}
+
+		if err, ok := r.(error); ok {
+			return nil, fmt.Errorf("failed to evaluate \"%s\": %w", q.expr, err)
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Match evaluates expression as condition. It's true if any output is
+// neither false nor null.
+func (q *Query) Match(v interface{}) (bool, error) {
+
+	input, err := Normalize(v)
+	if err != nil {
+		return false, err
+	}
+
+	return q.match(input)
+}
+
+func (q *Query) match(input interface{}) (bool, error) {
+
+	results, err := q.run(input)
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range results {
+		if r != nil && r != false {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Normalize converts value to types which can be handled by jq, like
+// structs, typed maps and numbers.
+func Normalize(v interface{}) (interface{}, error) {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var obj interface{}
+	err = dec.Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeNumbers(obj), nil
+}
+
+func normalizeNumbers(v interface{}) interface{} {
+
+	switch d := v.(type) {
+	case map[string]interface{}:
+		for k, val := range d {
+			d[k] = normalizeNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range d {
+			d[i] = normalizeNumbers(val)
+		}
+	case json.Number:
+		if i, err := d.Int64(); err == nil {
+			return int(i)
+		}
+
+		// Big integers like uint64
+		if b, ok := new(big.Int).SetString(d.String(), 10); ok {
+			return b
+		}
+
+		f, _ := d.Float64()
+		return f
+	}
+
+	return v
+}
+
+// Projection filters values with a condition and projects them with an
+// expression, it passes through values if expressions are empty.
+type Projection struct {
+	filter *Query
+	sel    *Query
+}
+
+func NewProjection(filter string, sel string) (*Projection, error) {
+
+	p := &Projection{}
+
+	if len(filter) > 0 {
+		q, err := Compile(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		p.filter = q
+	}
+
+	if len(sel) > 0 {
+		q, err := Compile(sel)
+		if err != nil {
+			return nil, err
+		}
+
+		p.sel = q
+	}
+
+	return p, nil
+}
+
+// Apply returns values to be output, it's empty if value was filtered out
+func (p *Projection) Apply(v interface{}) ([]interface{}, error) {
+
+	if p.filter == nil && p.sel == nil {
+		return []interface{}{v}, nil
+	}
+
+	input, err := Normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.filter != nil {
+		ok, err := p.filter.match(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, nil
+		}
+	}
+
+	if p.sel == nil {
+		return []interface{}{v}, nil
+	}
+
+	return p.sel.run(input)
+}
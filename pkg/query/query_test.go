@@ -0,0 +1,197 @@
+package query
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAccount struct {
+	ID      uint64                 `json:"id"`
+	Name    string                 `json:"name"`
+	Balance float64                `json:"balance"`
+	Tags    []string               `json:"tags"`
+	Address map[string]interface{} `json:"address"`
+	Created time.Time              `json:"created"`
+}
+
+var testRecord = &testAccount{
+	ID:      18446744073709551615,
+	Name:    "fred",
+	Balance: 12.5,
+	Tags:    []string{"vip", "early"},
+	Address: map[string]interface{}{"city": "Taipei"},
+	Created: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+}
+
+func TestCompile(t *testing.T) {
+
+	tests := []struct {
+		expr string
+		ok   bool
+	}{
+		{".id", true},
+		{"{id, name}", true},
+		{"select(.id > 1)", true},
+		{".id ==", false},
+		{"undefined_function(1)", false},
+	}
+
+	for _, tt := range tests {
+		if _, err := Compile(tt.expr); tt.ok != (err == nil) {
+			t.Errorf("Compile(%s): unexpected result %v", tt.expr, err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+
+	maxUint64, _ := new(big.Int).SetString("18446744073709551615", 10)
+
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{".name", []interface{}{"fred"}},
+		{".id", []interface{}{maxUint64}},
+		{".balance * 2", []interface{}{25.0}},
+		{".tags[]", []interface{}{"vip", "early"}},
+		{".tags | length", []interface{}{2}},
+		{".address.city", []interface{}{"Taipei"}},
+		{".created", []interface{}{"2024-03-01T00:00:00Z"}},
+		{"{name, city: .address.city}", []interface{}{map[string]interface{}{"name": "fred", "city": "Taipei"}}},
+		{".missing", []interface{}{nil}},
+		{"empty", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+
+		q, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := q.Run(testRecord)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+
+	// Runtime errors are reported
+	q, _ := Compile(".name + 1")
+	if _, err := q.Run(testRecord); err == nil {
+		t.Errorf("error of expression was not reported")
+	}
+}
+
+func TestMatch(t *testing.T) {
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{".name == \"fred\"", true},
+		{".name == \"wilma\"", false},
+		{".id > 4294967296", true},
+		{".balance < 10", false},
+		{".tags | index(\"vip\")", true},
+		{".missing", false},
+		{"false, null", false},
+		{"false, 0", true},
+		{"empty", false},
+	}
+
+	for _, tt := range tests {
+
+		q, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		match, err := q.Match(testRecord)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+
+		if match != tt.match {
+			t.Errorf("Match(%s) = %v", tt.expr, match)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+
+	v, err := Normalize(map[string]interface{}{
+		"int":    int64(-3),
+		"uint":   uint64(18446744073709551615),
+		"float":  1.5,
+		"nested": []interface{}{json.Number("7")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxUint64, _ := new(big.Int).SetString("18446744073709551615", 10)
+	want := map[string]interface{}{
+		"int":    -3,
+		"uint":   maxUint64,
+		"float":  1.5,
+		"nested": []interface{}{7},
+	}
+
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Normalize = %#v, want %#v", v, want)
+	}
+}
+
+func TestProjection(t *testing.T) {
+
+	record := map[string]interface{}{"id": uint64(4), "name": "fred"}
+
+	tests := []struct {
+		filter string
+		sel    string
+		want   []interface{}
+	}{
+		{"", "", []interface{}{record}},
+		{".id == 4", "", []interface{}{record}},
+		{".id == 5", "", nil},
+		{"", "{name}", []interface{}{map[string]interface{}{"name": "fred"}}},
+		{".id == 4", ".name, .id", []interface{}{"fred", 4}},
+		{".id == 5", ".name", nil},
+	}
+
+	for _, tt := range tests {
+
+		p, err := NewProjection(tt.filter, tt.sel)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := p.Apply(record)
+		if err != nil {
+			t.Errorf("filter %q, select %q: %v", tt.filter, tt.sel, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %q, select %q = %#v, want %#v", tt.filter, tt.sel, got, tt.want)
+		}
+	}
+
+	if _, err := NewProjection(".id ==", ""); err == nil {
+		t.Errorf("invalid filter was accepted")
+	}
+
+	if _, err := NewProjection("", "{"); err == nil {
+		t.Errorf("invalid select was accepted")
+	}
+}
@@ -0,0 +1,454 @@
+package recorddiff
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+type Side int
+
+const (
+	SideA Side = iota
+	SideB
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// FieldChange is a difference of field, fields of map are compared with
+// dot-separated names.
+type FieldChange struct {
+	Type  ChangeType  `json:"type"`
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// Change is a record which was added to B, removed from A or changed between
+// A and B.
+type Change struct {
+	Type   ChangeType             `json:"type"`
+	Key    string                 `json:"key"`
+	Old    map[string]interface{} `json:"old,omitempty"`
+	New    map[string]interface{} `json:"new,omitempty"`
+	Fields []*FieldChange         `json:"fields,omitempty"`
+}
+
+type Summary struct {
+	Added       uint64 `json:"added"`
+	Removed     uint64 `json:"removed"`
+	Changed     uint64 `json:"changed"`
+	Unchanged   uint64 `json:"unchanged"`
+	DuplicatesA uint64 `json:"duplicatesA"`
+	DuplicatesB uint64 `json:"duplicatesB"`
+	Spilled     bool   `json:"spilled"`
+}
+
+// Differences returns number of records which are different
+func (s *Summary) Differences() uint64 {
+	return s.Added + s.Removed + s.Changed
+}
+
+type Options struct {
+
+	// Maximum number of records kept in memory, records are spilled to
+	// partition files in TempDir when it's exceeded.
+	MemoryLimit int
+	Partitions  int
+	TempDir     string
+}
+
+func NewOptions() *Options {
+	return &Options{
+		MemoryLimit: 100000,
+		Partitions:  64,
+	}
+}
+
+// entry is a line of partition file
+type entry struct {
+	Key    string          `json:"k"`
+	Record json.RawMessage `json:"r"`
+}
+
+type partition struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// Differ compares two sets of records by key. Records are kept in memory
+// until MemoryLimit is exceeded, then all records are spilled to files which
+// are partitioned by hash of key, so that only a partition of both sets is
+// loaded at a time for comparison.
+type Differ struct {
+	options    *Options
+	records    [2]map[string]json.RawMessage
+	duplicates [2]uint64
+	count      int
+	dir        string
+	partitions [2][]*partition
+}
+
+func New(options *Options) *Differ {
+
+	if options.Partitions <= 0 {
+		options.Partitions = 1
+	}
+
+	return &Differ{
+		options: options,
+		records: [2]map[string]json.RawMessage{
+			make(map[string]json.RawMessage),
+			make(map[string]json.RawMessage),
+		},
+	}
+}
+
+// Add adds record of side, the last record wins if there are records with the
+// same key.
+func (d *Differ) Add(side Side, key string, record interface{}) error {
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if len(d.dir) > 0 {
+		return d.write(side, key, data)
+	}
+
+	if _, ok := d.records[side][key]; ok {
+		d.duplicates[side]++
+	} else {
+		d.count++
+	}
+
+	d.records[side][key] = data
+
+	if d.options.MemoryLimit > 0 && d.count > d.options.MemoryLimit {
+		return d.spill()
+	}
+
+	return nil
+}
+
+func (d *Differ) spill() error {
+
+	dir, err := os.MkdirTemp(d.options.TempDir, "gravity-diff-*")
+	if err != nil {
+		return err
+	}
+
+	d.dir = dir
+
+	for side := range d.partitions {
+		for i := 0; i < d.options.Partitions; i++ {
+
+			f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d-%d.ndjson", side, i)))
+			if err != nil {
+				return err
+			}
+
+			d.partitions[side] = append(d.partitions[side], &partition{
+				file:   f,
+				writer: bufio.NewWriter(f),
+			})
+		}
+	}
+
+	for side, records := range d.records {
+		for key, data := range records {
+			if err := d.write(Side(side), key, data); err != nil {
+				return err
+			}
+		}
+
+		d.records[side] = nil
+	}
+
+	return nil
+}
+
+func (d *Differ) write(side Side, key string, data json.RawMessage) error {
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	p := d.partitions[side][h.Sum32()%uint32(len(d.partitions[side]))]
+
+	line, err := json.Marshal(&entry{
+		Key:    key,
+		Record: data,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.writer.Write(line); err != nil {
+		return err
+	}
+
+	return p.writer.WriteByte('\n')
+}
+
+func (d *Differ) load(side Side, p *partition) (map[string]json.RawMessage, error) {
+
+	if _, err := p.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]json.RawMessage)
+	r := bufio.NewReader(p.file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var e entry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return nil, err
+			}
+
+			if _, ok := records[e.Key]; ok {
+				d.duplicates[side]++
+			}
+
+			records[e.Key] = e.Record
+		}
+
+		if err == io.EOF {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Diff compares records of A with records of B, changes are emitted in order
+// of keys, or in order of keys in each partition if records were spilled.
+func (d *Differ) Diff(ctx context.Context, emit func(change *Change) error) (*Summary, error) {
+
+	summary := &Summary{}
+
+	if len(d.dir) == 0 {
+		if err := compare(ctx, d.records[SideA], d.records[SideB], summary, emit); err != nil {
+			return nil, err
+		}
+	} else {
+
+		summary.Spilled = true
+
+		for side := range d.partitions {
+			for _, p := range d.partitions[side] {
+				if err := p.writer.Flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for i := range d.partitions[SideA] {
+
+			a, err := d.load(SideA, d.partitions[SideA][i])
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := d.load(SideB, d.partitions[SideB][i])
+			if err != nil {
+				return nil, err
+			}
+
+			if err := compare(ctx, a, b, summary, emit); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	summary.DuplicatesA = d.duplicates[SideA]
+	summary.DuplicatesB = d.duplicates[SideB]
+
+	return summary, nil
+}
+
+// Close removes partition files
+func (d *Differ) Close() error {
+
+	for side := range d.partitions {
+		for _, p := range d.partitions[side] {
+			p.file.Close()
+		}
+	}
+
+	if len(d.dir) == 0 {
+		return nil
+	}
+
+	return os.RemoveAll(d.dir)
+}
+
+func compare(ctx context.Context, a map[string]json.RawMessage, b map[string]json.RawMessage, summary *Summary, emit func(change *Change) error) error {
+
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		oldData, inA := a[key]
+		newData, inB := b[key]
+
+		// Identical records
+		if inA && inB && bytes.Equal(oldData, newData) {
+			summary.Unchanged++
+			continue
+		}
+
+		var oldRecord map[string]interface{}
+		if inA {
+			if err := decode(oldData, &oldRecord); err != nil {
+				return err
+			}
+		}
+
+		var newRecord map[string]interface{}
+		if inB {
+			if err := decode(newData, &newRecord); err != nil {
+				return err
+			}
+		}
+
+		change := &Change{
+			Key: key,
+		}
+
+		switch {
+		case !inA:
+			summary.Added++
+			change.Type = ChangeAdded
+			change.New = newRecord
+		case !inB:
+			summary.Removed++
+			change.Type = ChangeRemoved
+			change.Old = oldRecord
+		default:
+			compareFields("", oldRecord, newRecord, &change.Fields)
+			if len(change.Fields) == 0 {
+				summary.Unchanged++
+				continue
+			}
+
+			summary.Changed++
+			change.Type = ChangeChanged
+		}
+
+		if err := emit(change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decode(data []byte, v interface{}) error {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// compareFields walks into maps for reporting nested differences
+func compareFields(prefix string, a map[string]interface{}, b map[string]interface{}, changes *[]*FieldChange) {
+
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		field := name
+		if len(prefix) > 0 {
+			field = prefix + "." + name
+		}
+
+		av, inA := a[name]
+		bv, inB := b[name]
+
+		switch {
+		case !inA:
+			*changes = append(*changes, &FieldChange{Type: ChangeAdded, Field: field, New: bv})
+			continue
+		case !inB:
+			*changes = append(*changes, &FieldChange{Type: ChangeRemoved, Field: field, Old: av})
+			continue
+		}
+
+		am, aok := av.(map[string]interface{})
+		bm, bok := bv.(map[string]interface{})
+		if aok && bok {
+			compareFields(field, am, bm, changes)
+			continue
+		}
+
+		if !equal(av, bv) {
+			*changes = append(*changes, &FieldChange{Type: ChangeChanged, Field: field, Old: av, New: bv})
+		}
+	}
+}
+
+// equal compares values of JSON, numbers are equal if they have the same value
+// in different representations like 1 and 1.0.
+func equal(a interface{}, b interface{}) bool {
+
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		if an == bn {
+			return true
+		}
+
+		// Precision of float64 is not enough for large integers
+		af, _, aerr := big.ParseFloat(string(an), 10, 256, big.ToNearestEven)
+		bf, _, berr := big.ParseFloat(string(bn), 10, 256, big.ToNearestEven)
+
+		return aerr == nil && berr == nil && af.Cmp(bf) == 0
+	}
+
+	return reflect.DeepEqual(a, b)
+}
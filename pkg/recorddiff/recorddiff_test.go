@@ -0,0 +1,236 @@
+package recorddiff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type testRecord map[string]interface{}
+
+// addRecords adds two sets of records with every kind of change
+func addRecords(t *testing.T, d *Differ, n int) {
+
+	for i := 0; i < n; i++ {
+
+		key := fmt.Sprintf("%05d", i)
+		record := testRecord{
+			"id":   i,
+			"name": fmt.Sprintf("user%d", i),
+			"address": map[string]interface{}{
+				"city": "Taipei",
+				"zip":  "100",
+			},
+		}
+
+		// Removed
+		if i%7 != 0 {
+			if err := d.Add(SideA, key, record); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Duplicates, the last record wins
+		if i%11 == 0 {
+			if err := d.Add(SideA, key, testRecord{"id": -1}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := d.Add(SideA, key, record); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Added
+		if i%5 == 0 {
+			continue
+		}
+
+		changed := testRecord{}
+		for k, v := range record {
+			changed[k] = v
+		}
+
+		switch i % 3 {
+		case 1:
+			changed["name"] = fmt.Sprintf("User %d", i)
+		case 2:
+			changed["address"] = map[string]interface{}{"city": "Tainan"}
+			changed["email"] = "user@example.com"
+		}
+
+		if i%13 == 0 {
+			if err := d.Add(SideB, key, testRecord{"id": -1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := d.Add(SideB, key, changed); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func runDiff(t *testing.T, options *Options, n int) (*Summary, []*Change) {
+
+	d := New(options)
+	defer d.Close()
+
+	addRecords(t, d, n)
+
+	changes := make([]*Change, 0)
+	summary, err := d.Diff(context.Background(), func(change *Change) error {
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return summary, changes
+}
+
+func TestSpilledDiff(t *testing.T) {
+
+	const n = 2000
+
+	memSummary, memChanges := runDiff(t, &Options{MemoryLimit: 0, Partitions: 8}, n)
+	if memSummary.Spilled {
+		t.Fatalf("records were spilled without memory limit")
+	}
+
+	// Changes are emitted in order of keys
+	if !sort.SliceIsSorted(memChanges, func(i, j int) bool { return memChanges[i].Key < memChanges[j].Key }) {
+		t.Errorf("changes are not sorted by key")
+	}
+
+	// Summary of every kind of change
+	var added, removed, changed uint64
+	for i := 0; i < n; i++ {
+		switch {
+		case i%7 == 0 && i%5 == 0 && i%11 != 0:
+		case i%5 == 0:
+			removed++
+		case i%7 == 0 && i%11 != 0:
+			added++
+		case i%3 != 0:
+			changed++
+		}
+	}
+
+	if memSummary.Added != added || memSummary.Removed != removed || memSummary.Changed != changed {
+		t.Errorf("summary = %+v, want %d added, %d removed, %d changed", memSummary, added, removed, changed)
+	}
+
+	for _, limit := range []int{1, 100, 3000} {
+
+		for _, partitions := range []int{1, 7} {
+
+			tempDir := t.TempDir()
+			summary, changes := runDiff(t, &Options{MemoryLimit: limit, Partitions: partitions, TempDir: tempDir}, n)
+
+			if !summary.Spilled {
+				if limit < n {
+					t.Errorf("limit %d: records were not spilled", limit)
+				}
+			} else if limit >= 2*n {
+				t.Errorf("limit %d: records were spilled", limit)
+			}
+
+			spilled := summary.Spilled
+			summary.Spilled = memSummary.Spilled
+			if *summary != *memSummary {
+				t.Errorf("limit %d, %d partitions: summary = %+v, want %+v", limit, partitions, summary, memSummary)
+			}
+
+			// Spilled changes are sorted in each partition only
+			sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+			if !reflect.DeepEqual(changes, memChanges) {
+				a, _ := json.Marshal(changes)
+				b, _ := json.Marshal(memChanges)
+				t.Errorf("limit %d, %d partitions: changes are different\n%.500s\n%.500s", limit, partitions, a, b)
+			}
+
+			// Partition files are removed
+			entries, _ := os.ReadDir(tempDir)
+			if spilled && len(entries) != 0 {
+				t.Errorf("limit %d: %d files were left", limit, len(entries))
+			}
+		}
+	}
+}
+
+func TestCompareFields(t *testing.T) {
+
+	tests := []struct {
+		a    string
+		b    string
+		want []*FieldChange
+	}{
+		{`{"id":1}`, `{"id":1.0}`, []*FieldChange{}},
+		{`{"id":1e2}`, `{"id":100}`, []*FieldChange{}},
+		{
+			`{"id":18446744073709551615}`,
+			`{"id":18446744073709551614}`,
+			[]*FieldChange{{ChangeChanged, "id", json.Number("18446744073709551615"), json.Number("18446744073709551614")}},
+		},
+		{
+			`{"a":{"b":{"c":1,"d":2}},"x":null}`,
+			`{"a":{"b":{"c":2,"e":3}}}`,
+			[]*FieldChange{
+				{ChangeChanged, "a.b.c", json.Number("1"), json.Number("2")},
+				{ChangeRemoved, "a.b.d", json.Number("2"), nil},
+				{ChangeAdded, "a.b.e", nil, json.Number("3")},
+				{ChangeRemoved, "x", nil, nil},
+			},
+		},
+		{
+			`{"tags":["a","b"],"m":{"k":1}}`,
+			`{"tags":["a"],"m":"k"}`,
+			[]*FieldChange{
+				{ChangeChanged, "m", map[string]interface{}{"k": json.Number("1")}, "k"},
+				{ChangeChanged, "tags", []interface{}{"a", "b"}, []interface{}{"a"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+
+		var a, b map[string]interface{}
+		if err := decode([]byte(tt.a), &a); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := decode([]byte(tt.b), &b); err != nil {
+			t.Fatal(err)
+		}
+
+		changes := make([]*FieldChange, 0)
+		compareFields("", a, b, &changes)
+
+		if !reflect.DeepEqual(changes, tt.want) {
+			got, _ := json.Marshal(changes)
+			want, _ := json.Marshal(tt.want)
+			t.Errorf("compareFields(%s, %s) = %s, want %s", tt.a, tt.b, got, want)
+		}
+	}
+}
+
+func TestDiffCanceled(t *testing.T) {
+
+	d := New(NewOptions())
+	defer d.Close()
+
+	addRecords(t, d, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := d.Diff(ctx, func(change *Change) error { return nil }); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package recordfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+const (
+	parquetRowBuffer = 1024
+)
+
+// parquetWriter writes records to Parquet with a nullable column for each
+// field of schema. Types of columns follow product schema, time is stored in
+// microseconds of UTC, and maps, arrays and values of any type are stored in
+// JSON.
+type parquetWriter struct {
+	writer  *parquet.Writer
+	columns []Column
+	index   map[string]int
+	rows    []parquet.Row
+}
+
+func parquetNode(t string) parquet.Node {
+
+	switch t {
+	case "int":
+		return parquet.Int(64)
+	case "uint":
+		return parquet.Uint(64)
+	case "float":
+		return parquet.Leaf(parquet.DoubleType)
+	case "bool":
+		return parquet.Leaf(parquet.BooleanType)
+	case "string":
+		return parquet.String()
+	case "time":
+		return parquet.Timestamp(parquet.Microsecond)
+	case "binary":
+		return parquet.Leaf(parquet.ByteArrayType)
+	}
+
+	return parquet.JSON()
+}
+
+func parquetCodec(c sink.Compression) (compress.Codec, error) {
+
+	switch c {
+	case "", sink.CompressionNone:
+		return &parquet.Uncompressed, nil
+	case sink.CompressionGzip:
+		return &parquet.Gzip, nil
+	case sink.CompressionZstd:
+		return &parquet.Zstd, nil
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", sink.ErrUnsupportedCompression, c)
+}
+
+func newParquetWriter(w io.Writer, columns []Column, compression sink.Compression) (*parquetWriter, error) {
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: columns of parquet are taken from product schema", ErrNoSchema)
+	}
+
+	codec, err := parquetCodec(compression)
+	if err != nil {
+		return nil, err
+	}
+
+	group := make(parquet.Group, len(columns))
+	for _, c := range columns {
+		group[c.Name] = parquet.Optional(parquetNode(c.Type))
+	}
+
+	schema := parquet.NewSchema("record", group)
+
+	config, err := parquet.NewWriterConfig(schema, parquet.Compression(codec))
+	if err != nil {
+		return nil, err
+	}
+
+	pw := &parquetWriter{
+		writer:  parquet.NewWriter(w, config),
+		columns: columns,
+		index:   make(map[string]int, len(columns)),
+		rows:    make([]parquet.Row, 0, parquetRowBuffer),
+	}
+
+	// Columns of schema are sorted by name
+	for i, path := range schema.Columns() {
+		pw.index[path[0]] = i
+	}
+
+	return pw, nil
+}
+
+func (pw *parquetWriter) Write(r interface{}) error {
+
+	record, ok := r.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrNotObject, r)
+	}
+
+	row := make(parquet.Row, len(pw.columns))
+	for _, c := range pw.columns {
+
+		idx := pw.index[c.Name]
+
+		v, ok := lookup(record, c.Name)
+		if !ok || v == nil {
+			row[idx] = parquet.NullValue().Level(0, 0, idx)
+			continue
+		}
+
+		value, err := parquetValue(c.Type, v)
+		if err != nil {
+			return fmt.Errorf("failed to convert field \"%s\": %w", c.Name, err)
+		}
+
+		row[idx] = value.Level(0, 1, idx)
+	}
+
+	pw.rows = append(pw.rows, row)
+	if len(pw.rows) >= parquetRowBuffer {
+		return pw.flush()
+	}
+
+	return nil
+}
+
+func (pw *parquetWriter) flush() error {
+
+	if len(pw.rows) == 0 {
+		return nil
+	}
+
+	_, err := pw.writer.WriteRows(pw.rows)
+	pw.rows = pw.rows[:0]
+
+	return err
+}
+
+func (pw *parquetWriter) Close() error {
+
+	if err := pw.flush(); err != nil {
+		return err
+	}
+
+	return pw.writer.Close()
+}
+
+// parquetValue converts value of record to type of column
+func parquetValue(t string, v interface{}) (parquet.Value, error) {
+
+	switch t {
+	case "int":
+		n, err := toInt64(v)
+		return parquet.Int64Value(n), err
+	case "uint":
+		n, err := toUint64(v)
+		return parquet.Int64Value(int64(n)), err
+	case "float":
+		f, err := toFloat64(v)
+		return parquet.DoubleValue(f), err
+	case "bool":
+		switch d := v.(type) {
+		case bool:
+			return parquet.BooleanValue(d), nil
+		case string:
+			b, err := strconv.ParseBool(d)
+			return parquet.BooleanValue(b), err
+		}
+	case "string":
+		switch d := v.(type) {
+		case string:
+			return parquet.ByteArrayValue([]byte(d)), nil
+		case []byte:
+			return parquet.ByteArrayValue(d), nil
+		}
+
+		return parquet.ByteArrayValue([]byte(fmt.Sprint(v))), nil
+	case "time":
+		switch d := v.(type) {
+		case time.Time:
+			return parquet.Int64Value(d.UnixMicro()), nil
+		case string:
+			tm, err := time.Parse(time.RFC3339Nano, d)
+			return parquet.Int64Value(tm.UnixMicro()), err
+		}
+	case "binary":
+		switch d := v.(type) {
+		case []byte:
+			return parquet.ByteArrayValue(d), nil
+		case string:
+			return parquet.ByteArrayValue([]byte(d)), nil
+		}
+	default:
+		data, err := json.Marshal(v)
+		return parquet.ByteArrayValue(data), err
+	}
+
+	return parquet.Value{}, fmt.Errorf("unexpected %T for %s", v, t)
+}
+
+func toInt64(v interface{}) (int64, error) {
+
+	switch d := v.(type) {
+	case int64:
+		return d, nil
+	case int:
+		return int64(d), nil
+	case int32:
+		return int64(d), nil
+	case uint64:
+		if d > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", d)
+		}
+
+		return int64(d), nil
+	case uint32:
+		return int64(d), nil
+	case float64:
+		return int64(d), nil
+	case json.Number:
+		return d.Int64()
+	case string:
+		return strconv.ParseInt(d, 10, 64)
+	}
+
+	return 0, fmt.Errorf("unexpected %T for int", v)
+}
+
+func toUint64(v interface{}) (uint64, error) {
+
+	switch d := v.(type) {
+	case uint64:
+		return d, nil
+	case uint32:
+		return uint64(d), nil
+	case uint:
+		return uint64(d), nil
+	case int64:
+		if d < 0 {
+			return 0, fmt.Errorf("%d is negative", d)
+		}
+
+		return uint64(d), nil
+	case int:
+		if d < 0 {
+			return 0, fmt.Errorf("%d is negative", d)
+		}
+
+		return uint64(d), nil
+	case float64:
+		return uint64(d), nil
+	case json.Number:
+		return strconv.ParseUint(string(d), 10, 64)
+	case string:
+		return strconv.ParseUint(d, 10, 64)
+	}
+
+	return 0, fmt.Errorf("unexpected %T for uint", v)
+}
+
+func toFloat64(v interface{}) (float64, error) {
+
+	switch d := v.(type) {
+	case float64:
+		return d, nil
+	case float32:
+		return float64(d), nil
+	case int64:
+		return float64(d), nil
+	case int:
+		return float64(d), nil
+	case uint64:
+		return float64(d), nil
+	case json.Number:
+		return d.Float64()
+	case string:
+		return strconv.ParseFloat(d, 64)
+	}
+
+	return 0, fmt.Errorf("unexpected %T for float", v)
+}
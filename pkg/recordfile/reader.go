@@ -0,0 +1,51 @@
+package recordfile
+
+import (
+	"io"
+	"os"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+)
+
+// File is a record file which is decompressed by extension of file name
+type File struct {
+	io.Reader
+	file    *os.File
+	decoder io.ReadCloser
+
+	Format      Format
+	Compression sink.Compression
+}
+
+// Open opens record file like accounts.ndjson.zst, format is empty if it
+// cannot be detected.
+func Open(path string) (*File, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format, compression := DetectFormat(path)
+
+	dec, err := sink.NewDecoder(f, compression)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &File{
+		Reader:      dec,
+		file:        f,
+		decoder:     dec,
+		Format:      format,
+		Compression: compression,
+	}, nil
+}
+
+func (f *File) Close() error {
+
+	f.decoder.Close()
+
+	return f.file.Close()
+}
@@ -0,0 +1,126 @@
+package recordfile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+)
+
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatNDJSON  Format = "ndjson"
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrNoSchema          = errors.New("schema is required")
+	ErrNotObject         = errors.New("record is not an object")
+)
+
+// ParseFormat parses name of format
+func ParseFormat(name string) (Format, error) {
+
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatJSON, FormatNDJSON, FormatCSV, FormatParquet:
+		return f, nil
+	case "jsonl":
+		return FormatNDJSON, nil
+	}
+
+	return "", fmt.Errorf("%w: \"%s\" (json, ndjson, csv or parquet)", ErrUnsupportedFormat, name)
+}
+
+// DetectFormat returns format and compression by extension of file name, e.g.
+// accounts.ndjson.zst. Format is empty if it's unknown.
+func DetectFormat(path string) (Format, sink.Compression) {
+
+	compression := sink.CompressionNone
+	name := strings.ToLower(filepath.Base(path))
+	for _, c := range []sink.Compression{sink.CompressionGzip, sink.CompressionZstd} {
+		if strings.HasSuffix(name, c.Extension()) {
+			compression = c
+			name = strings.TrimSuffix(name, c.Extension())
+			break
+		}
+	}
+
+	format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+	if err != nil {
+		return "", compression
+	}
+
+	return format, compression
+}
+
+// Column is a field of records, fields of map type are flattened with
+// dot-separated names.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Columns returns sorted columns of fields in product schema
+func Columns(schema map[string]interface{}) []Column {
+
+	columns := make([]Column, 0, len(schema))
+	schemaColumns("", schema, &columns)
+
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].Name < columns[j].Name
+	})
+
+	return columns
+}
+
+func schemaColumns(prefix string, schema map[string]interface{}, columns *[]Column) {
+
+	for name, def := range schema {
+
+		if len(prefix) > 0 {
+			name = prefix + "." + name
+		}
+
+		d, _ := def.(map[string]interface{})
+		t, _ := d["type"].(string)
+		if t == "map" {
+			if fields, ok := d["fields"].(map[string]interface{}); ok {
+				schemaColumns(name, fields, columns)
+				continue
+			}
+		}
+
+		*columns = append(*columns, Column{
+			Name: name,
+			Type: t,
+		})
+	}
+}
+
+// lookup returns value of column from record
+func lookup(record map[string]interface{}, name string) (interface{}, bool) {
+
+	if v, ok := record[name]; ok {
+		return v, true
+	}
+
+	// Field of map
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+
+	m, ok := record[parts[0]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	return lookup(m, parts[1])
+}
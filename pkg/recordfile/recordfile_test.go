@@ -0,0 +1,107 @@
+package recordfile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+)
+
+func TestDetectFormat(t *testing.T) {
+
+	tests := []struct {
+		path        string
+		format      Format
+		compression sink.Compression
+	}{
+		{"accounts.json", FormatJSON, sink.CompressionNone},
+		{"accounts.ndjson", FormatNDJSON, sink.CompressionNone},
+		{"out/accounts.jsonl.gz", FormatNDJSON, sink.CompressionGzip},
+		{"ACCOUNTS.CSV.ZST", FormatCSV, sink.CompressionZstd},
+		{"accounts.parquet", FormatParquet, sink.CompressionNone},
+		{"accounts.txt.gz", "", sink.CompressionGzip},
+		{"accounts", "", sink.CompressionNone},
+		{"", "", sink.CompressionNone},
+	}
+
+	for _, tt := range tests {
+
+		format, compression := DetectFormat(tt.path)
+		if format != tt.format || compression != tt.compression {
+			t.Errorf("DetectFormat(%s) = %s, %s, want %s, %s", tt.path, format, compression, tt.format, tt.compression)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestColumns(t *testing.T) {
+
+	schema := map[string]interface{}{
+		"name": map[string]interface{}{"type": "string"},
+		"id":   map[string]interface{}{"type": "uint"},
+		"address": map[string]interface{}{
+			"type": "map",
+			"fields": map[string]interface{}{
+				"city": map[string]interface{}{"type": "string"},
+				"geo": map[string]interface{}{
+					"type": "map",
+					"fields": map[string]interface{}{
+						"lat": map[string]interface{}{"type": "float"},
+					},
+				},
+			},
+		},
+		"tags": map[string]interface{}{"type": "array"},
+		"meta": map[string]interface{}{"type": "map"},
+	}
+
+	want := []Column{
+		{"address.city", "string"},
+		{"address.geo.lat", "float"},
+		{"id", "uint"},
+		{"meta", "map"},
+		{"name", "string"},
+		{"tags", "array"},
+	}
+
+	if got := Columns(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns = %v, want %v", got, want)
+	}
+}
+
+func TestLookup(t *testing.T) {
+
+	record := map[string]interface{}{
+		"id":    1,
+		"a.b":   "dotted",
+		"a":     map[string]interface{}{"c": "nested", "d": map[string]interface{}{"e": 2}},
+		"empty": nil,
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		ok    bool
+	}{
+		{"id", 1, true},
+		{"a.b", "dotted", true},
+		{"a.c", "nested", true},
+		{"a.d.e", 2, true},
+		{"empty", nil, true},
+		{"a.x", nil, false},
+		{"id.x", nil, false},
+		{"missing", nil, false},
+	}
+
+	for _, tt := range tests {
+
+		v, ok := lookup(record, tt.name)
+		if ok != tt.ok || !reflect.DeepEqual(v, tt.value) {
+			t.Errorf("lookup(%s) = %v, %v, want %v, %v", tt.name, v, ok, tt.value, tt.ok)
+		}
+	}
+}
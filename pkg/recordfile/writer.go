@@ -0,0 +1,185 @@
+package recordfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/eventformat"
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+)
+
+type Options struct {
+	Format      Format
+	Compression sink.Compression
+
+	// Columns of CSV and Parquet, columns of CSV are taken from the first
+	// record if it's empty.
+	Columns []Column
+
+	// Header of CSV is not written when records are appended to file
+	NoHeader bool
+}
+
+// Writer writes records to file, Close must be called to finish the file but
+// the underlying writer is not closed.
+type Writer interface {
+	Write(record interface{}) error
+	Close() error
+}
+
+func NewWriter(w io.Writer, options *Options) (Writer, error) {
+
+	// Parquet compresses pages by itself
+	if options.Format == FormatParquet {
+		return newParquetWriter(w, options.Columns, options.Compression)
+	}
+
+	enc, err := sink.NewEncoder(w, options.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriter(enc)
+	base := textWriter{
+		w:   bw,
+		enc: enc,
+	}
+
+	switch options.Format {
+	case FormatJSON:
+		return &jsonWriter{textWriter: base, indent: true}, nil
+	case FormatNDJSON:
+		return &jsonWriter{textWriter: base}, nil
+	case FormatCSV:
+		return &csvWriter{textWriter: base, csv: csv.NewWriter(bw), columns: options.Columns, header: options.NoHeader}, nil
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// textWriter buffers output and finishes compressed stream on close
+type textWriter struct {
+	w   *bufio.Writer
+	enc io.WriteCloser
+}
+
+func (tw *textWriter) Close() error {
+
+	if err := tw.w.Flush(); err != nil {
+		return err
+	}
+
+	return tw.enc.Close()
+}
+
+type jsonWriter struct {
+	textWriter
+	indent bool
+}
+
+func (jw *jsonWriter) Write(record interface{}) error {
+
+	var data []byte
+	var err error
+	if jw.indent {
+		data, err = json.MarshalIndent(record, "", "  ")
+	} else {
+		data, err = json.Marshal(record)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := jw.w.Write(data); err != nil {
+		return err
+	}
+
+	return jw.w.WriteByte('\n')
+}
+
+type csvWriter struct {
+	textWriter
+	csv     *csv.Writer
+	columns []Column
+	header  bool
+}
+
+func (cw *csvWriter) Write(record interface{}) error {
+
+	if _, ok := record.(map[string]interface{}); !ok {
+		return fmt.Errorf("%w: %T", ErrNotObject, record)
+	}
+
+	fields := make(map[string]string)
+	eventformat.Flatten("", record, fields)
+
+	// Columns are taken from the first record without schema
+	if cw.columns == nil {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		cw.columns = make([]Column, len(names))
+		for i, name := range names {
+			cw.columns[i] = Column{Name: name}
+		}
+	}
+
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(cw.columns))
+	for i, c := range cw.columns {
+		row[i] = fields[c.Name]
+	}
+
+	return cw.csv.Write(row)
+}
+
+// Columns returns columns of CSV, it's nil until the first record was written
+// if columns were not specified.
+func (cw *csvWriter) Columns() []Column {
+	return cw.columns
+}
+
+func (cw *csvWriter) writeHeader() error {
+
+	if cw.header {
+		return nil
+	}
+
+	header := make([]string, len(cw.columns))
+	for i, c := range cw.columns {
+		header[i] = c.Name
+	}
+
+	cw.header = true
+
+	return cw.csv.Write(header)
+}
+
+func (cw *csvWriter) Close() error {
+
+	// Header is written even if there is no record
+	if cw.columns != nil {
+		if err := cw.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	cw.csv.Flush()
+	if err := cw.csv.Error(); err != nil {
+		return err
+	}
+
+	return cw.textWriter.Close()
+}
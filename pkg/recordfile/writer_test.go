@@ -0,0 +1,284 @@
+package recordfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/sink"
+	"github.com/parquet-go/parquet-go"
+)
+
+func testRecords(n int) []interface{} {
+
+	records := make([]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{
+			"id":   i + 1,
+			"name": "user" + strings.Repeat("x", i%3),
+			"address": map[string]interface{}{
+				"city": "Taipei",
+			},
+		}
+	}
+
+	return records
+}
+
+// writeChunks writes records to file in chunks, each chunk is written by a new
+// writer like snapshot does after every checkpoint.
+func writeChunks(t *testing.T, filename string, options Options, records []interface{}, chunk int) {
+
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for start := 0; start < len(records); start += chunk {
+
+		opts := options
+		opts.NoHeader = start > 0
+
+		w, err := NewWriter(f, &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		end := start + chunk
+		if end > len(records) {
+			end = len(records)
+		}
+
+		for _, r := range records[start:end] {
+			if err := w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Columns of CSV are taken from the first chunk
+		if c, ok := w.(interface{ Columns() []Column }); ok && options.Columns == nil {
+			options.Columns = c.Columns()
+		}
+	}
+}
+
+func readLines(t *testing.T, filename string) []string {
+
+	f, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("%s: %v", filename, err)
+	}
+
+	return lines
+}
+
+func TestNDJSONChunks(t *testing.T) {
+
+	records := testRecords(10)
+	dir := t.TempDir()
+
+	for _, name := range []string{"accounts.ndjson", "accounts.ndjson.gz", "accounts.ndjson.zst"} {
+
+		filename := filepath.Join(dir, name)
+		format, compression := DetectFormat(filename)
+
+		writeChunks(t, filename, Options{Format: format, Compression: compression}, records, 3)
+
+		// Compressed streams of chunks are concatenated
+		lines := readLines(t, filename)
+		if len(lines) != len(records) {
+			t.Errorf("%s: %d lines, want %d", name, len(lines), len(records))
+			continue
+		}
+
+		for i, line := range lines {
+
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("%s: line %d: %v", name, i+1, err)
+			}
+
+			if record["id"] != float64(i+1) {
+				t.Errorf("%s: line %d has id %v", name, i+1, record["id"])
+			}
+		}
+	}
+}
+
+func TestCSVChunks(t *testing.T) {
+
+	records := testRecords(7)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		columns []Column
+		header  string
+	}{
+		{"accounts.csv", nil, "address.city,id,name"},
+		{"accounts.csv.gz", nil, "address.city,id,name"},
+		{"schema.csv.zst", []Column{{Name: "id"}, {Name: "name"}, {Name: "email"}}, "id,name,email"},
+	}
+
+	for _, tt := range tests {
+
+		filename := filepath.Join(dir, tt.name)
+		format, compression := DetectFormat(filename)
+
+		writeChunks(t, filename, Options{Format: format, Compression: compression, Columns: tt.columns}, records, 2)
+
+		// Header is written once
+		lines := readLines(t, filename)
+		if len(lines) != len(records)+1 {
+			t.Fatalf("%s: %d lines, want %d", tt.name, len(lines), len(records)+1)
+		}
+
+		if lines[0] != tt.header {
+			t.Errorf("%s: header = %q, want %q", tt.name, lines[0], tt.header)
+		}
+
+		for _, line := range lines[1:] {
+			if strings.Contains(line, "id") {
+				t.Errorf("%s: header was repeated: %q", tt.name, line)
+			}
+		}
+	}
+}
+
+func TestCSVHeaderWithoutRecords(t *testing.T) {
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, &Options{Format: FormatCSV, Compression: sink.CompressionNone, Columns: []Column{{Name: "id"}, {Name: "name"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "id,name\n" {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	// Records must be objects
+	w, _ = NewWriter(io.Discard, &Options{Format: FormatCSV})
+	if err := w.Write([]interface{}{1}); err == nil {
+		t.Errorf("array was written to CSV")
+	}
+}
+
+func TestParquet(t *testing.T) {
+
+	columns := Columns(map[string]interface{}{
+		"id":   map[string]interface{}{"type": "uint"},
+		"name": map[string]interface{}{"type": "string"},
+		"address": map[string]interface{}{
+			"type": "map",
+			"fields": map[string]interface{}{
+				"city": map[string]interface{}{"type": "string"},
+			},
+		},
+		"email": map[string]interface{}{"type": "string"},
+	})
+
+	for _, compression := range []sink.Compression{sink.CompressionNone, sink.CompressionZstd} {
+
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, &Options{Format: FormatParquet, Compression: compression, Columns: columns})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records := testRecords(5)
+		for _, r := range records {
+			if err := w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		type row struct {
+			ID          *uint64 `parquet:"id"`
+			Name        *string `parquet:"name"`
+			AddressCity *string `parquet:"address.city"`
+			Email       *string `parquet:"email"`
+		}
+
+		rows, err := parquet.Read[row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+
+		if len(rows) != len(records) {
+			t.Fatalf("%s: %d rows, want %d", compression, len(rows), len(records))
+		}
+
+		for i, r := range rows {
+
+			record := records[i].(map[string]interface{})
+			if r.ID == nil || *r.ID != uint64(record["id"].(int)) {
+				t.Errorf("%s: row %d: id = %v", compression, i, r.ID)
+			}
+
+			if r.Name == nil || *r.Name != record["name"] {
+				t.Errorf("%s: row %d: name = %v", compression, i, r.Name)
+			}
+
+			if r.AddressCity == nil || *r.AddressCity != "Taipei" {
+				t.Errorf("%s: row %d: address.city = %v", compression, i, r.AddressCity)
+			}
+
+			// Missing field is null
+			if r.Email != nil {
+				t.Errorf("%s: row %d: email = %v", compression, i, *r.Email)
+			}
+		}
+	}
+
+	if _, err := NewWriter(io.Discard, &Options{Format: FormatParquet}); err == nil {
+		t.Errorf("parquet without columns was created")
+	}
+}
+
+func TestJSON(t *testing.T) {
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, &Options{Format: FormatJSON, Compression: sink.CompressionNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write(map[string]interface{}{"id": 1})
+	w.Write("scalar")
+	w.Close()
+
+	want := "{\n  \"id\": 1\n}\n\"scalar\"\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,216 @@
+package sink
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSink writes records as lines to files in a directory, files are
+// rotated by size on disk and age.
+type FileSink struct {
+	dir     string
+	options *Options
+
+	mutex    sync.Mutex
+	file     *os.File
+	writer   io.Writer
+	encoder  io.WriteCloser
+	size     int64
+	openedAt time.Time
+	files    int
+}
+
+func NewFileSink(dir string, options *Options) (*FileSink, error) {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		dir:     dir,
+		options: options,
+	}, nil
+}
+
+func (fs *FileSink) String() string {
+	return fmt.Sprintf("file://%s (compression: %s)", fs.dir, fs.options.Compression)
+}
+
+// Files returns number of files which have been created
+func (fs *FileSink) Files() int {
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	return fs.files
+}
+
+func (fs *FileSink) Write(record []byte) error {
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.file != nil && fs.shouldRotate() {
+		if err := fs.close(); err != nil {
+			return err
+		}
+	}
+
+	if fs.file == nil {
+		if err := fs.open(); err != nil {
+			return err
+		}
+	}
+
+	_, err := fs.writer.Write(record)
+	if err != nil {
+		return err
+	}
+
+	if len(record) == 0 || record[len(record)-1] != '\n' {
+		_, err = fs.writer.Write([]byte{'\n'})
+	}
+
+	return err
+}
+
+// countingWriter counts bytes written to file after compression
+type countingWriter struct {
+	w    io.Writer
+	size *int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	*cw.size += int64(n)
+	return n, err
+}
+
+func (fs *FileSink) shouldRotate() bool {
+
+	if fs.options.MaxSize > 0 && fs.size >= fs.options.MaxSize {
+		return true
+	}
+
+	if fs.options.MaxAge > 0 && time.Since(fs.openedAt) >= fs.options.MaxAge {
+		return true
+	}
+
+	return false
+}
+
+func (fs *FileSink) open() error {
+
+	now := time.Now().UTC()
+
+	// Names are sortable by time, sequence avoids conflicts of files which
+	// were created at the same time.
+	name := fmt.Sprintf("%s-%s-%04d.ndjson%s",
+		fs.options.Prefix,
+		now.Format("20060102T150405.000000000Z"),
+		fs.files,
+		fs.options.Compression.Extension(),
+	)
+
+	f, err := os.OpenFile(filepath.Join(fs.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	// Entry of new file should be persisted as well
+	if d, err := os.Open(fs.dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	fs.file = f
+	fs.size = 0
+	fs.openedAt = now
+	fs.files++
+
+	w := &countingWriter{w: f, size: &fs.size}
+	fs.writer = w
+	fs.encoder = nil
+
+	if fs.options.Compression != CompressionNone {
+		enc, err := NewEncoder(w, fs.options.Compression)
+		if err != nil {
+			f.Close()
+			fs.file = nil
+			return err
+		}
+
+		fs.encoder = enc
+		fs.writer = enc
+	}
+
+	return nil
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// Sync flushes compressor and commits the file to disk
+func (fs *FileSink) Sync() error {
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.file == nil {
+		return nil
+	}
+
+	// Files are rotated even if no more records were written
+	if fs.shouldRotate() {
+		return fs.close()
+	}
+
+	if f, ok := fs.encoder.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return fs.file.Sync()
+}
+
+// close finishes compressed stream and commits the file to disk
+func (fs *FileSink) close() error {
+
+	if fs.encoder != nil {
+		if err := fs.encoder.Close(); err != nil {
+			fs.file.Close()
+			fs.file = nil
+			return err
+		}
+	}
+
+	err := fs.file.Sync()
+	if cerr := fs.file.Close(); err == nil {
+		err = cerr
+	}
+
+	fs.file = nil
+	fs.writer = nil
+	fs.encoder = nil
+
+	return err
+}
+
+func (fs *FileSink) Close() error {
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.file == nil {
+		return nil
+	}
+
+	return fs.close()
+}
@@ -0,0 +1,196 @@
+package sink
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFiles returns lines of all files in directory in order of names
+func readFiles(t *testing.T, dir string, c Compression) ([]string, []string) {
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	sort.Strings(names)
+
+	lines := make([]string, 0)
+	for _, name := range names {
+
+		if !strings.HasSuffix(name, ".ndjson"+c.Extension()) {
+			t.Errorf("unexpected file name %s", name)
+		}
+
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dec, err := NewDecoder(f, c)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		scanner := bufio.NewScanner(dec)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		if err := scanner.Err(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		dec.Close()
+		f.Close()
+	}
+
+	return names, lines
+}
+
+func TestFileSink(t *testing.T) {
+
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+
+		dir := t.TempDir()
+
+		options := NewOptions()
+		options.Prefix = "accounts"
+		options.Compression = c
+		options.MaxSize = 200
+
+		s, err := NewFileSink(dir, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := make([]string, 0)
+		for i := 0; i < 100; i++ {
+
+			record := fmt.Sprintf("{\"id\":%d,\"name\":\"user%d\"}", i, i)
+			want = append(want, record)
+
+			// Newline is appended if it's missing
+			if i%2 == 0 {
+				record += "\n"
+			}
+
+			if err := s.Write([]byte(record)); err != nil {
+				t.Fatal(err)
+			}
+
+			// Size of compressed file is known after flush
+			if i%10 == 9 {
+				if err := s.Sync(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		names, lines := readFiles(t, dir, c)
+		if len(names) < 2 || s.Files() != len(names) {
+			t.Errorf("%s: %d files (%d created), expected rotation", c, len(names), s.Files())
+		}
+
+		for _, name := range names {
+			if !strings.HasPrefix(name, "accounts-") {
+				t.Errorf("%s: unexpected file name %s", c, name)
+			}
+		}
+
+		if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s: %d lines were read, want %d", c, len(lines), len(want))
+		}
+
+		// Closing again does nothing
+		if err := s.Close(); err != nil {
+			t.Errorf("%s: %v", c, err)
+		}
+	}
+}
+
+func TestFileSinkMaxAge(t *testing.T) {
+
+	dir := t.TempDir()
+
+	options := NewOptions()
+	options.MaxSize = 0
+	options.MaxAge = 20 * time.Millisecond
+
+	s, err := NewFileSink(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Write([]byte("{\"id\":1}")); err != nil {
+		t.Fatal(err)
+	}
+
+	// File is closed by sync after max age even without writes
+	time.Sleep(30 * time.Millisecond)
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Write([]byte("{\"id\":2}")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, lines := readFiles(t, dir, CompressionNone)
+	if len(names) != 2 || len(lines) != 2 {
+		t.Errorf("%d files and %d lines, want 2 files", len(names), len(lines))
+	}
+}
+
+func TestFileSinkWithoutRotation(t *testing.T) {
+
+	dir := t.TempDir()
+
+	options := NewOptions()
+	options.MaxSize = 0
+	options.Compression = CompressionGzip
+
+	s, err := NewFileSink(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is created without records
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if err := s.Write([]byte("{\"id\":1}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, lines := readFiles(t, dir, CompressionGzip)
+	if len(names) != 1 || len(lines) != 1000 {
+		t.Errorf("%d files and %d lines, want 1 file", len(names), len(lines))
+	}
+}
@@ -0,0 +1,190 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+
+	DefaultMaxSize = 100 * 1024 * 1024
+)
+
+var (
+	ErrUnsupportedSink        = errors.New("unsupported sink")
+	ErrUnsupportedCompression = errors.New("unsupported compression")
+	ErrInvalidSize            = errors.New("invalid size")
+)
+
+// Sink writes records durably. Records are not guaranteed to be persisted
+// until Sync returns.
+type Sink interface {
+	Write(record []byte) error
+	Sync() error
+	Close() error
+	String() string
+}
+
+type Options struct {
+
+	// Prefix of file names
+	Prefix string
+
+	Compression Compression
+
+	// Rotation, it's disabled if zero
+	MaxSize int64
+	MaxAge  time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Prefix:      "events",
+		Compression: CompressionNone,
+		MaxSize:     DefaultMaxSize,
+	}
+}
+
+// Open opens sink of URI like file:///var/lib/archive
+func Open(uri string, options *Options) (Sink, error) {
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedSink, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := u.Path
+		if len(u.Host) > 0 {
+			// file://relative/path
+			path = u.Host + path
+		}
+
+		if len(path) == 0 {
+			return nil, fmt.Errorf("%w: path is required: %s", ErrUnsupportedSink, uri)
+		}
+
+		return NewFileSink(path, options)
+	}
+
+	return nil, fmt.Errorf("%w: %s (file:///path)", ErrUnsupportedSink, uri)
+}
+
+// ParseCompression parses name of compression
+func ParseCompression(name string) (Compression, error) {
+
+	switch c := Compression(strings.ToLower(name)); c {
+	case "", CompressionNone:
+		return CompressionNone, nil
+	case CompressionGzip, CompressionZstd:
+		return c, nil
+	}
+
+	return "", fmt.Errorf("%w: \"%s\" (none, gzip or zstd)", ErrUnsupportedCompression, name)
+}
+
+// NewEncoder returns compressor which writes to w, it must be closed to
+// finish the compressed stream. The w is not closed.
+func NewEncoder(w io.Writer, c Compression) (io.WriteCloser, error) {
+
+	switch c {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	case "", CompressionNone:
+		return nopCloser{w}, nil
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", ErrUnsupportedCompression, c)
+}
+
+// NewDecoder returns decompressor which reads from r. The r is not closed.
+func NewDecoder(r io.Reader, c Compression) (io.ReadCloser, error) {
+
+	switch c {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return dec.IOReadCloser(), nil
+	case "", CompressionNone:
+		return io.NopCloser(r), nil
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", ErrUnsupportedCompression, c)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// Extension returns file extension of compression
+func (c Compression) Extension() string {
+
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	}
+
+	return ""
+}
+
+// ParseSize parses size in bytes with optional unit like 512KB, 100MB or 1GB
+func ParseSize(value string) (int64, error) {
+
+	s := strings.ToUpper(strings.TrimSpace(value))
+
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+		{"B", 1},
+	}
+
+	multiplier := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			multiplier = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: \"%s\"", ErrInvalidSize, value)
+	}
+
+	return n * multiplier, nil
+}
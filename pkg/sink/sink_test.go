@@ -0,0 +1,146 @@
+package sink
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCompression(t *testing.T) {
+
+	tests := []struct {
+		name string
+		want Compression
+		ok   bool
+	}{
+		{"", CompressionNone, true},
+		{"none", CompressionNone, true},
+		{"GZIP", CompressionGzip, true},
+		{"zstd", CompressionZstd, true},
+		{"lz4", "", false},
+	}
+
+	for _, tt := range tests {
+
+		c, err := ParseCompression(tt.name)
+		if tt.ok != (err == nil) || c != tt.want {
+			t.Errorf("ParseCompression(%q) = %s, %v", tt.name, c, err)
+		}
+
+		if !tt.ok && !errors.Is(err, ErrUnsupportedCompression) {
+			t.Errorf("ParseCompression(%q): expected ErrUnsupportedCompression, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  int64
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"512", 512, true},
+		{"512B", 512, true},
+		{"64kb", 64 << 10, true},
+		{" 100 MB ", 100 << 20, true},
+		{"1G", 1 << 30, true},
+		{"2GB", 2 << 30, true},
+		{"", 0, false},
+		{"MB", 0, false},
+		{"-1MB", 0, false},
+		{"1.5GB", 0, false},
+		{"1TB", 0, false},
+	}
+
+	for _, tt := range tests {
+
+		n, err := ParseSize(tt.value)
+		if tt.ok != (err == nil) || n != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v", tt.value, n, err)
+		}
+
+		if !tt.ok && !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("ParseSize(%q): expected ErrInvalidSize, got %v", tt.value, err)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+
+	dir := filepath.Join(t.TempDir(), "archive")
+
+	s, err := Open("file://"+dir, NewOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if !strings.HasPrefix(s.String(), "file://"+dir) {
+		t.Errorf("String() = %s", s.String())
+	}
+
+	for _, uri := range []string{"s3://bucket/path", "/var/lib/archive", "file://", "::"} {
+		if _, err := Open(uri, NewOptions()); !errors.Is(err, ErrUnsupportedSink) {
+			t.Errorf("Open(%s): expected ErrUnsupportedSink, got %v", uri, err)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+
+	data := bytes.Repeat([]byte("{\"id\":1,\"name\":\"fred\"}\n"), 100)
+
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd, ""} {
+
+		// Compressed streams which are concatenated are decoded as one
+		var buf bytes.Buffer
+		for i := 0; i < 2; i++ {
+
+			enc, err := NewEncoder(&buf, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := enc.Write(data); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if c == CompressionGzip || c == CompressionZstd {
+			if buf.Len() >= 2*len(data) {
+				t.Errorf("%s: output was not compressed (%d bytes)", c, buf.Len())
+			}
+		}
+
+		dec, err := NewDecoder(&buf, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := io.ReadAll(dec)
+		dec.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+
+		if !bytes.Equal(out, append(data, data...)) {
+			t.Errorf("%s: decoded %d bytes, want %d", c, len(out), 2*len(data))
+		}
+	}
+
+	if _, err := NewEncoder(io.Discard, "lz4"); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected ErrUnsupportedCompression, got %v", err)
+	}
+
+	if _, err := NewDecoder(bytes.NewReader(nil), "lz4"); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected ErrUnsupportedCompression, got %v", err)
+	}
+}
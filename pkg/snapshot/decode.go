@@ -0,0 +1,131 @@
+package snapshot
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Result is a decoded record, Index is 1-based position of record in snapshot
+type Result struct {
+	Index uint64
+	Msg   *nats.Msg
+	Value interface{}
+	Err   error
+}
+
+type DecodeFunc func(msg *nats.Msg) (interface{}, error)
+
+type EmitFunc func(result *Result) error
+
+// Decode decodes records of reader with workers in parallel and emits results
+// in the original order. At most window records are being decoded or waiting
+// to be emitted, so memory is bounded while a slow record is decoded. Errors of
+// decoding are passed to emit, and an error returned by emit stops decoding.
+func Decode(ctx context.Context, r *Reader, workers int, window int, decode DecodeFunc, emit EmitFunc) error {
+
+	if workers <= 0 {
+		workers = 1
+	}
+
+	if window < workers {
+		window = workers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *Result, workers)
+	results := make(chan *Result, window)
+	slots := make(chan struct{}, window)
+	readErr := make(chan error, 1)
+
+	// Read records in order
+	go func() {
+		defer close(jobs)
+
+		var index uint64
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			msg, err := r.Next(ctx)
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+
+				return
+			}
+
+			index++
+
+			select {
+			case jobs <- &Result{Index: index, Msg: msg}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Workers
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				job.Value, job.Err = decode(job.Msg)
+
+				select {
+				case results <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Reorder results
+	pending := make(map[uint64]*Result)
+	next := uint64(1)
+	for result := range results {
+
+		pending[result.Index] = result
+
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+
+			if err := emit(result); err != nil {
+				return err
+			}
+
+			<-slots
+			next++
+		}
+	}
+
+	select {
+	case err := <-readErr:
+		return err
+	default:
+	}
+
+	return ctx.Err()
+}
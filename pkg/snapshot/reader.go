@@ -0,0 +1,368 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	"github.com/BrobridgeOrg/gravity-sdk/v2/core"
+	product_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/product"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	SnapshotAPI          = "$GVT.%s.API.SNAPSHOT.VIEW.%s"
+	SnapshotEventSubject = "$GVT.%s.SS.%s.>"
+
+	DefaultBufferSize = 1024
+	DefaultTimeout    = 30 * time.Second
+)
+
+var (
+	ErrClosed  = errors.New("snapshot reader was closed")
+	ErrTimeout = errors.New("timeout waiting for records of snapshot")
+)
+
+type Options struct {
+	Domain     string
+	Partitions []int32
+
+	// Maximum number of records which are received but not read, the next
+	// batch is pulled when less than half of the buffer is used.
+	BufferSize int
+
+	// Resume after the last key of a batch which was processed before
+	LastKey string
+
+	// Timeout of API requests and arrival of records
+	Timeout time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Domain:     "default",
+		Partitions: []int32{},
+		BufferSize: DefaultBufferSize,
+		Timeout:    DefaultTimeout,
+	}
+}
+
+type batch struct {
+	end     uint64
+	lastKey string
+}
+
+// Reader reads records from snapshot view of product. Unlike snapshot of
+// Gravity SDK, records are pulled only if there is room in the buffer, and
+// the position of each pulled batch is kept so that it can be resumed from the
+// last key which was processed.
+type Reader struct {
+	client  *connector.Client
+	options *Options
+	id      string
+	sub     *nats.Subscription
+	records chan *nats.Msg
+	arrived chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	sending sync.WaitGroup
+	once    sync.Once
+
+	mutex    sync.Mutex
+	received uint64
+	expected uint64
+	batches  []batch
+	eof      bool
+	err      error
+}
+
+// Open creates snapshot view of product and starts pulling records, ctx only
+// applies to creating snapshot view.
+func Open(ctx context.Context, client *connector.Client, product string, options *Options) (*Reader, error) {
+
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+
+	readerCtx, cancel := context.WithCancel(context.Background())
+
+	r := &Reader{
+		client:  client,
+		options: options,
+		records: make(chan *nats.Msg, bufferSize),
+		arrived: make(chan struct{}, 1),
+		ctx:     readerCtx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	reply := &product_sdk.CreateSnapshotViewReply{}
+	err := r.request(ctx, "CREATE", &product_sdk.CreateSnapshotViewRequest{
+		Product:    product,
+		Partitions: options.Partitions,
+	}, reply)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r.id = reply.ID
+
+	js, err := client.GetJetStream()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	sub, err := js.Subscribe(fmt.Sprintf(SnapshotEventSubject, options.Domain, r.id), r.handle)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	// Records are bounded by pulling instead of dropping slow consumer
+	sub.SetPendingLimits(-1, -1)
+	r.sub = sub
+
+	go r.pull()
+
+	return r, nil
+}
+
+func (r *Reader) GetID() string {
+	return r.id
+}
+
+func (r *Reader) request(ctx context.Context, api string, req interface{}, reply interface{}) error {
+
+	data, _ := json.Marshal(req)
+
+	ctx, cancel := context.WithTimeout(ctx, r.options.Timeout)
+	defer cancel()
+
+	msg, err := r.client.GetConnection().RequestWithContext(ctx, fmt.Sprintf(SnapshotAPI, r.options.Domain, api), data)
+	if err != nil {
+		return err
+	}
+
+	var resp core.ErrorReply
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return err
+	}
+
+	if resp.Error != nil {
+		return errors.New(resp.Error.Message)
+	}
+
+	return json.Unmarshal(msg.Data, reply)
+}
+
+func (r *Reader) handle(msg *nats.Msg) {
+
+	r.mutex.Lock()
+	if r.eof || r.ctx.Err() != nil {
+		r.mutex.Unlock()
+		return
+	}
+
+	r.sending.Add(1)
+	r.mutex.Unlock()
+
+	defer r.sending.Done()
+
+	select {
+	case r.records <- msg:
+	case <-r.ctx.Done():
+		return
+	}
+
+	r.mutex.Lock()
+	r.received++
+	r.mutex.Unlock()
+
+	select {
+	case r.arrived <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Reader) fail(err error) {
+
+	r.mutex.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.mutex.Unlock()
+
+	r.cancel()
+}
+
+// pull requests the next batch when all records of previous batch arrived and
+// less than half of the buffer is used.
+func (r *Reader) pull() {
+
+	defer close(r.done)
+
+	lastKey := r.options.LastKey
+	for {
+
+		deadline := time.Now().Add(r.options.Timeout)
+		for {
+			r.mutex.Lock()
+			waiting := r.received < r.expected
+			r.mutex.Unlock()
+
+			if !waiting && len(r.records) <= cap(r.records)/2 {
+				break
+			}
+
+			// Records are being read, so the deadline only applies to arrival
+			if !waiting {
+				deadline = time.Now().Add(r.options.Timeout)
+			} else if time.Now().After(deadline) {
+				r.fail(ErrTimeout)
+				return
+			}
+
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-r.arrived:
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		reply := &product_sdk.PullSnapshotViewReply{}
+		err := r.request(r.ctx, "PULL", &product_sdk.PullSnapshotViewRequest{
+			ID:           r.id,
+			LastKey:      lastKey,
+			AfterLastKey: len(lastKey) > 0,
+		}, reply)
+		if err != nil {
+			if r.ctx.Err() == nil {
+				r.fail(err)
+			}
+
+			return
+		}
+
+		// No more records, channel is closed after records which are being
+		// sent.
+		if reply.Count == 0 {
+			r.mutex.Lock()
+			r.eof = true
+			r.mutex.Unlock()
+
+			r.sending.Wait()
+			close(r.records)
+
+			return
+		}
+
+		if len(reply.LastKey) > 0 {
+			lastKey = reply.LastKey
+		}
+
+		r.mutex.Lock()
+		r.expected += uint64(reply.Count)
+		r.batches = append(r.batches, batch{
+			end:     r.expected,
+			lastKey: lastKey,
+		})
+		r.mutex.Unlock()
+	}
+}
+
+// Next returns the next record, it returns io.EOF if there are no more records
+func (r *Reader) Next(ctx context.Context) (*nats.Msg, error) {
+
+	select {
+	case msg, ok := <-r.records:
+		if !ok {
+			return nil, io.EOF
+		}
+
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.ctx.Done():
+		return nil, r.Err()
+	}
+}
+
+// Err returns error which stopped reader
+func (r *Reader) Err() error {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	return ErrClosed
+}
+
+// BatchKey returns the last key of batch if the n-th record (1-based) is the
+// last record of the batch. Records of the batch and batches before it have
+// been processed if they were processed in order, so snapshot can be resumed
+// after the key.
+func (r *Reader) BatchKey(n uint64) (string, bool) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, b := range r.batches {
+		if b.end == n {
+
+			// Batches before it are no longer needed
+			r.batches = r.batches[i+1:]
+
+			return b.lastKey, len(b.lastKey) > 0
+		}
+
+		if b.end > n {
+			break
+		}
+	}
+
+	return "", false
+}
+
+// Close stops pulling and deletes snapshot view, it's safe to call it more
+// than once.
+func (r *Reader) Close() error {
+
+	var err error
+	r.once.Do(func() {
+
+		r.cancel()
+
+		if r.sub != nil {
+			<-r.done
+			r.sub.Unsubscribe()
+		}
+
+		if len(r.id) == 0 {
+			return
+		}
+
+		reply := &product_sdk.DeleteSnapshotViewReply{}
+		err = r.request(context.Background(), "DELETE", &product_sdk.DeleteSnapshotViewRequest{
+			ID: r.id,
+		}, reply)
+	})
+
+	return err
+}
@@ -0,0 +1,305 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// newTestReader returns reader of records, channel is closed after records
+// unless open is set.
+func newTestReader(n int, open bool) *Reader {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Reader{
+		records: make(chan *nats.Msg, n),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	for i := 1; i <= n; i++ {
+		msg := nats.NewMsg("$GVT.default.SS.test.0")
+		msg.Data = []byte(strconv.Itoa(i))
+		r.records <- msg
+	}
+
+	if !open {
+		close(r.records)
+	}
+
+	return r
+}
+
+func decodeTestRecord(msg *nats.Msg) (interface{}, error) {
+	return strconv.Atoi(string(msg.Data))
+}
+
+func TestDecode(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		records int
+		workers int
+		window  int
+	}{
+		{name: "single worker", records: 10, workers: 1, window: 1},
+		{name: "workers", records: 100, workers: 8, window: 16},
+		{name: "window is less than workers", records: 50, workers: 4, window: 1},
+	}
+
+	for _, tt := range tests {
+
+		r := newTestReader(tt.records, false)
+
+		// Earlier records take longer, so they are decoded out of order
+		var inflight, maxInflight int64
+		decode := func(msg *nats.Msg) (interface{}, error) {
+
+			n := atomic.AddInt64(&inflight, 1)
+			for {
+				max := atomic.LoadInt64(&maxInflight)
+				if n <= max || atomic.CompareAndSwapInt64(&maxInflight, max, n) {
+					break
+				}
+			}
+
+			v, err := strconv.Atoi(string(msg.Data))
+			time.Sleep(time.Duration(tt.records-v) * 10 * time.Microsecond)
+
+			return v, err
+		}
+
+		var got []int
+		var indexes []uint64
+		err := Decode(context.Background(), r, tt.workers, tt.window, decode, func(result *Result) error {
+			atomic.AddInt64(&inflight, -1)
+			got = append(got, result.Value.(int))
+			indexes = append(indexes, result.Index)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", tt.name, err)
+		}
+
+		for i := range got {
+			if got[i] != i+1 || indexes[i] != uint64(i+1) {
+				t.Errorf("%s: result %d = %d (index %d), want %d", tt.name, i, got[i], indexes[i], i+1)
+				break
+			}
+		}
+
+		if len(got) != tt.records {
+			t.Errorf("%s: %d results, want %d", tt.name, len(got), tt.records)
+		}
+
+		window := tt.window
+		if window < tt.workers {
+			window = tt.workers
+		}
+
+		if maxInflight > int64(window) {
+			t.Errorf("%s: %d records were being decoded, want at most %d", tt.name, maxInflight, window)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+
+	r := newTestReader(5, false)
+
+	errDecode := errors.New("invalid record")
+	decode := func(msg *nats.Msg) (interface{}, error) {
+		if string(msg.Data) == "3" {
+			return nil, errDecode
+		}
+
+		return decodeTestRecord(msg)
+	}
+
+	// Errors of decoding are passed to emit
+	var errs []uint64
+	var count int
+	err := Decode(context.Background(), r, 2, 4, decode, func(result *Result) error {
+		count++
+		if result.Err != nil {
+			errs = append(errs, result.Index)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if count != 5 {
+		t.Errorf("%d results, want 5", count)
+	}
+
+	if want := []uint64{3}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors of records %v, want %v", errs, want)
+	}
+}
+
+func TestDecodeEmitError(t *testing.T) {
+
+	r := newTestReader(100, false)
+
+	errStop := errors.New("stop")
+
+	var emitted []uint64
+	err := Decode(context.Background(), r, 4, 8, decodeTestRecord, func(result *Result) error {
+		emitted = append(emitted, result.Index)
+		if result.Index == 3 {
+			return errStop
+		}
+
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("Decode() error = %v, want %v", err, errStop)
+	}
+
+	if want := []uint64{1, 2, 3}; !reflect.DeepEqual(emitted, want) {
+		t.Errorf("emitted %v, want %v", emitted, want)
+	}
+}
+
+func TestDecodeReadError(t *testing.T) {
+
+	// Reader fails after records which were received
+	r := newTestReader(3, true)
+	go func() {
+		for len(r.records) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		r.fail(ErrTimeout)
+	}()
+
+	var count int
+	err := Decode(context.Background(), r, 2, 4, decodeTestRecord, func(result *Result) error {
+		count++
+		return nil
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrTimeout)
+	}
+
+	if count > 3 {
+		t.Errorf("%d results, want at most 3", count)
+	}
+
+	// Reader which was closed
+	r = newTestReader(0, true)
+	r.cancel()
+
+	_, err = r.Next(context.Background())
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Next() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestBatchKey(t *testing.T) {
+
+	r := newTestReader(0, false)
+	r.batches = []batch{
+		{end: 3, lastKey: "k3"},
+		{end: 6, lastKey: "k6"},
+		{end: 9, lastKey: "k9"},
+		{end: 10, lastKey: ""},
+	}
+
+	tests := []struct {
+		n       uint64
+		key     string
+		ok      bool
+		batches int
+	}{
+		{n: 2, key: "", ok: false, batches: 4},
+		{n: 3, key: "k3", ok: true, batches: 3},
+		// Batches which were returned are pruned
+		{n: 3, key: "", ok: false, batches: 3},
+		{n: 9, key: "k9", ok: true, batches: 1},
+		{n: 10, key: "", ok: false, batches: 0},
+	}
+
+	for _, tt := range tests {
+
+		key, ok := r.BatchKey(tt.n)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("BatchKey(%d) = %q, %v, want %q, %v", tt.n, key, ok, tt.key, tt.ok)
+		}
+
+		if len(r.batches) != tt.batches {
+			t.Errorf("BatchKey(%d): %d batches are kept, want %d", tt.n, len(r.batches), tt.batches)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+
+	filename := filepath.Join(t.TempDir(), "accounts.state")
+
+	// State file doesn't exist
+	state, err := LoadState(filename)
+	if err != nil || state != nil {
+		t.Errorf("LoadState() of missing file = %v, %v, want nil", state, err)
+	}
+
+	want := &State{
+		Product:     "accounts",
+		Format:      "csv",
+		Compression: "gzip",
+		Columns:     []string{"id", "name"},
+		LastKey:     "key-100",
+		Records:     100,
+		Written:     98,
+		Skipped:     1,
+		Failed:      1,
+		Size:        4096,
+		ReportSize:  128,
+	}
+
+	if err := want.Save(filename); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if want.UpdatedAt.IsZero() {
+		t.Errorf("UpdatedAt is not set by Save")
+	}
+
+	got, err := LoadState(filename)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+
+	if !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want %v", got.UpdatedAt, want.UpdatedAt)
+	}
+
+	got.UpdatedAt = want.UpdatedAt
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadState() = %+v, want %+v", got, want)
+	}
+
+	// Temporary files are not left
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("%d files in directory, want 1", len(entries))
+	}
+
+	// Invalid state file
+	os.WriteFile(filename, []byte("{"), 0644)
+	if _, err := LoadState(filename); err == nil {
+		t.Errorf("LoadState() of invalid file should fail")
+	}
+}
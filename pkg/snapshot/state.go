@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is position of snapshot which was written to output file, the file is
+// truncated to Size and snapshot is resumed after LastKey. Error report is
+// truncated to ReportSize as well, so skipped records are not reported twice.
+type State struct {
+	Product     string    `json:"product"`
+	Format      string    `json:"format"`
+	Compression string    `json:"compression"`
+	Columns     []string  `json:"columns,omitempty"`
+	LastKey     string    `json:"lastKey"`
+	Records     uint64    `json:"records"`
+	Written     uint64    `json:"written"`
+	Skipped     uint64    `json:"skipped"`
+	Failed      uint64    `json:"failed"`
+	Size        int64     `json:"size"`
+	ReportSize  int64     `json:"reportSize,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// LoadState reads state from file, it returns nil if file doesn't exist
+func LoadState(filename string) (*State, error) {
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", filename, err)
+	}
+
+	return &state, nil
+}
+
+// Save replaces state file atomically
+func (state *State) Save(filename string) error {
+
+	state.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
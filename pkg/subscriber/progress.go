@@ -0,0 +1,101 @@
+package subscriber
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Progress marks messages in progress periodically until they are done, so
+// that messages which are waiting for slow handlers or retries are not
+// redelivered after ack wait of consumer.
+type Progress struct {
+	mutex sync.Mutex
+	msgs  map[*nats.Msg]struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func NewProgress(interval time.Duration) *Progress {
+
+	p := &Progress{
+		msgs: make(map[*nats.Msg]struct{}),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go p.run(interval)
+
+	return p
+}
+
+func (p *Progress) run(interval time.Duration) {
+
+	defer close(p.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		p.mutex.Lock()
+		msgs := make([]*nats.Msg, 0, len(p.msgs))
+		for msg := range p.msgs {
+			msgs = append(msgs, msg)
+		}
+		p.mutex.Unlock()
+
+		// Messages which were acknowledged in the meantime are ignored by
+		// server
+		for _, msg := range msgs {
+			msg.InProgress()
+		}
+	}
+}
+
+// Add starts marking messages in progress
+func (p *Progress) Add(msgs ...*nats.Msg) {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, msg := range msgs {
+		p.msgs[msg] = struct{}{}
+	}
+}
+
+// Done stops marking messages, it should be called before messages are
+// acknowledged or redelivered.
+func (p *Progress) Done(msgs ...*nats.Msg) {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, msg := range msgs {
+		delete(p.msgs, msg)
+	}
+}
+
+// Len returns number of messages in progress
+func (p *Progress) Len() int {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.msgs)
+}
+
+// Stop stops marking all messages, it's safe to call it more than once
+func (p *Progress) Stop() {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+}
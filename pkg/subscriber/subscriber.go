@@ -0,0 +1,333 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	ProductEventStream   = "GVT_%s_DP_%s"
+	ProductEventSubject  = "$GVT.%s.DP.%s.%s.EVENT.>"
+	ProductEventConsumer = "GVT_%s_SUBSCRIBER_%s"
+
+	DefaultBatchSize = 1024
+	DefaultMaxWait   = time.Second
+
+	// Messages which are not acknowledged within ack wait are redelivered,
+	// so messages which are still being processed are marked in progress.
+	DefaultAckWait   = 30 * time.Second
+	ProgressInterval = DefaultAckWait / 3
+)
+
+type Options struct {
+	Domain string
+
+	// Name of durable consumer, anonymous subscriber will be created if it's empty
+	Name string
+
+	// Partitions to subscribe, -1 for all partitions
+	Partitions []int
+
+	// Start position, only one of them should be specified and StartSequence
+	// is used if others are empty. It only applies when consumer is created,
+	// existing durable consumer resumes from its own position.
+	StartSequence uint64
+	StartTime     *time.Time
+	Last          uint64
+	DeliverNew    bool
+
+	BatchSize int
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Domain:        "default",
+		Partitions:    []int{-1},
+		StartSequence: 1,
+		BatchSize:     DefaultBatchSize,
+	}
+}
+
+// Subscription receives product events with pull consumer like subscriber of
+// Gravity SDK, but it can be closed so that messages will not be delivered to
+// handler anymore.
+type Subscription struct {
+	sub      *nats.Subscription
+	progress *Progress
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	once     sync.Once
+	err      error
+}
+
+// Subjects returns subjects of specific partitions of product
+func Subjects(domain string, product string, partitions []int) []string {
+
+	subjects := make([]string, 0, len(partitions))
+	for _, p := range partitions {
+
+		// All partitions
+		if p == -1 {
+			return []string{
+				fmt.Sprintf(ProductEventSubject, domain, product, "*"),
+			}
+		}
+
+		subjects = append(subjects, fmt.Sprintf(ProductEventSubject, domain, product, fmt.Sprintf("%d", p)))
+	}
+
+	if len(subjects) == 0 {
+		subjects = append(subjects, fmt.Sprintf(ProductEventSubject, domain, product, "*"))
+	}
+
+	return subjects
+}
+
+// Partition returns partition of product event by its subject
+func Partition(subject string) (int, error) {
+
+	// $GVT.<domain>.DP.<product>.<partition>.EVENT.<event>
+	parts := strings.Split(subject, ".")
+	if len(parts) < 7 || parts[2] != "DP" || parts[5] != "EVENT" {
+		return 0, fmt.Errorf("invalid subject of product event: %s", subject)
+	}
+
+	return strconv.Atoi(parts[4])
+}
+
+// Subscribe subscribes to product and calls handler for each message in a
+// single goroutine.
+func Subscribe(client *connector.Client, product string, handler func(*nats.Msg), options *Options) (*Subscription, error) {
+
+	js, err := client.GetJetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	stream := fmt.Sprintf(ProductEventStream, options.Domain, product)
+	subjects := Subjects(options.Domain, product, options.Partitions)
+
+	var sub *nats.Subscription
+	if len(options.Name) > 0 {
+
+		// Durable consumer will be created explicitly, otherwise it will be
+		// deleted by JetStream client on unsubscribe.
+		consumer := fmt.Sprintf(ProductEventConsumer, options.Domain, options.Name)
+		_, err := js.ConsumerInfo(stream, consumer)
+		if errors.Is(err, nats.ErrConsumerNotFound) {
+			config := &nats.ConsumerConfig{
+				Durable:        consumer,
+				FilterSubjects: subjects,
+				AckPolicy:      nats.AckExplicitPolicy,
+				AckWait:        DefaultAckWait,
+				MaxWaiting:     1024,
+			}
+
+			err = setStartPosition(js, stream, config, options)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = js.AddConsumer(stream, config)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err = js.PullSubscribe("", consumer, nats.Bind(stream, consumer))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		config := &nats.ConsumerConfig{
+			FilterSubjects: subjects,
+		}
+
+		err = setStartPosition(js, stream, config, options)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := []nats.SubOpt{
+			nats.BindStream(stream),
+			nats.ConsumerFilterSubjects(subjects...),
+			nats.AckExplicit(),
+			nats.AckWait(DefaultAckWait),
+			nats.PullMaxWaiting(1024),
+		}
+
+		switch config.DeliverPolicy {
+		case nats.DeliverNewPolicy:
+			opts = append(opts, nats.DeliverNew())
+		case nats.DeliverByStartTimePolicy:
+			opts = append(opts, nats.StartTime(*config.OptStartTime))
+		default:
+			opts = append(opts, nats.StartSequence(config.OptStartSeq))
+		}
+
+		sub, err = js.PullSubscribe("", "", opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Subscription{
+		sub:      sub,
+		progress: NewProgress(ProgressInterval),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go s.fetch(batchSize, handler)
+
+	return s, nil
+}
+
+// setStartPosition sets deliver policy of consumer according to start position
+// of options, the last N events are resolved to a start sequence.
+func setStartPosition(js nats.JetStreamContext, stream string, config *nats.ConsumerConfig, options *Options) error {
+
+	switch {
+	case options.DeliverNew:
+		config.DeliverPolicy = nats.DeliverNewPolicy
+	case options.StartTime != nil:
+		config.DeliverPolicy = nats.DeliverByStartTimePolicy
+		config.OptStartTime = options.StartTime
+	case options.Last > 0:
+		seq, err := resolveLast(js, stream, options.Last)
+		if err != nil {
+			return err
+		}
+
+		config.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		config.OptStartSeq = seq
+	default:
+		config.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		config.OptStartSeq = options.StartSequence
+		if config.OptStartSeq == 0 {
+			config.OptStartSeq = 1
+		}
+	}
+
+	return nil
+}
+
+// resolveLast returns sequence of the n-th last message of stream. It only
+// takes stream information, so subscription of specific partitions receives
+// their messages among the last n messages of stream.
+func resolveLast(js nats.JetStreamContext, stream string, n uint64) (uint64, error) {
+
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		return 0, err
+	}
+
+	state := info.State
+	if state.Msgs == 0 {
+		return state.LastSeq + 1, nil
+	}
+
+	if state.LastSeq < state.FirstSeq+n {
+		return state.FirstSeq, nil
+	}
+
+	return state.LastSeq - n + 1, nil
+}
+
+func (s *Subscription) fetch(batchSize int, handler func(*nats.Msg)) {
+
+	defer close(s.done)
+	defer s.progress.Stop()
+
+	for {
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		// Fetching will be interrupted by closing
+		ctx, cancel := context.WithTimeout(s.ctx, DefaultMaxWait)
+		msgs, err := s.sub.Fetch(batchSize, nats.Context(ctx))
+		cancel()
+
+		if err != nil && !errors.Is(err, nats.ErrTimeout) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+
+			// Subscription is no longer available
+			if errors.Is(err, nats.ErrBadSubscription) || errors.Is(err, nats.ErrConnectionClosed) || errors.Is(err, nats.ErrConsumerDeleted) {
+				s.err = err
+				return
+			}
+		}
+
+		// Messages are waiting while handler is blocked by previous ones
+		s.progress.Add(msgs...)
+
+		for i, msg := range msgs {
+
+			// Messages which are not handled will be redelivered
+			if s.ctx.Err() != nil {
+				s.progress.Done(msgs[i:]...)
+				for _, m := range msgs[i:] {
+					Redeliver(m)
+				}
+
+				return
+			}
+
+			s.progress.Done(msg)
+			handler(msg)
+		}
+	}
+}
+
+// Redeliver asks server to redeliver message which is not handled. Pull
+// request may be still pending on server after fetching returns, so it's
+// delayed until the pull request expires, otherwise message will be delivered
+// to the pull request which nobody receives from and redelivered until ack
+// wait is exceeded.
+func Redeliver(msg *nats.Msg) error {
+	return msg.NakWithDelay(DefaultMaxWait)
+}
+
+// Done returns a channel which is closed when subscription stops fetching
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error which stopped subscription
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Close stops fetching and waits for the handler to return. Anonymous
+// consumer will be deleted while durable consumer is kept for resuming.
+func (s *Subscription) Close() error {
+
+	var err error
+	s.once.Do(func() {
+		s.cancel()
+		<-s.done
+		err = s.sub.Unsubscribe()
+		if errors.Is(err, nats.ErrConnectionClosed) || errors.Is(err, nats.ErrBadSubscription) {
+			err = nil
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,204 @@
+package subscriber
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func runJetStream(t *testing.T) nats.JetStreamContext {
+
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("server is not ready")
+	}
+
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return js
+}
+
+func TestResolveLast(t *testing.T) {
+
+	js := runJetStream(t)
+
+	stream := fmt.Sprintf(ProductEventStream, "default", "accounts")
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     stream,
+		Subjects: Subjects("default", "accounts", []int{-1}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty stream starts from the next message
+	seq, err := resolveLast(js, stream, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seq != 1 {
+		t.Errorf("resolveLast of empty stream = %d, want 1", seq)
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, err := js.Publish(fmt.Sprintf("$GVT.default.DP.accounts.%d.EVENT.accountCreated", i%4), []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Messages before sequence 6 were removed
+	if err := js.PurgeStream(stream, &nats.StreamPurgeRequest{Sequence: 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n    uint64
+		want uint64
+	}{
+		{1, 20},
+		{3, 18},
+		{15, 6},
+		{16, 6},
+		{100, 6},
+	}
+
+	for _, tt := range tests {
+
+		seq, err := resolveLast(js, stream, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seq != tt.want {
+			t.Errorf("resolveLast(%d) = %d, want %d", tt.n, seq, tt.want)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+
+	tests := []struct {
+		subject   string
+		partition int
+		ok        bool
+	}{
+		{"$GVT.default.DP.accounts.3.EVENT.accountCreated", 3, true},
+		{"$GVT.default.DP.accounts.12.EVENT.accountCreated", 12, true},
+		{"$GVT.default.DP.accounts.x.EVENT.accountCreated", 0, false},
+		{"$GVT.default.EVENT.accountCreated", 0, false},
+	}
+
+	for _, tt := range tests {
+
+		p, err := Partition(tt.subject)
+		if tt.ok != (err == nil) {
+			t.Errorf("Partition(%s): unexpected result %v", tt.subject, err)
+			continue
+		}
+
+		if tt.ok && p != tt.partition {
+			t.Errorf("Partition(%s) = %d, want %d", tt.subject, p, tt.partition)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+
+	js := runJetStream(t)
+
+	stream := fmt.Sprintf(ProductEventStream, "default", "accounts")
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     stream,
+		Subjects: Subjects("default", "accounts", []int{-1}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := js.Publish("$GVT.default.DP.accounts.0.EVENT.accountCreated", []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ackWait := 500 * time.Millisecond
+	sub, err := js.PullSubscribe("", "", nats.BindStream(stream), nats.AckExplicit(), nats.AckWait(ackWait))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := sub.Fetch(2, nats.MaxWait(time.Second))
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("Fetch = %d messages, %v", len(msgs), err)
+	}
+
+	// The second message is not marked in progress
+	p := NewProgress(ackWait / 5)
+	defer p.Stop()
+
+	p.Add(msgs[0])
+	if p.Len() != 1 {
+		t.Errorf("Len = %d, want 1", p.Len())
+	}
+
+	time.Sleep(3 * ackWait)
+
+	redelivered, err := sub.Fetch(2, nats.MaxWait(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(redelivered) != 1 {
+		t.Fatalf("%d messages were redelivered, want 1", len(redelivered))
+	}
+
+	md, _ := redelivered[0].Metadata()
+	if md.Sequence.Stream != 2 {
+		t.Errorf("message %d was redelivered, want 2", md.Sequence.Stream)
+	}
+
+	redelivered[0].Ack()
+
+	// Message is redelivered once it's done without acknowledgement
+	p.Done(msgs[0])
+	if p.Len() != 0 {
+		t.Errorf("Len = %d, want 0", p.Len())
+	}
+
+	redelivered, err = sub.Fetch(1, nats.MaxWait(3*ackWait))
+	if err != nil || len(redelivered) != 1 {
+		t.Fatalf("Fetch = %d messages, %v", len(redelivered), err)
+	}
+
+	if md, _ := redelivered[0].Metadata(); md.Sequence.Stream != 1 {
+		t.Errorf("message %d was redelivered, want 1", md.Sequence.Stream)
+	}
+}
@@ -0,0 +1,95 @@
+package token
+
+import (
+	"fmt"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
+	token_sdk "github.com/BrobridgeOrg/gravity-sdk/v2/token"
+)
+
+// Client calls token APIs with connection of CLI. Requests and replies are
+// the same as token client of Gravity SDK.
+type Client struct {
+	client *connector.Client
+	domain string
+}
+
+func NewClient(client *connector.Client, domain string) *Client {
+	return &Client{
+		client: client,
+		domain: domain,
+	}
+}
+
+func (c *Client) call(api string, req interface{}, reply interface{}) error {
+	return c.client.Call(fmt.Sprintf(token_sdk.TokenAPI+".%s", c.domain, api), req, reply)
+}
+
+func (c *Client) ListAvailablePermissions() (map[string]string, error) {
+
+	reply := &token_sdk.ListAvailablePermissionsReply{}
+	err := c.call("LIST_AVAILABLE_PERMISSIONS", &token_sdk.ListAvailablePermissionsRequest{}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Permissions, nil
+}
+
+func (c *Client) CreateToken(tokenID string, setting *token_sdk.TokenSetting) (string, *token_sdk.TokenSetting, error) {
+
+	reply := &token_sdk.CreateTokenReply{}
+	err := c.call("CREATE", &token_sdk.CreateTokenRequest{
+		TokenID: tokenID,
+		Setting: setting,
+	}, reply)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return reply.Token, reply.Setting, nil
+}
+
+func (c *Client) DeleteToken(tokenID string) error {
+	return c.call("DELETE", &token_sdk.DeleteTokenRequest{
+		TokenID: tokenID,
+	}, &token_sdk.DeleteTokenReply{})
+}
+
+func (c *Client) UpdateToken(tokenID string, setting *token_sdk.TokenSetting) (*token_sdk.TokenSetting, error) {
+
+	reply := &token_sdk.UpdateTokenReply{}
+	err := c.call("UPDATE", &token_sdk.UpdateTokenRequest{
+		TokenID: tokenID,
+		Setting: setting,
+	}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Setting, nil
+}
+
+func (c *Client) GetToken(tokenID string) (string, *token_sdk.TokenSetting, error) {
+
+	reply := &token_sdk.InfoTokenReply{}
+	err := c.call("INFO", &token_sdk.InfoTokenRequest{
+		TokenID: tokenID,
+	}, reply)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return reply.Token, reply.Setting, nil
+}
+
+func (c *Client) ListTokens() ([]*token_sdk.TokenSetting, error) {
+
+	reply := &token_sdk.ListTokensReply{}
+	err := c.call("LIST", &token_sdk.ListTokensRequest{}, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Tokens, nil
+}
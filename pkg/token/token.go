@@ -2,11 +2,9 @@ package token
 
 import (
 	"context"
-	"errors"
 
 	"github.com/BrobridgeOrg/gravity-cli/pkg/configs"
 	"github.com/BrobridgeOrg/gravity-cli/pkg/connector"
-	"github.com/BrobridgeOrg/gravity-sdk/v2/token"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
@@ -16,7 +14,7 @@ var logger *zap.Logger
 type Token struct {
 	config      *configs.Config
 	connector   *connector.Connector
-	tokenClient *token.TokenClient
+	tokenClient *Client
 }
 
 func New(lifecycle fx.Lifecycle, config *configs.Config, l *zap.Logger, c *connector.Connector) *Token {
@@ -31,18 +29,7 @@ func New(lifecycle fx.Lifecycle, config *configs.Config, l *zap.Logger, c *conne
 	lifecycle.Append(
 		fx.Hook{
 			OnStart: func(context.Context) error {
-				pcOpts := token.NewOptions()
-				pcOpts.Domain = c.GetDomain()
-				tokenClient := token.NewTokenClient(
-					c.GetClient(),
-					pcOpts,
-				)
-
-				if tokenClient == nil {
-					return errors.New("Failed to create token client")
-				}
-
-				p.tokenClient = tokenClient
+				p.tokenClient = NewClient(c.GetClient(), c.GetDomain())
 
 				return nil
 			},
@@ -55,6 +42,6 @@ func New(lifecycle fx.Lifecycle, config *configs.Config, l *zap.Logger, c *conne
 	return p
 }
 
-func (p *Token) GetClient() *token.TokenClient {
+func (p *Token) GetClient() *Client {
 	return p.tokenClient
 }
@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	DefaultSignatureHeader = "X-Gravity-Signature"
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrInvalidHeader    = errors.New("invalid header")
+	ErrUnexpectedStatus = errors.New("unexpected status")
+)
+
+type Options struct {
+	URL     string
+	Headers http.Header
+
+	// Body is signed with HMAC-SHA256 if secret is specified
+	Secret          string
+	SignatureHeader string
+
+	Client *http.Client
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Headers:         make(http.Header),
+		SignatureHeader: DefaultSignatureHeader,
+		Client:          http.DefaultClient,
+	}
+}
+
+// Webhook posts JSON to HTTP endpoint, requests succeed only if server
+// responded with 2xx status.
+type Webhook struct {
+	options *Options
+}
+
+func New(options *Options) (*Webhook, error) {
+
+	u, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, options.URL)
+	}
+
+	return &Webhook{
+		options: options,
+	}, nil
+}
+
+func (wh *Webhook) GetURL() string {
+	return wh.options.URL
+}
+
+// Sign returns signature of body in format "sha256=<hex>"
+func Sign(secret string, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseHeaders parses headers in key=value format like --header of pub and
+// import, e.g. "Authorization=Bearer xxx"
+func ParseHeaders(values []string) (http.Header, error) {
+
+	headers := make(http.Header)
+	for _, v := range values {
+
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHeader, v)
+		}
+
+		headers.Add(strings.TrimSpace(parts[0]), parts[1])
+	}
+
+	return headers, nil
+}
+
+// Post sends body with extra headers, headers of options come first
+func (wh *Webhook) Post(ctx context.Context, body []byte, headers http.Header) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, values := range wh.options.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	for k, values := range headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	if len(wh.options.Secret) > 0 {
+		req.Header.Set(wh.options.SignatureHeader, Sign(wh.options.Secret, body))
+	}
+
+	client := wh.options.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// Connection can be reused after body was read
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BrobridgeOrg/gravity-cli/pkg/hook"
+)
+
+func newTestWebhook(t *testing.T, url string, configure func(opts *Options)) *Webhook {
+
+	opts := NewOptions()
+	opts.URL = url
+	if configure != nil {
+		configure(opts)
+	}
+
+	wh, err := New(opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return wh
+}
+
+func TestNew(t *testing.T) {
+
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"http://localhost:8080/events", true},
+		{"https://example.com/hook", true},
+		{"ftp://example.com/hook", false},
+		{"localhost:8080", false},
+		{"http://", false},
+		{"://bad", false},
+	}
+
+	for _, tt := range tests {
+
+		opts := NewOptions()
+		opts.URL = tt.url
+
+		_, err := New(opts)
+		if tt.ok && err != nil {
+			t.Errorf("New(%q): unexpected error %v", tt.url, err)
+		}
+
+		if !tt.ok && !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("New(%q): expected ErrInvalidURL, got %v", tt.url, err)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+
+	tests := []struct {
+		values []string
+		name   string
+		want   string
+		ok     bool
+	}{
+		{[]string{"Authorization=Bearer xxx"}, "Authorization", "Bearer xxx", true},
+		{[]string{"X-Token=a=b="}, "X-Token", "a=b=", true},
+		{[]string{" X-Trace = abc"}, "X-Trace", " abc", true},
+		{[]string{"X-Empty="}, "X-Empty", "", true},
+		{[]string{"Authorization: Bearer xxx"}, "", "", false},
+		{[]string{"=value"}, "", "", false},
+	}
+
+	for _, tt := range tests {
+
+		headers, err := ParseHeaders(tt.values)
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidHeader) {
+				t.Errorf("ParseHeaders(%q): expected ErrInvalidHeader, got %v", tt.values, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseHeaders(%q): unexpected error %v", tt.values, err)
+			continue
+		}
+
+		if got := headers.Get(tt.name); got != tt.want {
+			t.Errorf("ParseHeaders(%q): %s = %q, want %q", tt.values, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPost(t *testing.T) {
+
+	var received http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	wh := newTestWebhook(t, server.URL, func(opts *Options) {
+		opts.Headers.Set("Authorization", "Bearer xxx")
+	})
+
+	extra := make(http.Header)
+	extra.Set("X-Gravity-Seq", "42")
+
+	err := wh.Post(context.Background(), []byte(`{"id":1}`), extra)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if string(body) != `{"id":1}` {
+		t.Errorf("body = %s", body)
+	}
+
+	for name, want := range map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer xxx",
+		"X-Gravity-Seq": "42",
+	} {
+		if got := received.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	// No signature without secret
+	if got := received.Get(DefaultSignatureHeader); len(got) > 0 {
+		t.Errorf("unexpected signature %q", got)
+	}
+}
+
+func TestPostUnexpectedStatus(t *testing.T) {
+
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusBadRequest, http.StatusInternalServerError} {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Location", "/elsewhere")
+			w.WriteHeader(status)
+		}))
+
+		wh := newTestWebhook(t, server.URL, func(opts *Options) {
+
+			// Redirects are not followed, so 3xx is reported as well
+			opts.Client = &http.Client{
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+		})
+
+		err := wh.Post(context.Background(), []byte(`{}`), nil)
+		if !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("status %d: expected ErrUnexpectedStatus, got %v", status, err)
+		}
+
+		server.Close()
+	}
+}
+
+func TestPostSignature(t *testing.T) {
+
+	secret := "sekret"
+	body := []byte(`[{"id":1},{"id":2}]`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign(secret, body); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", DefaultSignatureHeader},
+		{"X-Hub-Signature-256", "X-Hub-Signature-256"},
+	}
+
+	for _, tt := range tests {
+
+		var signature string
+		var received []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			signature = r.Header.Get(tt.want)
+			received, _ = io.ReadAll(r.Body)
+		}))
+
+		wh := newTestWebhook(t, server.URL, func(opts *Options) {
+			opts.Secret = secret
+			if len(tt.header) > 0 {
+				opts.SignatureHeader = tt.header
+			}
+		})
+
+		if err := wh.Post(context.Background(), body, nil); err != nil {
+			t.Fatalf("Post: %v", err)
+		}
+
+		server.Close()
+
+		// Receiver verifies signature of the body it received
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(received)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			t.Errorf("%s = %q, want %q", tt.want, signature, expected)
+		}
+	}
+}
+
+func TestPostRetry(t *testing.T) {
+
+	tests := []struct {
+		failures int32
+		retries  int
+		ok       bool
+	}{
+		{0, 3, true},
+		{2, 3, true},
+		{3, 3, true},
+		{4, 3, false},
+	}
+
+	for _, tt := range tests {
+
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) <= tt.failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}))
+
+		wh := newTestWebhook(t, server.URL, nil)
+
+		opts := hook.NewOptions()
+		opts.Name = "Request"
+		opts.Retries = tt.retries
+		opts.Backoff = 10 * time.Millisecond
+		opts.MaxBackoff = 20 * time.Millisecond
+		opts.Stderr = io.Discard
+
+		executor := hook.New(opts)
+
+		result := make(chan error, 1)
+		startTime := time.Now()
+		executor.Submit(&hook.Job{
+			Run: func(ctx context.Context) error {
+				return wh.Post(ctx, []byte(`{}`), nil)
+			},
+			Done: func(err error) {
+				result <- err
+			},
+		})
+
+		err := <-result
+		elapsed := time.Since(startTime)
+		executor.Close()
+		server.Close()
+
+		if tt.ok && err != nil {
+			t.Errorf("%d failures: unexpected error %v", tt.failures, err)
+		}
+
+		if !tt.ok && !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("%d failures: expected ErrUnexpectedStatus, got %v", tt.failures, err)
+		}
+
+		// Attempts stop at the first success or after all retries
+		want := tt.failures + 1
+		if want > int32(tt.retries)+1 {
+			want = int32(tt.retries) + 1
+		}
+
+		if got := atomic.LoadInt32(&attempts); got != want {
+			t.Errorf("%d failures: %d attempts, want %d", tt.failures, got, want)
+		}
+
+		// Backoff doubles from 10ms and is capped at 20ms
+		var backoff time.Duration
+		for i, d := 0, 10*time.Millisecond; i < int(want)-1; i++ {
+			backoff += d
+			if d *= 2; d > 20*time.Millisecond {
+				d = 20 * time.Millisecond
+			}
+		}
+
+		if elapsed < backoff {
+			t.Errorf("%d failures: finished in %s, expected backoff of %s", tt.failures, elapsed, backoff)
+		}
+	}
+}